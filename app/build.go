@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/mohae/contour"
 	"github.com/mohae/feedlot/conf"
@@ -72,6 +73,15 @@ func buildPackerTemplateFromDistro() (string, error) {
 		log.Errorf("%s: %s", d, err)
 		return "", err
 	}
+	// For dry runs, only show what would change.
+	if contour.GetBool(conf.DryRun) {
+		diff, err := pTpl.diff(rTpl.IODirInf, rTpl.BuildInf, rTpl.Dirs, rTpl.Files)
+		if err != nil {
+			log.Errorf("%s: %s", d, err)
+			return "", err
+		}
+		return diff.String(), nil
+	}
 	// Create the JSON version of the Packer template. This also handles
 	// creation of the build directory and copying all files that the Packer
 	// template needs to the build directory.
@@ -90,7 +100,7 @@ func buildPackerTemplateFromDistro() (string, error) {
 // message providing information about the processing of the requested builds
 // or an error.
 func BuildBuilds(buildNames ...string) (string, error) {
-	if buildNames[0] == "" {
+	if len(buildNames) == 0 || buildNames[0] == "" {
 		err := fmt.Errorf("build builds failed: no build names were received")
 		log.Error(err)
 		return "", err
@@ -113,6 +123,11 @@ func BuildBuilds(buildNames ...string) (string, error) {
 		log.Error(err)
 		return "", err
 	}
+	// Matrix builds are built as the builds they were expanded into.
+	buildNames = expandBuildNames(buildNames)
+	if contour.GetBool(conf.DryRun) {
+		return dryRunBuilds(buildNames...)
+	}
 	// Make as many channels as there are build requests.  A channel per build
 	// is fine for now.  If a large number of builds needs to be supported,
 	// switching to a queue and worker pool would be a better choice.
@@ -160,29 +175,65 @@ func buildPackerTemplateFromNamedBuild(name string, doneCh chan error) {
 	}
 	log.Infof("%s: start creation of packer template", name)
 	defer log.Infof("%s: end creation of packer template", name)
-	var ok bool
-	// Check the type and create the defaults for that type, if it doesn't already exist.
-	bTpl, err := getBuildTemplate(name)
+	rTpl, err := buildRawTemplate(name)
 	if err != nil {
-		doneCh <- fmt.Errorf("%s: build failed: %s", name, err)
+		err = Error{name, err}
+		log.Error(err)
+		doneCh <- err
 		return
 	}
-	// See if the distro default exists.
-	rTpl := RawTemplate{}
-	rTpl, ok = DistroDefaults.Templates[ParseDistro(bTpl.Distro)]
-	if !ok {
-		err := fmt.Errorf("%s: %s: not a supported distro", name, bTpl.Distro)
+	pTpl, err := rTpl.createPackerTemplate()
+	if err != nil {
+		err = Error{name, err}
 		log.Error(err)
 		doneCh <- err
 		return
 	}
-	// TODO: this is probably where the merging of parent build would occur
-	rTpl.Name = name
-	err = rTpl.updateBuildSettings(bTpl)
+	err = pTpl.create(rTpl.IODirInf, rTpl.BuildInf, rTpl.Dirs, rTpl.Files)
 	if err != nil {
 		err = Error{name, err}
 		log.Error(err)
 		doneCh <- err
+		return
+	}
+	doneCh <- nil
+	return
+}
+
+// buildRawTemplate returns the raw template for the named build: the build's
+// distro defaults with the settings of the build's parents, if it extends any,
+// and then the build template's settings merged into them.  The distro
+// defaults are copied so the returned template can be modified without
+// affecting other builds.
+func buildRawTemplate(name string) (*RawTemplate, error) {
+	chain, err := getBuildChain(name)
+	if err != nil {
+		return nil, err
+	}
+	distro := chainDistro(chain)
+	// See if the distro default exists; builds in an env use the env's
+	// defaults, if it has any.
+	rTpl, err := DistroDefaults.getEnvTemplate(chain[len(chain)-1].env, distro)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a supported distro", distro)
+	}
+	rTpl.Name = name
+	// The parents are merged, in order, before the build; the relative dirs
+	// are resolved once everything has been merged.
+	for _, bTpl := range chain {
+		if len(chain) > 1 {
+			log.Debugf("%s: merge settings from %s", name, bTpl.BuildName)
+		}
+		err = rTpl.mergeBuildSettings(bTpl)
+		if err != nil {
+			return nil, err
+		}
+		rTpl.chain = append(rTpl.chain, bTpl.BuildName)
+	}
+	rTpl.updateSourceDirSetting()
+	err = rTpl.updateTemplateOutputDirSetting()
+	if err != nil {
+		return nil, err
 	}
 	if contour.GetBool(conf.Example) {
 		log.Debugf("%s: using examples", name)
@@ -190,20 +241,58 @@ func buildPackerTemplateFromNamedBuild(name string, doneCh chan error) {
 		rTpl.ExampleDir = contour.GetString(conf.ExampleDir)
 		rTpl.setExampleDirs()
 	}
+	return rTpl, nil
+}
+
+// chainDistro returns the distro of the build whose chain is passed; a build's
+// distro is inherited from its closest parent that has one.
+func chainDistro(chain []*RawTemplate) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Distro != "" {
+			return chain[i].Distro
+		}
+	}
+	return ""
+}
+
+// dryRunBuilds generates the Packer template for each of the passed builds,
+// in memory, and returns the differences between them, and their resources,
+// and what is currently in their template output directories.  Nothing on
+// disk is changed.  Like BuildBuilds, an error with a build doesn't stop the
+// processing of the other builds; the error is included in the results.
+func dryRunBuilds(buildNames ...string) (string, error) {
+	var errorCount int
+	diffs := make([]string, 0, len(buildNames))
+	for _, name := range buildNames {
+		diff, err := dryRunNamedBuild(name)
+		if err != nil {
+			log.Error(err)
+			diffs = append(diffs, err.Error())
+			errorCount++
+			continue
+		}
+		diffs = append(diffs, diff.String())
+	}
+	log.Infof("dry run: %d builds, %d errors", len(buildNames), errorCount)
+	return strings.Join(diffs, "\n"), nil
+}
+
+// dryRunNamedBuild generates the Packer template for the passed build and
+// returns what would change if it were built.
+func dryRunNamedBuild(name string) (TemplateDiff, error) {
+	log.Infof("%s: start dry run of packer template", name)
+	defer log.Infof("%s: end dry run of packer template", name)
+	rTpl, err := buildRawTemplate(name)
+	if err != nil {
+		return TemplateDiff{}, Error{name, err}
+	}
 	pTpl, err := rTpl.createPackerTemplate()
 	if err != nil {
-		err = Error{name, err}
-		log.Error(err)
-		doneCh <- err
-		return
+		return TemplateDiff{}, Error{name, err}
 	}
-	err = pTpl.create(rTpl.IODirInf, rTpl.BuildInf, rTpl.Dirs, rTpl.Files)
+	diff, err := pTpl.diff(rTpl.IODirInf, rTpl.BuildInf, rTpl.Dirs, rTpl.Files)
 	if err != nil {
-		err = Error{name, err}
-		log.Error(err)
-		doneCh <- err
-		return
+		return TemplateDiff{}, Error{name, err}
 	}
-	doneCh <- nil
-	return
+	return diff, nil
 }
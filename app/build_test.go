@@ -8,6 +8,7 @@ import (
 	"unsafe"
 
 	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
 )
 
 func TestBuildPackerTemplateFromDistros(t *testing.T) {
@@ -278,3 +279,82 @@ func CompareStringSliceElements(new, old []string) (msg string, ok bool) {
 	}
 	return "", true
 }
+
+// TestBuildExamples creates the Packer template of every example build, in
+// each of the example conf formats.
+func TestBuildExamples(t *testing.T) {
+	example := contour.GetBool(conf.Example)
+	exampleDir := contour.GetString(conf.ExampleDir)
+	dir := contour.GetString(conf.Dir)
+	format := contour.GetString(conf.Format)
+	defer func() {
+		contour.UpdateBool(conf.Example, example)
+		contour.UpdateString(conf.ExampleDir, exampleDir)
+		contour.UpdateString(conf.Dir, dir)
+		contour.UpdateString(conf.Format, format)
+		DistroDefaults = distroDefaults{}
+		BuildDefs = map[string]Builds{}
+	}()
+	contour.UpdateBool(conf.Example, true)
+	contour.UpdateString(conf.ExampleDir, "../example")
+	for _, f := range []string{"json", "toml", "yaml"} {
+		contour.UpdateString(conf.Dir, "conf/"+f)
+		contour.UpdateString(conf.Format, f)
+		DistroDefaults = distroDefaults{}
+		BuildDefs = map[string]Builds{}
+		err := DistroDefaults.Set()
+		if err != nil {
+			t.Errorf("%s: expected no error, got %q", f, err)
+			continue
+		}
+		err = loadBuilds()
+		if err != nil {
+			t.Errorf("%s: expected no error, got %q", f, err)
+			continue
+		}
+		names := allBuildNames()
+		if len(names) == 0 {
+			t.Errorf("%s: expected example builds, got none", f)
+		}
+		for _, name := range names {
+			r, err := buildRawTemplate(name)
+			if err != nil {
+				t.Errorf("%s: %s: expected no error, got %q", f, name, err)
+				continue
+			}
+			// the ISO information is looked up online; use stand-in values.
+			setTestISOInfo(r)
+			p, err := r.createPackerTemplate()
+			if err != nil {
+				t.Errorf("%s: %s: expected no error, got %q", f, name, err)
+				continue
+			}
+			for _, e := range p.undeclaredUserVariables() {
+				t.Errorf("%s: %s: expected user variables to be declared, got %q", f, name, e)
+			}
+		}
+	}
+}
+
+// setTestISOInfo sets the ISO information of the template's distro so that
+// it doesn't get looked up.
+func setTestISOInfo(r *RawTemplate) {
+	rel := release{
+		ISO:     ISO{ReleaseURL: "http://example.com/", Checksum: "0123456789abcdef", ChecksumType: "sha256", Name: "test.iso"},
+		Arch:    r.Arch,
+		Distro:  r.Distro,
+		Image:   r.Image,
+		Release: r.Release,
+	}
+	switch r.Distro {
+	case CentOS.String():
+		r.ReleaseISO = &centos{release: rel}
+		r.OSType = "RedHat_64"
+	case Debian.String():
+		r.ReleaseISO = &debian{release: rel}
+		r.OSType = "Debian_64"
+	case Ubuntu.String():
+		r.ReleaseISO = &ubuntu{release: rel}
+		r.OSType = "Ubuntu_64"
+	}
+}
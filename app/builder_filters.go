@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrOnlyExcept occurs when a component has both an only and an except list;
+// Packer doesn't allow both.
+var ErrOnlyExcept = errors.New("only and except are mutually exclusive")
+
+// builderFilters returns the settings that restrict a provisioner or
+// post-processor to some of the build's builders: the only and except
+// lists and, if override is true, the per-builder overrides.  They are read
+// from the component's arrays.  Each builder they refer to must be in the
+// build's builder_ids; a builder is referred to by its ID, which is either
+// its type or the name it is given in the Packer template.
+//
+// The overrides are an object whose keys are builder IDs and whose values
+// are objects of the settings to use with that builder.  Feedlot variables in
+// the overrides' string values are replaced.
+func (r *RawTemplate) builderFilters(arrays map[string]interface{}, override bool) (map[string]interface{}, error) {
+	filters := map[string]interface{}{}
+	for _, name := range []string{"only", "except"} {
+		v, ok := arrays[name]
+		if !ok || v == nil {
+			continue
+		}
+		IDs, err := r.filterBuilderIDs(name, v)
+		if err != nil {
+			return nil, err
+		}
+		filters[name] = IDs
+	}
+	if _, ok := filters["only"]; ok {
+		if _, ok := filters["except"]; ok {
+			return nil, ErrOnlyExcept
+		}
+	}
+	if !override {
+		return filters, nil
+	}
+	v, ok := arrays["override"]
+	if !ok || v == nil {
+		return filters, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, SettingTypeErr{Path: "override", Expected: "an object", Got: settingTypeName(v)}
+	}
+	// sorted so that the first invalid builder is always the one reported.
+	IDs := make([]string, 0, len(m))
+	for ID := range m {
+		IDs = append(IDs, ID)
+	}
+	sort.Strings(IDs)
+	overrides := make(map[string]interface{}, len(m))
+	for _, ID := range IDs {
+		if !contains(r.BuilderIDs, ID) {
+			return nil, Error{slug: "override", err: fmt.Errorf("%s: builder not in builder_ids", ID)}
+		}
+		settings, ok := m[ID].(map[string]interface{})
+		if !ok {
+			return nil, SettingTypeErr{Path: "override." + ID, Expected: "an object", Got: settingTypeName(m[ID])}
+		}
+		o := make(map[string]interface{}, len(settings))
+		for k, val := range settings {
+			if s, ok := val.(string); ok {
+				val = r.replaceSettingVars("override."+ID+"."+k, s)
+			}
+			o[k] = val
+		}
+		overrides[ID] = o
+	}
+	filters["override"] = overrides
+	return filters, nil
+}
+
+// filterBuilderIDs returns the builder IDs in the list, v, of the filter,
+// name.  Each ID must be in the build's builder_ids.
+func (r *RawTemplate) filterBuilderIDs(name string, v interface{}) ([]string, error) {
+	var IDs []string
+	switch l := v.(type) {
+	case []string:
+		IDs = make([]string, len(l))
+		copy(IDs, l)
+	case []interface{}:
+		IDs = make([]string, 0, len(l))
+		for i, x := range l {
+			s, ok := x.(string)
+			if !ok {
+				return nil, SettingTypeErr{Path: fmt.Sprintf("%s[%d]", name, i), Expected: "a builder ID", Got: settingTypeName(x)}
+			}
+			IDs = append(IDs, s)
+		}
+	default:
+		return nil, SettingTypeErr{Path: name, Expected: "a list of builder IDs", Got: settingTypeName(v)}
+	}
+	for _, ID := range IDs {
+		if !contains(r.BuilderIDs, ID) {
+			return nil, Error{slug: name, err: fmt.Errorf("%s: builder not in builder_ids", ID)}
+		}
+	}
+	return IDs, nil
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilderFilters(t *testing.T) {
+	r := newRawTemplate()
+	r.BuilderIDs = []string{"virtualbox-iso", "vmware"}
+	tests := []struct {
+		arrays   map[string]interface{}
+		override bool
+		expected map[string]interface{}
+		err      string
+	}{
+		{nil, true, map[string]interface{}{}, ""},
+		{
+			map[string]interface{}{"only": []interface{}{"virtualbox-iso"}, "scripts": []interface{}{"setup.sh"}},
+			true,
+			map[string]interface{}{"only": []string{"virtualbox-iso"}},
+			"",
+		},
+		{
+			map[string]interface{}{"except": []string{"vmware"}},
+			false,
+			map[string]interface{}{"except": []string{"vmware"}},
+			"",
+		},
+		{
+			map[string]interface{}{
+				"override": map[string]interface{}{
+					"vmware": map[string]interface{}{"execute_command": "sudo sh '{{.Path}}'", "pause_before": "10s"},
+				},
+			},
+			true,
+			map[string]interface{}{
+				"override": map[string]interface{}{
+					"vmware": map[string]interface{}{"execute_command": "sudo sh '{{.Path}}'", "pause_before": "10s"},
+				},
+			},
+			"",
+		},
+		{
+			map[string]interface{}{"override": map[string]interface{}{"vmware": map[string]interface{}{}}},
+			false,
+			map[string]interface{}{},
+			"",
+		},
+		{map[string]interface{}{"only": []interface{}{"vmware-iso"}}, true, nil, "only: vmware-iso: builder not in builder_ids"},
+		{map[string]interface{}{"except": []interface{}{"vmware", 1.0}}, true, nil, "except[1]: expected a builder ID, got number"},
+		{map[string]interface{}{"only": "vmware"}, true, nil, "only: expected a list of builder IDs, got string"},
+		{
+			map[string]interface{}{"only": []string{"vmware"}, "except": []string{"virtualbox-iso"}},
+			true,
+			nil,
+			"only and except are mutually exclusive",
+		},
+		{
+			map[string]interface{}{"override": map[string]interface{}{"qemu": map[string]interface{}{}}},
+			true,
+			nil,
+			"override: qemu: builder not in builder_ids",
+		},
+		{
+			map[string]interface{}{"override": map[string]interface{}{"vmware": "sudo"}},
+			true,
+			nil,
+			"override.vmware: expected an object, got string",
+		},
+	}
+	for i, test := range tests {
+		filters, err := r.builderFilters(test.arrays, test.override)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(filters, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, filters)
+		}
+	}
+}
+
+func TestCreateBuilderName(t *testing.T) {
+	r := newRawTemplate()
+	r.Name = "test"
+	r.BuilderIDs = []string{"null", "local"}
+	r.Builders = map[string]BuilderC{
+		"common": {TemplateSection{Type: "common", Settings: []string{"communicator = ssh", "ssh_host = 127.0.0.1", "ssh_username = vagrant", "ssh_password = vagrant"}}},
+		"null":   {TemplateSection{Type: "null"}},
+		"local":  {TemplateSection{Type: "null"}},
+	}
+	tests := []struct {
+		ID   string
+		name interface{}
+	}{
+		{"null", nil},
+		{"local", "local"},
+	}
+	for i, test := range tests {
+		settings, err := r.createBuilder(test.ID)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if settings["name"] != test.name {
+			t.Errorf("%d: expected name to be %v, got %v", i, test.name, settings["name"])
+		}
+	}
+}
+
+func TestCreateProvisionerBuilderFilters(t *testing.T) {
+	r := newRawTemplate()
+	r.Name = "test"
+	r.BuilderIDs = []string{"vbox", "vmware-iso"}
+	r.Provisioners = map[string]ProvisionerC{
+		"guest-additions": {TemplateSection{
+			Type:     "shell",
+			Settings: []string{"execute_command = sh '{{.Path}}'"},
+			Arrays: map[string]interface{}{
+				"inline": []string{"mount /dev/cdrom"},
+				"only":   []interface{}{"vbox"},
+				"override": map[string]interface{}{
+					"vbox": map[string]interface{}{"execute_command": "sudo sh '{{.Path}}'"},
+				},
+			},
+		}},
+	}
+	settings, err := r.createProvisioner("guest-additions")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if !reflect.DeepEqual(settings["only"], []string{"vbox"}) {
+		t.Errorf("expected only to be %v, got %#v", []string{"vbox"}, settings["only"])
+	}
+	expected := map[string]interface{}{"vbox": map[string]interface{}{"execute_command": "sudo sh '{{.Path}}'"}}
+	if !reflect.DeepEqual(settings["override"], expected) {
+		t.Errorf("expected override to be %#v, got %#v", expected, settings["override"])
+	}
+	r.BuilderIDs = []string{"virtualbox-iso", "vmware-iso"}
+	_, err = r.createProvisioner("guest-additions")
+	if err == nil {
+		t.Error("expected an error, got none")
+	} else if err.Error() != "shell: guest-additions: only: vbox: builder not in builder_ids" {
+		t.Errorf("expected %q, got %q", "shell: guest-additions: only: vbox: builder not in builder_ids", err)
+	}
+}
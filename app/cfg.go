@@ -10,8 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
 	cjsn "github.com/mohae/cjson"
 	"github.com/mohae/contour"
 	"github.com/mohae/deepcopy"
@@ -46,6 +49,17 @@ type Build struct {
 	ProvisionerIDs []string `toml:"provisioner_ids" json:"provisioner_ids"`
 	// A map of {rovisioner configurations.
 	Provisioners map[string]ProvisionerC `toml:"provisioners" json:"provisioners"`
+	// Packer user variables in "name=default" format.  These are added to
+	// the Packer template's variables and can be referenced by settings, e.g.
+	// {{user `name`}}.
+	Variables []string `toml:"variables" json:"variables"`
+	// The names of the variables whose values are sensitive.  These are
+	// added to the Packer template's sensitive-variables.
+	SensitiveVariables []string `toml:"sensitive_variables" json:"sensitive_variables"`
+	// Feedlot variables in "name=value" format.  They are referenced like
+	// the other Feedlot variables, e.g. :name, and their values can reference
+	// other variables, including environment variables, e.g. :env.HTTP_PROXY.
+	Vars []string `toml:"vars" json:"vars"`
 }
 
 // Copy makes a deep copy of the Build and returns it.
@@ -82,36 +96,89 @@ type TemplateSection struct {
 	// Type is the actual Packer component type, this may or may not be the
 	// same as the map key (ID).
 	Type string `toml:"type" json:"type"`
-	// Settings are string settings in "key=value" format.
+	// Settings are string settings in "key=value" format.  In the conf files
+	// they can also be an object of native values; see setSettings.
 	Settings []string `toml:"settings" json:"settings"`
 	// Arrays are the string array settings.
 	Arrays map[string]interface{} `toml:"arrays" json:"arrays"`
+	// Merge is the merge strategy of each of the section's arrays, keyed by
+	// the array's name.  The strategy determines how the array is merged with
+	// the array of the same name from the section it is being merged into,
+	// e.g. a build's with the defaults'.  If an array doesn't have a strategy,
+	// it replaces the existing array.
+	Merge map[string]string `toml:"merge,omitempty" json:"merge,omitempty"`
+	// Passthrough is whether or not the section's settings and arrays are
+	// written to the Packer template as they are, instead of being processed
+	// as its type's; see createPassthrough.  This allows components that
+	// Feedlot doesn't support, e.g. plugins, to be used.
+	Passthrough bool `toml:"passthrough,omitempty" json:"passthrough,omitempty"`
+	// Resources are the names of a passthrough section's settings and arrays
+	// whose values are the paths of resources.  They are found and copied
+	// like the resources of the supported components are.
+	Resources []string `toml:"resources,omitempty" json:"resources,omitempty"`
+	// rawSettings are the settings as decoded, either a list of key=value
+	// strings or an object of native values, until setSettings resolves them.
+	rawSettings interface{}
 }
 
-// mergeArrays merges the received array with the current one.
-func (t *TemplateSection) mergeArrays(n map[string]interface{}) {
+// mergeArrays merges the received arrays with the current ones.  How each
+// array is merged depends on its merge strategy, in strategies; by default,
+// the new array replaces the current one.  See mergeArray for the strategies.
+// Once merged, the section's arrays are resolved so its own strategies, if
+// any, are cleared.
+func (t *TemplateSection) mergeArrays(n map[string]interface{}, strategies map[string]string) error {
+	t.Merge = nil
+	for k := range strategies {
+		if _, ok := n[k]; !ok {
+			return Error{slug: "merge." + k, err: errors.New("no array to merge")}
+		}
+	}
 	if n == nil {
-		return
+		return nil
 	}
-	if t.Arrays == nil {
+	if t.Arrays == nil && len(strategies) == 0 {
 		t.Arrays = n
-		return
-	}
-	// both are populated, merge them.
-	merged := map[string]interface{}{}
-	// Get the all keys from both maps
-	keys := mergeKeysFromMaps(t.Arrays, n)
-	// Process using the keys.
-	for _, v := range keys {
-		// If the element for this key doesn't exist in new, add old.
-		if _, ok := n[v]; !ok {
-			merged[v] = t.Arrays[v]
+		return nil
+	}
+	merged := make(map[string]interface{}, len(t.Arrays)+len(n))
+	for k, v := range t.Arrays {
+		merged[k] = v
+	}
+	for k, v := range n {
+		old, ok := t.Arrays[k]
+		m, keep, err := mergeArray(old, ok, v, strategies[k])
+		if err != nil {
+			return Error{slug: "arrays." + k, err: err}
+		}
+		if !keep {
+			delete(merged, k)
 			continue
 		}
-		// Otherwise use the new value
-		merged[v] = n[v]
+		merged[k] = m
 	}
 	t.Arrays = merged
+	return nil
+}
+
+// mergePassthrough merges the passthrough settings of the received section, n,
+// with the current ones.  A section that is passthrough stays passthrough;
+// n's resources, if it has any, replace the current ones.
+func (t *TemplateSection) mergePassthrough(n TemplateSection) {
+	if n.Passthrough {
+		t.Passthrough = true
+	}
+	if n.Resources != nil {
+		t.Resources = n.Resources
+	}
+}
+
+// resolveArrays applies the section's merge strategies to its own arrays, as
+// if they were being merged into a section without any arrays.  This is used
+// for sections that don't have anything to be merged into.
+func (t *TemplateSection) resolveArrays() error {
+	n, strategies := t.Arrays, t.Merge
+	t.Arrays = nil
+	return t.mergeArrays(n, strategies)
 }
 
 // BuilderC represents a builder component of a Packer template.
@@ -391,6 +458,12 @@ func (i *IODirInf) check() {
 type PackerInf struct {
 	MinPackerVersion string `toml:"min_packer_version" json:"min_packer_version"`
 	Description      string `toml:"description" json:"description"`
+	// TemplateFormat is the format of the Packer template that gets written:
+	// json or hcl2.  If it isn't set, the template_format setting is used.
+	TemplateFormat string `toml:"template_format" json:"template_format"`
+	// VarFile is whether or not a var-file, with the default value of each
+	// of the template's variables, is written with the Packer template.
+	VarFile *bool `toml:"var_file" json:"var_file"`
 }
 
 func (p *PackerInf) update(v PackerInf) {
@@ -400,6 +473,12 @@ func (p *PackerInf) update(v PackerInf) {
 	if v.Description != "" {
 		p.Description = v.Description
 	}
+	if v.TemplateFormat != "" {
+		p.TemplateFormat = v.TemplateFormat
+	}
+	if v.VarFile != nil {
+		p.VarFile = v.VarFile
+	}
 }
 
 // Defaults is used to store Feedlot application level defaults for Packer templates.
@@ -409,6 +488,8 @@ type Defaults struct {
 	BuildInf
 	Build
 	loaded bool `toml:"-"`
+	// file is the name of the file the defaults were loaded from.
+	file string
 }
 
 // Load loads the default settings. If the defaults have already been loaded
@@ -425,6 +506,12 @@ func (d *Defaults) Load(p string) error {
 		log.Error(err)
 		return err
 	}
+	return d.load(name, format)
+}
+
+// load loads the default settings from the named file, which is in the
+// passed format.
+func (d *Defaults) load(name string, format conf.ConfFormat) error {
 	switch format {
 	case conf.TOML:
 		log.Debug("load defaults: using toml")
@@ -436,8 +523,7 @@ func (d *Defaults) Load(p string) error {
 		}
 	case conf.JSON:
 		log.Debug("load defaults: using json")
-		var buff []byte
-		buff, err = ioutil.ReadFile(name)
+		buff, err := ioutil.ReadFile(name)
 		if err != nil {
 			err = fmt.Errorf("load defaults: %s: %s", name, err)
 			log.Error(err)
@@ -449,12 +535,33 @@ func (d *Defaults) Load(p string) error {
 			log.Error(err)
 			return err
 		}
+	case conf.YAML:
+		log.Debug("load defaults: using yaml")
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			err = fmt.Errorf("load defaults: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+		err = yaml.Unmarshal(buff, &d)
+		if err != nil {
+			err = fmt.Errorf("load defaults: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
 	default:
-		err := fmt.Errorf("load defaults: %s: %s", contour.GetString(conf.Format), conf.ErrUnsupportedFormat)
+		err := fmt.Errorf("load defaults: %s: %s", name, conf.ErrUnsupportedFormat)
+		log.Error(err)
+		return err
+	}
+	err := d.Build.setSettings("")
+	if err != nil {
+		err = fmt.Errorf("load defaults: %s: %s", name, err)
 		log.Error(err)
 		return err
 	}
 	d.Build.setTypes()
+	d.file = name
 	d.loaded = true
 	log.Info("defaults successfully loaded")
 	return nil
@@ -493,6 +600,8 @@ type SupportedDistro struct {
 type SupportedDistros struct {
 	Distros map[string]*SupportedDistro
 	loaded  bool
+	// file is the name of the file the supported distros were loaded from.
+	file string
 }
 
 // Load the supported distro info.
@@ -528,11 +637,34 @@ func (s *SupportedDistros) Load(p string) error {
 			log.Error(err)
 			return err
 		}
+	case conf.YAML:
+		log.Debug("load supported distros: yaml")
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			err = fmt.Errorf("load supported: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+		err = yaml.Unmarshal(buff, &s.Distros)
+		if err != nil {
+			err = fmt.Errorf("load supported: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
 	default:
 		err := fmt.Errorf("load supported: %s: %s", name, conf.ErrUnsupportedFormat)
 		log.Error(err)
 		return err
 	}
+	for k, v := range s.Distros {
+		err = v.Build.setSettings(k)
+		if err != nil {
+			err = fmt.Errorf("load supported: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+	}
+	s.file = name
 	s.loaded = true
 	log.Info("supported distros loaded")
 	return nil
@@ -542,6 +674,9 @@ func (s *SupportedDistros) Load(p string) error {
 type Builds struct {
 	Templates map[string]*RawTemplate
 	loaded    bool
+	// env is the name of the environment the builds were loaded from; it is
+	// empty for the builds in the conf dir.
+	env string
 }
 
 // Load the build information from the provided name.
@@ -552,7 +687,8 @@ func (b *Builds) Load(name string) error {
 		log.Error(err)
 		return err
 	}
-	switch conf.ParseConfFormat(contour.GetString(conf.Format)) {
+	// The file's extension determines its format.
+	switch conf.FileFormat(name) {
 	case conf.TOML:
 		log.Debugf("load build %s: toml", name)
 		_, err := toml.DecodeFile(name, &b.Templates)
@@ -575,13 +711,46 @@ func (b *Builds) Load(name string) error {
 			log.Error(err)
 			return err
 		}
+	case conf.YAML:
+		log.Debugf("load build %s: yaml", name)
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			err = fmt.Errorf("load build %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+		err = yaml.Unmarshal(buff, &b.Templates)
+		if err != nil {
+			err = fmt.Errorf("load build %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
 	default:
 		err := fmt.Errorf("load build %s: %s", name, conf.ErrUnsupportedFormat)
 		log.Error(err)
 		return err
 	}
-	for _, v := range b.Templates {
+	for k, v := range b.Templates {
+		err := v.Build.setSettings(k)
+		if err != nil {
+			err = fmt.Errorf("load build %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
 		v.Build.setTypes()
+		if v.Matrix == nil {
+			continue
+		}
+		for i := range v.Matrix.Override {
+			o := &v.Matrix.Override[i]
+			err := o.Build.setSettings(fmt.Sprintf("%s.matrix.override[%d]", k, i))
+			if err != nil {
+				err = fmt.Errorf("load build %s: %s", name, err)
+				log.Error(err)
+				return err
+			}
+			o.Build.setTypes()
+		}
 	}
 	b.loaded = true
 	log.Infof("laod builds done: %s", name)
@@ -593,11 +762,17 @@ func (b *Builds) Load(name string) error {
 func getBuildTemplate(name string) (*RawTemplate, error) {
 	var r *RawTemplate
 	var err error
-	for _, blds := range BuildDefs {
+	for fname, blds := range BuildDefs {
 		for n, bTpl := range blds.Templates {
 			if n == name {
 				r = bTpl.Copy()
 				r.BuildName = name
+				r.env = blds.env
+				r.setOrigin(BuildLayer, fname)
+				for _, o := range r.MatrixOverrides {
+					o.env = blds.env
+					o.setOrigin(BuildLayer, fname)
+				}
 				goto found
 			}
 		}
@@ -610,12 +785,77 @@ found:
 	return r, nil
 }
 
+// getBuildChain returns the named build's template preceded by the templates
+// of all of the builds it extends, in the order they are to be merged: a
+// build's parents are merged before it, in the order they are listed, and each
+// parent's own parents are merged before the parent.  The matrix overrides of
+// a build that was generated from a matrix build are merged right after it.  A build that appears
+// more than once in the chain is only merged the first time.  An error is
+// returned if a build in the chain doesn't exist or if a build extends itself,
+// directly or through its parents.
+func getBuildChain(name string) ([]*RawTemplate, error) {
+	var chain []*RawTemplate
+	merged := map[string]bool{}
+	var resolve func(name string, path []string) error
+	resolve = func(name string, path []string) error {
+		for i, n := range path {
+			if n == name {
+				cycle := append(append([]string{}, path[i:]...), name)
+				return fmt.Errorf("extends cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		if merged[name] {
+			return nil
+		}
+		bTpl, err := getBuildTemplate(name)
+		if err != nil {
+			return err
+		}
+		path = append(path, name)
+		for _, parent := range bTpl.Extends {
+			err = resolve(parent, path)
+			if err != nil {
+				return err
+			}
+		}
+		merged[name] = true
+		chain = append(chain, bTpl)
+		chain = append(chain, bTpl.MatrixOverrides...)
+		return nil
+	}
+	err := resolve(name, nil)
+	if err != nil {
+		log.Errorf("%s: %s", name, err)
+		return nil, err
+	}
+	return chain, nil
+}
+
+// allBuildNames returns the names of all the builds in BuildDefs, sorted.
+// Matrix builds aren't included; the builds they were expanded into are.
+func allBuildNames() []string {
+	var names []string
+	for _, blds := range BuildDefs {
+		for n, tpl := range blds.Templates {
+			if tpl.Matrix != nil {
+				continue
+			}
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Contains lists of builds.
 type BuildLists struct {
 	Lists map[string]List
 }
 
-// A List contains 1 or more builds.
+// A List contains 1 or more builds.  Each entry is either a build's name, a
+// glob pattern that is matched against the build names, e.g. ubuntu-*, a
+// selector expression, e.g. distro=ubuntu,tag=ci, or a reference to another
+// list, e.g. @ubuntu.
 type List struct {
 	Builds []string
 }
@@ -631,25 +871,64 @@ func (b *BuildLists) Load(p string) error {
 		log.Error(err)
 		return err
 	}
+	err = b.load(name, format)
+	if err != nil {
+		return err
+	}
+	// Each env's build lists are layered over the ones already loaded: a
+	// list with the same name as an existing list replaces it.
+	for _, env := range envs() {
+		name, format := envConfFile(env, "build_list")
+		if name == "" {
+			continue
+		}
+		log.Debugf("load build lists for env %s", env)
+		err = b.load(name, format)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("load build lists from %s: done", p)
+	return nil
+}
+
+// load loads the build lists in the named file, which is in the passed
+// format, and adds them to the build lists.  Any existing list with the same
+// name as a loaded list is replaced.
+func (b *BuildLists) load(name string, format conf.ConfFormat) error {
+	var lists map[string]List
 	switch format {
 	case conf.TOML:
-		log.Debugf("load build lists from %s: toml", p)
-		_, err := toml.DecodeFile(name, &b.Lists)
+		log.Debugf("load build lists from %s: toml", name)
+		_, err := toml.DecodeFile(name, &lists)
 		if err != nil {
 			err = fmt.Errorf("load build list: %s: %s", name, err)
 			log.Error(err)
 			return err
 		}
 	case conf.JSON:
-		log.Debugf("load build lists from %s: json", p)
-		var buff []byte
-		buff, err = ioutil.ReadFile(name)
+		log.Debugf("load build lists from %s: json", name)
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			err = fmt.Errorf("load build list: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+		err = cjsn.Unmarshal(buff, &lists)
 		if err != nil {
 			err = fmt.Errorf("load build list: %s: %s", name, err)
 			log.Error(err)
 			return err
 		}
-		err = cjsn.Unmarshal(buff, &b.Lists)
+	case conf.YAML:
+		log.Debugf("load build lists from %s: yaml", name)
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			err = fmt.Errorf("load build list: %s: %s", name, err)
+			log.Error(err)
+			return err
+		}
+		err = yaml.Unmarshal(buff, &lists)
 		if err != nil {
 			err = fmt.Errorf("load build list: %s: %s", name, err)
 			log.Error(err)
@@ -660,7 +939,12 @@ func (b *BuildLists) Load(p string) error {
 		log.Error(err)
 		return err
 	}
-	log.Infof("load build lists from %s: done", p)
+	if b.Lists == nil {
+		b.Lists = make(map[string]List, len(lists))
+	}
+	for k, v := range lists {
+		b.Lists[k] = v
+	}
 	return nil
 }
 
@@ -674,6 +958,70 @@ func (b *BuildLists) Get(s string) (List, error) {
 	return l, nil
 }
 
+// Resolve returns the names of the builds in the named build list: each of
+// the list's entries is resolved to the builds it refers to, in order.  A
+// build is only included the first time it's referred to.  Globs and
+// selectors are resolved against BuildDefs, so the builds must be loaded.  A
+// list that refers to itself, directly or through other lists, is an error.
+func (b *BuildLists) Resolve(name string) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	var resolve func(name string, path []string) error
+	resolve = func(name string, path []string) error {
+		for i, n := range path {
+			if n == name {
+				cycle := append(append([]string{}, path[i:]...), name)
+				return fmt.Errorf("build list cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		l, err := b.Get(name)
+		if err != nil {
+			return err
+		}
+		path = append(path, name)
+		for _, entry := range l.Builds {
+			var builds []string
+			switch {
+			case strings.HasPrefix(entry, ListRefPrefix):
+				err = resolve(strings.TrimPrefix(entry, ListRefPrefix), path)
+				if err != nil {
+					return err
+				}
+				continue
+			case isSelector(entry):
+				var sel Selector
+				sel, err = ParseSelector(entry)
+				if err != nil {
+					return Error{slug: name, err: err}
+				}
+				builds, err = selectBuilds(sel)
+			case isGlob(entry):
+				builds, err = globBuilds(entry)
+			default:
+				builds = []string{entry}
+			}
+			if err != nil {
+				return Error{slug: name, err: err}
+			}
+			for _, n := range builds {
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+		return nil
+	}
+	err := resolve(name, nil)
+	if err != nil {
+		log.Errorf("build list %s: %s", name, err)
+		return nil, err
+	}
+	log.Debugf("build list %s: resolved to %v", name, names)
+	return names, nil
+}
+
 // mergeKeysFromComponentMaps takes a variadic array of packer component maps
 // and returns a merged, de-duped slice of keys for those maps.
 func mergeKeysFromComponentMaps(m ...map[string]Componenter) []string {
@@ -481,7 +481,7 @@ provisionersEnd:
 
 func TestTemplateSectionMergeArrays(t *testing.T) {
 	ts := &TemplateSection{}
-	ts.mergeArrays(nil)
+	ts.mergeArrays(nil, nil)
 	if ts.Arrays != nil {
 		t.Errorf("Expected the merged array to be nil, was not nil: %#v", ts.Arrays)
 	}
@@ -531,7 +531,7 @@ func TestTemplateSectionMergeArrays(t *testing.T) {
 	}
 
 	ts.Arrays = old
-	ts.mergeArrays(nil)
+	ts.mergeArrays(nil, nil)
 	if ts.Arrays == nil {
 		t.Errorf("Expected merged to be not nil, was nil")
 	} else {
@@ -541,7 +541,7 @@ func TestTemplateSectionMergeArrays(t *testing.T) {
 	}
 
 	ts.Arrays = nil
-	ts.mergeArrays(nw)
+	ts.mergeArrays(nw, nil)
 	if ts.Arrays == nil {
 		t.Errorf("Expected merged to be not nil, was nil")
 	} else {
@@ -551,7 +551,7 @@ func TestTemplateSectionMergeArrays(t *testing.T) {
 	}
 
 	ts.Arrays = old
-	ts.mergeArrays(nw)
+	ts.mergeArrays(nw, nil)
 	if ts.Arrays == nil {
 		t.Errorf("Expected merged to be not nil, was nil")
 	} else {
@@ -693,9 +693,10 @@ func TestDefaults(t *testing.T) {
 		expectedErr string
 	}{
 		{"", "load defaults: : unsupported conf format"},
-		{"yaml", "load defaults: yaml: unsupported conf format"},
+		{"xml", "load defaults: xml: unsupported conf format"},
 		{"toml", ""},
 		{"json", ""},
+		{"yaml", ""},
 	}
 
 	contour.UpdateString(conf.Dir, "../test_files/conf")
@@ -728,9 +729,10 @@ func TestSupportedDistros(t *testing.T) {
 		expectedErr string
 	}{
 		{"", "", "load supported: : unsupported conf format"},
-		{"yaml", "", "load supported: yaml: unsupported conf format"},
+		{"xml", "", "load supported: xml: unsupported conf format"},
 		{"toml", "../test_files", ""},
 		{"json", "../test_files", ""},
+		{"yaml", "../test_files", ""},
 	}
 	for i, test := range tests {
 		contour.UpdateString(conf.Format, test.format)
@@ -764,9 +766,10 @@ func TestBuildStuff(t *testing.T) {
 		{"", "yaml", "load build: no build name specified"},
 		{"", "toml", "load build: no build name specified"},
 		{"", "json", "load build: no build name specified"},
-		{"../test_files/conf/build2.yaml", "yaml", "load build ../test_files/conf/build2.yaml: unsupported format"},
+		{"../test_files/conf/build2.xml", "xml", "load build ../test_files/conf/build2.xml: unsupported format"},
 		{"../test_files/conf/build2.toml", "toml", ""},
 		{"../test_files/conf/build2.json", "json", ""},
+		{"../test_files/conf/build2.yaml", "yaml", ""},
 	}
 	contour.UpdateString(conf.Dir, "../test_files/conf")
 	for i, test := range tests {
@@ -797,9 +800,10 @@ func TestBuildListStuff(t *testing.T) {
 		expectedErr string
 	}{
 		{"", "load build list: : : unsupported conf format"},
-		{"yaml", "load build list: : yaml: unsupported conf format"},
+		{"xml", "load build list: : xml: unsupported conf format"},
 		{"toml", ""},
 		{"json", ""},
+		{"yaml", ""},
 	}
 	contour.UpdateString(conf.Dir, "conf")
 	for i, test := range tests {
@@ -876,6 +880,7 @@ func TestFindConfigFile(t *testing.T) {
 		{"test.json", "test.json", "json", "test.cjon", conf.JSON, ""},
 		{"test.cjson", "test.cjson", "json", "test.json", conf.JSON, ""},
 		{"test.tml", "test.tml", "toml", "test.toml", conf.TOML, ""},
+		{"test.yml", "test.yml", "yaml", "test.yaml", conf.YAML, ""},
 		{"atest.toml", "test.toml", "toml", "", conf.TOML, "stat test.toml: no such file or directory"},
 		{"test.yaml", "test.yaml", "yaml", "", conf.UnsupportedConfFormat, ""},
 	}
@@ -913,3 +918,96 @@ func TestFindConfigFile(t *testing.T) {
 	os.RemoveAll(tmpDir)
 
 }
+
+func TestGetBuildChain(t *testing.T) {
+	BuildDefs = map[string]Builds{
+		"conf/build.json": {
+			Templates: map[string]*RawTemplate{
+				"base":     &RawTemplate{Distro: "ubuntu"},
+				"server":   &RawTemplate{Extends: []string{"base"}},
+				"desktop":  &RawTemplate{Extends: []string{"base"}},
+				"both":     &RawTemplate{Extends: []string{"server", "desktop"}},
+				"missing":  &RawTemplate{Extends: []string{"base", "nope"}},
+				"self":     &RawTemplate{Extends: []string{"self"}},
+				"cycle-a":  &RawTemplate{Extends: []string{"cycle-b"}},
+				"cycle-b":  &RawTemplate{Extends: []string{"base", "cycle-a"}},
+				"in-cycle": &RawTemplate{Extends: []string{"cycle-a"}},
+			},
+		},
+	}
+	defer func() { BuildDefs = map[string]Builds{} }()
+	tests := []struct {
+		name     string
+		expected []string
+		err      string
+	}{
+		{"base", []string{"base"}, ""},
+		{"server", []string{"base", "server"}, ""},
+		{"both", []string{"base", "server", "desktop", "both"}, ""},
+		{"missing", nil, "build not found: nope"},
+		{"self", nil, "extends cycle: self -> self"},
+		{"cycle-a", nil, "extends cycle: cycle-a -> cycle-b -> cycle-a"},
+		{"in-cycle", nil, "extends cycle: cycle-a -> cycle-b -> cycle-a"},
+	}
+	for i, test := range tests {
+		chain, err := getBuildChain(test.name)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected error %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected error %q, got none", i, test.err)
+			continue
+		}
+		var names []string
+		for _, r := range chain {
+			names = append(names, r.BuildName)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, names)
+		}
+	}
+}
+
+func TestConfFilenameFormat(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "feedlot-conf-format-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, name := range []string{"default.toml", "build.cjsn", "build_list.tml", "supported.json"} {
+		err = ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(""), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		findName       string
+		cfgFormat      string
+		expectedName   string
+		expectedFormat conf.ConfFormat
+	}{
+		{"default.json", "json", "default.toml", conf.TOML},
+		{"default.toml", "json", "default.toml", conf.TOML},
+		{"build.toml", "toml", "build.cjsn", conf.JSON},
+		{"build_list.json", "json", "build_list.tml", conf.TOML},
+		{"supported.toml", "toml", "supported.json", conf.JSON},
+	}
+	defer contour.UpdateString(conf.Format, "json")
+	for i, test := range tests {
+		contour.UpdateString(conf.Format, test.cfgFormat)
+		name, format, err := conf.ConfFilename(filepath.Join(tmpDir, test.findName))
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if name != filepath.Join(tmpDir, test.expectedName) {
+			t.Errorf("%d: expected %q, got %q", i, filepath.Join(tmpDir, test.expectedName), name)
+		}
+		if format != test.expectedFormat {
+			t.Errorf("%d: expected %s, got %s", i, test.expectedFormat, format)
+		}
+	}
+}
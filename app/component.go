@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ComponentFactory creates the settings of a type of Packer component.  Each
+// of the builders, post-processors, and provisioners that Feedlot supports is
+// created by a factory; other types are supported by registering a factory
+// for them, see RegisterBuilder, RegisterPostProcessor, and
+// RegisterProvisioner.
+type ComponentFactory interface {
+	// Type returns the Packer component type, e.g. virtualbox-iso.  Types
+	// are case insensitive.
+	Type() string
+	// Create returns the Packer template settings of the template's
+	// component, id, whose section is in the template's Builders,
+	// PostProcessors, or Provisioners.  The settings must include the type.
+	// The variables in the section's values can be replaced using
+	// ReplaceSettingVars.
+	Create(r *RawTemplate, id string) (map[string]interface{}, error)
+}
+
+// SettingsLister is implemented by the component factories that know all of
+// the settings and arrays that their components support.  The components of
+// a factory that doesn't implement it aren't checked for unknown settings.
+type SettingsLister interface {
+	// Settings returns the names of the settings and arrays that the
+	// factory's components support.  The builders' communicator settings and
+	// the settings that every post-processor, or provisioner, supports don't
+	// need to be included.
+	Settings() []string
+}
+
+// ErrEmptyComponentType occurs when a component factory's type is empty.
+var ErrEmptyComponentType = errors.New("empty component type")
+
+// The registered component factories, keyed by their lower cased type.  They
+// are used when the Packer templates are created, so factories must be
+// registered before then.
+var (
+	builderFactories       = map[string]ComponentFactory{}
+	postProcessorFactories = map[string]ComponentFactory{}
+	provisionerFactories   = map[string]ComponentFactory{}
+)
+
+// RegisterBuilder registers the factory of a builder type.  A type can only be
+// registered once; the common builder's type is reserved.
+func RegisterBuilder(f ComponentFactory) error {
+	if strings.ToLower(f.Type()) == Common.String() {
+		return fmt.Errorf("builder %s: reserved type", f.Type())
+	}
+	return registerComponent(builderFactories, "builder", f)
+}
+
+// RegisterPostProcessor registers the factory of a post-processor type.  A
+// type can only be registered once.
+func RegisterPostProcessor(f ComponentFactory) error {
+	return registerComponent(postProcessorFactories, "post-processor", f)
+}
+
+// RegisterProvisioner registers the factory of a provisioner type.  A type
+// can only be registered once.
+func RegisterProvisioner(f ComponentFactory) error {
+	return registerComponent(provisionerFactories, "provisioner", f)
+}
+
+// registerComponent adds the factory, f, of a kind of component to the
+// factories.
+func registerComponent(factories map[string]ComponentFactory, kind string, f ComponentFactory) error {
+	typ := strings.ToLower(f.Type())
+	if typ == "" {
+		return Error{slug: kind, err: ErrEmptyComponentType}
+	}
+	if _, ok := factories[typ]; ok {
+		return fmt.Errorf("%s %s: already registered", kind, typ)
+	}
+	if p, ok := f.(SchemaProvider); ok && p.Schema().Kind != kind {
+		return fmt.Errorf("%s %s: schema is a %s schema", kind, typ, p.Schema().Kind)
+	}
+	factories[typ] = f
+	return nil
+}
+
+// ReplaceSettingVars returns the value, v, of the component setting, k, with
+// its Feedlot variables replaced.  References to variables that aren't
+// defined are left as is; once the component's factory returns, they are an
+// error.
+func (r *RawTemplate) ReplaceSettingVars(k, v string) string {
+	return r.replaceSettingVars(k, v)
+}
+
+// init registers the factories of Feedlot's components; each is created
+// from its schema.
+func init() {
+	for _, schemas := range [][]ComponentSchema{builderSchemas, postProcessorSchemas, provisionerSchemas} {
+		for _, s := range schemas {
+			kindFactories(s.Kind)[s.Type] = schemaFactory{schema: s}
+		}
+	}
+}
@@ -0,0 +1,775 @@
+package app
+
+// The schemas of the components that Feedlot supports; every builder,
+// post-processor, and provisioner is created from its schema, see
+// component.go.
+
+// builderSchemas are the schemas of the builders.  In addition to their
+// settings, the communicator settings are supported.
+var builderSchemas = []ComponentSchema{
+	// https://packer.io/docs/builders/amazon-chroot.html
+	{
+		Kind: ComponentBuilder,
+		Type: AmazonChroot.String(),
+		Settings: []SettingSchema{
+			{Name: "access_key", Required: true},
+			{Name: "ami_description"},
+			{Name: "ami_groups", Kind: SettingArray},
+			{Name: "ami_name", Required: true},
+			{Name: "ami_product_codes", Kind: SettingArray},
+			{Name: "ami_regions", Kind: SettingArray},
+			{Name: "ami_users", Kind: SettingArray},
+			{Name: "ami_virtualization_type"},
+			{Name: "chroot_mounts", Kind: SettingArray},
+			{Name: "command_wrapper"},
+			{Name: "copy_files", Kind: SettingArray},
+			{Name: "device_path"},
+			{Name: "enhanced_networking", Kind: SettingBool},
+			{Name: "force_deregister", Kind: SettingBool},
+			{Name: "mount_options", Kind: SettingArray},
+			{Name: "mount_path"},
+			{Name: "root_volume_size", Kind: SettingInt},
+			{Name: "secret_key", Required: true},
+			{Name: "source_ami", Required: true},
+			{Name: "tags", Kind: SettingObject},
+		},
+	},
+	// https://packer.io/docs/builders/amazon-ebs.html
+	{
+		Kind: ComponentBuilder,
+		Type: AmazonEBS.String(),
+		Settings: []SettingSchema{
+			{Name: "access_key", Required: true},
+			{Name: "ami_block_device_mappings", Kind: SettingArray, convert: amiBlockDeviceMappings},
+			{Name: "ami_description"},
+			{Name: "ami_groups", Kind: SettingArray},
+			{Name: "ami_name", Required: true},
+			{Name: "ami_product_codes", Kind: SettingArray},
+			{Name: "ami_regions", Kind: SettingArray},
+			{Name: "ami_users", Kind: SettingArray},
+			{Name: "associate_public_ip_address", Kind: SettingBool},
+			{Name: "availability_zone"},
+			{Name: "enhanced_networking", Kind: SettingBool},
+			{Name: "force_deregister", Kind: SettingBool},
+			{Name: "iam_instance_profile"},
+			{Name: "instance_type", Required: true},
+			{Name: "launch_block_device_mappings", Kind: SettingArray},
+			{Name: "region", Required: true},
+			{Name: "run_tags", Kind: SettingObject},
+			{Name: "secret_key", Required: true},
+			{Name: "security_group_id"},
+			{Name: "security_group_ids", Kind: SettingArray},
+			{Name: "source_ami", Required: true},
+			{Name: "spot_price"},
+			{Name: "spot_price_auto_product"},
+			{Name: "ssh_keypair_name", Communicator: "ssh"},
+			{Name: "ssh_private_key_file", Communicator: "ssh"},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "subnet_id"},
+			{Name: "tags", Kind: SettingObject},
+			{Name: "temporary_key_pair_name"},
+			{Name: "token"},
+			{Name: "user_data"},
+			{Name: "user_data_file", Resource: true},
+			{Name: "vpc_id"},
+			{Name: "windows_password_timeout", Communicator: "winrm"},
+		},
+	},
+	// https://packer.io/docs/builders/amazon-instance.html
+	{
+		Kind: ComponentBuilder,
+		Type: AmazonInstance.String(),
+		Settings: []SettingSchema{
+			{Name: "access_key", Required: true},
+			{Name: "account_id", Required: true},
+			{Name: "ami_block_device_mappings", Kind: SettingArray, convert: amiBlockDeviceMappings},
+			{Name: "ami_description"},
+			{Name: "ami_groups", Kind: SettingArray},
+			{Name: "ami_name", Required: true},
+			{Name: "ami_product_codes", Kind: SettingArray},
+			{Name: "ami_regions", Kind: SettingArray},
+			{Name: "ami_users", Kind: SettingArray},
+			{Name: "ami_virtualization_type"},
+			{Name: "associate_public_ip_address", Kind: SettingBool},
+			{Name: "availability_zone"},
+			{Name: "bundle_destination"},
+			{Name: "bundle_prefix"},
+			{Name: "bundle_upload_command", Command: true},
+			{Name: "bundle_vol_command", Command: true},
+			{Name: "ebs_optimized", Kind: SettingBool},
+			{Name: "enhanced_networking", Kind: SettingBool},
+			{Name: "force_deregister", Kind: SettingBool},
+			{Name: "iam_instance_profile"},
+			{Name: "instance_type", Required: true},
+			{Name: "launch_block_device_mappings", Kind: SettingArray},
+			{Name: "region", Required: true},
+			{Name: "run_tags", Kind: SettingObject},
+			{Name: "s3_bucket", Required: true},
+			{Name: "secret_key", Required: true},
+			{Name: "security_group_id"},
+			{Name: "security_group_ids", Kind: SettingArray},
+			{Name: "source_ami", Required: true},
+			{Name: "spot_price"},
+			{Name: "spot_price_auto_product"},
+			{Name: "ssh_keypair_name", Communicator: "ssh"},
+			{Name: "ssh_private_ip", Kind: SettingBool, Communicator: "ssh"},
+			{Name: "ssh_private_key_file", Communicator: "ssh"},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "subnet_id"},
+			{Name: "tags", Kind: SettingObject},
+			{Name: "temporary_key_pair_name"},
+			{Name: "user_data"},
+			{Name: "user_data_file", Resource: true},
+			{Name: "vpc_id"},
+			{Name: "windows_password_timeout", Communicator: "winrm"},
+			{Name: "x509_cert_path", Required: true},
+			{Name: "x509_key_path", Required: true},
+			{Name: "x509_upload_path"},
+		},
+	},
+	// https://packer.io/docs/builders/digitalocean.html
+	{
+		Kind: ComponentBuilder,
+		Type: DigitalOcean.String(),
+		Settings: []SettingSchema{
+			{Name: "api_token", Required: true},
+			{Name: "droplet_name"},
+			{Name: "image", Required: true},
+			{Name: "private_networking", Kind: SettingBool},
+			{Name: "region", Required: true},
+			{Name: "size", Required: true},
+			{Name: "snapshot_name"},
+			{Name: "state_timeout"},
+			{Name: "user_data"},
+		},
+	},
+	// https://packer.io/docs/builders/docker.html
+	{
+		Kind: ComponentBuilder,
+		Type: Docker.String(),
+		Settings: []SettingSchema{
+			{Name: "commit", Kind: SettingBool, Required: true},
+			{Name: "discard", Kind: SettingBool, Required: true},
+			{Name: "export_path", Required: true},
+			{Name: "image", Required: true},
+			{Name: "login", Kind: SettingBool},
+			{Name: "login_email"},
+			{Name: "login_password"},
+			{Name: "login_server"},
+			{Name: "login_username"},
+			{Name: "pull", Kind: SettingBool},
+			{Name: "run_command", Kind: SettingArray, Command: true},
+			{Name: "volumes", Kind: SettingObject},
+		},
+	},
+	// https://packer.io/docs/builders/googlecompute.html
+	{
+		Kind: ComponentBuilder,
+		Type: GoogleCompute.String(),
+		Settings: []SettingSchema{
+			// the account file has the account's credentials; it isn't
+			// copied.
+			{Name: "account_file"},
+			{Name: "address"},
+			{Name: "disk_size", Kind: SettingInt},
+			{Name: "image_description"},
+			{Name: "image_name"},
+			{Name: "instance_name"},
+			{Name: "machine_type"},
+			{Name: "metadata", Kind: SettingObject},
+			{Name: "network"},
+			{Name: "preemtible", Kind: SettingBool},
+			{Name: "project_id", Required: true},
+			{Name: "source_image", Required: true},
+			{Name: "state_timeout"},
+			{Name: "tags", Kind: SettingArray},
+			{Name: "use_internal_ip", Kind: SettingBool},
+			{Name: "zone", Required: true},
+		},
+	},
+	// https://packer.io/docs/builders/null.html
+	{
+		Kind:                 ComponentBuilder,
+		Type:                 Null.String(),
+		CommunicatorRequired: true,
+	},
+	// https://packer.io/docs/builders/openstack.html
+	{
+		Kind: ComponentBuilder,
+		Type: OpenStack.String(),
+		Settings: []SettingSchema{
+			{Name: "api_key"},
+			{Name: "availability_zone"},
+			{Name: "config_drive", Kind: SettingBool},
+			{Name: "flavor", Required: true},
+			{Name: "floating_ip"},
+			{Name: "floating_ip_pool"},
+			{Name: "image_name", Required: true},
+			{Name: "insecure", Kind: SettingBool},
+			{Name: "metadata", Kind: SettingObject},
+			{Name: "networks", Kind: SettingArray},
+			{Name: "password", Required: true, CommunicatorSetting: "password"},
+			{Name: "rackconnect_wait", Kind: SettingBool},
+			{Name: "region"},
+			{Name: "security_groups", Kind: SettingArray},
+			{Name: "source_image", Required: true},
+			{Name: "ssh_interface", Communicator: "ssh"},
+			{Name: "tenant_id"},
+			{Name: "tenant_name"},
+			{Name: "use_floating_ip", Kind: SettingBool},
+			{Name: "username", Required: true, CommunicatorSetting: "username"},
+		},
+	},
+	// https://packer.io/docs/builders/parallels-iso.html
+	{
+		Kind: ComponentBuilder,
+		Type: ParallelsISO.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "disk_size", Kind: SettingInt},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "guest_os_type"},
+			{Name: "hard_drive_interface"},
+			{Name: "host_interfaces", Kind: SettingArray},
+			{Name: "http_directory"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			{Name: "iso_checksum"},
+			{Name: "iso_checksum_type", Required: true},
+			{Name: "iso_checksum_url"},
+			{Name: "iso_target_path"},
+			{Name: "iso_url", Overrides: []string{"iso_urls"}},
+			{Name: "iso_urls", Kind: SettingArray},
+			{Name: "output_directory"},
+			{Name: "parallels_tools_flavor", Required: true, RequiredUnless: "parallels_tools_guest_mode=disable"},
+			{Name: "parallels_tools_guest_mode", Options: []string{"disable", "upload", "detach"}},
+			{Name: "parallels_tools_guest_path"},
+			{Name: "prlctl", Kind: SettingArray},
+			{Name: "prlctl_post", Kind: SettingArray},
+			{Name: "prlctl_version_file"},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "skip_compaction", Kind: SettingBool},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "vm_name"},
+		},
+		OneOf: [][]string{{"iso_checksum", "iso_checksum_url"}, {"iso_url", "iso_urls"}},
+	},
+	// https://packer.io/docs/builders/parallels-pvm.html
+	{
+		Kind: ComponentBuilder,
+		Type: ParallelsPVM.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "host_interfaces", Kind: SettingArray},
+			{Name: "output_directory"},
+			{Name: "parallels_tools_flavor", Required: true, RequiredUnless: "parallels_tools_mode=disable"},
+			{Name: "parallels_tools_guest_path"},
+			{Name: "parallels_tools_mode", Options: []string{"disable", "upload", "detach"}},
+			{Name: "parallels_tools_path"},
+			{Name: "prlctl", Kind: SettingArray},
+			{Name: "prlctl_post", Kind: SettingArray},
+			{Name: "prlctl_version_file"},
+			{Name: "reassign_mac", Kind: SettingBool},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "skip_compaction", Kind: SettingBool},
+			{Name: "source_path", Required: true, Resource: true},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "vm_name"},
+		},
+	},
+	// https://packer.io/docs/builders/qemu.html
+	{
+		Kind: ComponentBuilder,
+		Type: QEMU.String(),
+		Settings: []SettingSchema{
+			{Name: "accelerator"},
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "disk_cache"},
+			{Name: "disk_compression", Kind: SettingBool},
+			{Name: "disk_discard"},
+			{Name: "disk_image", Kind: SettingBool},
+			{Name: "disk_interface"},
+			{Name: "disk_size", Kind: SettingInt},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "format"},
+			{Name: "headless", Kind: SettingBool},
+			{Name: "http_directory", Default: "http"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			{Name: "iso_checksum", Required: true},
+			{Name: "iso_checksum_type", Required: true},
+			{Name: "iso_target_path"},
+			{Name: "iso_url", Overrides: []string{"iso_urls"}},
+			{Name: "iso_urls", Kind: SettingArray},
+			{Name: "net_device"},
+			{Name: "output_directory"},
+			{Name: "qemu_binary"},
+			{Name: "qemuargs", Kind: SettingArray},
+			{Name: "skip_compaction", Kind: SettingBool},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+		},
+		OneOf:  [][]string{{"iso_url", "iso_urls"}},
+		finish: httpBuilder,
+	},
+	// https://packer.io/docs/builders/virtualbox-iso.html
+	{
+		Kind: ComponentBuilder,
+		Type: VirtualBoxISO.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "disk_size", Kind: SettingInt},
+			{Name: "export_opts", Kind: SettingArray},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "format"},
+			{Name: "guest_additions_mode"},
+			{Name: "guest_additions_path"},
+			{Name: "guest_additions_sha256"},
+			{Name: "guest_additions_url"},
+			// defaults to the distro release's os type.
+			{Name: "guest_os_type"},
+			{Name: "hard_drive_interface"},
+			{Name: "headless", Kind: SettingBool},
+			{Name: "http_directory", Default: "http"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			// required if the iso_url, or iso_urls, is set.
+			{Name: "iso_checksum"},
+			{Name: "iso_checksum_type"},
+			{Name: "iso_interface"},
+			{Name: "iso_target_path"},
+			// defaults to the distro release's ISO.
+			{Name: "iso_url", Overrides: []string{"iso_urls"}},
+			{Name: "iso_urls", Kind: SettingArray},
+			{Name: "output_directory"},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "ssh_host_port_max", Kind: SettingInt, Communicator: "ssh"},
+			{Name: "ssh_host_port_min", Kind: SettingInt, Communicator: "ssh"},
+			{Name: "ssh_password", Required: true, CommunicatorSetting: "password"},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "vboxmanage", Kind: SettingArray, convert: vboxManage},
+			{Name: "vboxmanage_post", Kind: SettingArray, convert: vboxManage},
+			{Name: "virtualbox_version_file"},
+			{Name: "vm_name"},
+		},
+		finish: isoBuilder(VirtualBoxISO),
+	},
+	// https://packer.io/docs/builders/virtualbox-ovf.html
+	{
+		Kind: ComponentBuilder,
+		Type: VirtualBoxOVF.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "export_opts", Kind: SettingArray},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "format"},
+			{Name: "guest_additions_mode"},
+			{Name: "guest_additions_path"},
+			{Name: "guest_additions_sha256"},
+			{Name: "guest_additions_url"},
+			{Name: "headless", Kind: SettingBool},
+			{Name: "http_directory", Default: "http"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			{Name: "import_flags", Kind: SettingArray},
+			{Name: "import_opts"},
+			{Name: "output_directory"},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "source_path", Required: true, Resource: true},
+			{Name: "ssh_host_port_max", Kind: SettingInt, Communicator: "ssh"},
+			{Name: "ssh_host_port_min", Kind: SettingInt, Communicator: "ssh"},
+			{Name: "ssh_skip_nat_mapping", Kind: SettingBool, Communicator: "ssh"},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "vboxmanage", Kind: SettingArray, convert: vboxManage},
+			{Name: "vboxmanage_post", Kind: SettingArray, convert: vboxManage},
+			{Name: "virtualbox_version_file"},
+			{Name: "vm_name"},
+		},
+		finish: httpBuilder,
+	},
+	// https://packer.io/docs/builders/vmware-iso.html
+	{
+		Kind: ComponentBuilder,
+		Type: VMWareISO.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "disk_additional_size", Kind: SettingArray, convert: diskSizes},
+			{Name: "disk_size", Kind: SettingInt},
+			{Name: "disk_type_id"},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "fusion_app_path"},
+			// defaults to the distro release's os type.
+			{Name: "guest_os_type"},
+			{Name: "headless", Kind: SettingBool},
+			{Name: "http_directory", Default: "http"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			// required if the iso_url, or iso_urls, is set.
+			{Name: "iso_checksum"},
+			{Name: "iso_checksum_type"},
+			{Name: "iso_target_path"},
+			// defaults to the distro release's ISO.
+			{Name: "iso_url", Overrides: []string{"iso_urls"}},
+			{Name: "iso_urls", Kind: SettingArray},
+			{Name: "output_directory"},
+			{Name: "remote_cache_datastore"},
+			{Name: "remote_cache_directory"},
+			{Name: "remote_datastore"},
+			{Name: "remote_host"},
+			{Name: "remote_password"},
+			{Name: "remote_private_key_file"},
+			{Name: "remote_type"},
+			{Name: "remote_username"},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "skip_compaction", Kind: SettingBool},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "tools_upload_flavor"},
+			{Name: "tools_upload_path"},
+			{Name: "version"},
+			{Name: "vm_name"},
+			{Name: "vmdk_name"},
+			{Name: "vmx_data", Kind: SettingArray, convert: vmxData},
+			{Name: "vmx_data_post", Kind: SettingArray, convert: vmxData},
+			{Name: "vmx_template_path"},
+			{Name: "vnc_port_max", Kind: SettingInt},
+			{Name: "vnc_port_min", Kind: SettingInt},
+		},
+		finish: isoBuilder(VMWareISO),
+	},
+	// https://packer.io/docs/builders/vmware-vmx.html
+	{
+		Kind: ComponentBuilder,
+		Type: VMWareVMX.String(),
+		Settings: []SettingSchema{
+			{Name: "boot_command", Kind: SettingArray, Command: true},
+			{Name: "boot_wait"},
+			{Name: "floppy_files", Kind: SettingArray},
+			{Name: "fusion_app_path"},
+			{Name: "headless", Kind: SettingBool},
+			{Name: "http_directory", Default: "http"},
+			{Name: "http_port_max", Kind: SettingInt},
+			{Name: "http_port_min", Kind: SettingInt},
+			{Name: "output_directory"},
+			{Name: "shutdown_command", Command: true},
+			{Name: "shutdown_timeout"},
+			{Name: "skip_compaction", Kind: SettingBool},
+			{Name: "source_path", Required: true, Resource: true},
+			{Name: "ssh_username", Required: true, CommunicatorSetting: "username"},
+			{Name: "vm_name"},
+			{Name: "vmx_data", Kind: SettingArray, convert: vmxData},
+			{Name: "vmx_data_post", Kind: SettingArray, convert: vmxData},
+			{Name: "vnc_port_max", Kind: SettingInt},
+			{Name: "vnc_port_min", Kind: SettingInt},
+		},
+		finish: httpBuilder,
+	},
+}
+
+// postProcessorSchemas are the schemas of the post-processors.  In addition
+// to their settings, except, keep_input_artifact, and only are supported.
+var postProcessorSchemas = []ComponentSchema{
+	// https://packer.io/docs/post-processors/atlas.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: Atlas.String(),
+		Settings: []SettingSchema{
+			{Name: "artifact", Required: true},
+			{Name: "artifact_type", Required: true},
+			{Name: "atlas_url"},
+			{Name: "metadata", Kind: SettingObject},
+			{Name: "token", Required: true},
+		},
+	},
+	// https://packer.io/docs/post-processors/compress.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: Compress.String(),
+		Settings: []SettingSchema{
+			{Name: "compression_level", Kind: SettingInt},
+			{Name: "keep_input_artifact", Kind: SettingBool},
+			{Name: "output"},
+		},
+	},
+	// https://packer.io/docs/post-processors/docker-import.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: DockerImport.String(),
+		Settings: []SettingSchema{
+			{Name: "repository", Required: true},
+			{Name: "tag", Required: true},
+		},
+	},
+	// https://packer.io/docs/post-processors/docker-push.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: DockerPush.String(),
+		Settings: []SettingSchema{
+			{Name: "login", Kind: SettingBool},
+			{Name: "login_email"},
+			{Name: "login_password"},
+			{Name: "login_server"},
+			{Name: "login_username"},
+		},
+	},
+	// https://packer.io/docs/post-processors/docker-save.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: DockerSave.String(),
+		Settings: []SettingSchema{
+			{Name: "path", Required: true},
+		},
+	},
+	// https://packer.io/docs/post-processors/docker-tag.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: DockerTag.String(),
+		Settings: []SettingSchema{
+			{Name: "force", Kind: SettingBool},
+			{Name: "repository", Required: true},
+			{Name: "tag"},
+		},
+	},
+	// https://packer.io/docs/post-processors/vagrant.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: Vagrant.String(),
+		Settings: []SettingSchema{
+			{Name: "compression_level", Kind: SettingInt},
+			{Name: "include", Kind: SettingArray},
+			{Name: "keep_input_artifact", Kind: SettingBool},
+			{Name: "output"},
+			// the provider specific overrides.
+			{Name: "override", Kind: SettingObject},
+			{Name: "vagrantfile_template", Resource: true},
+		},
+	},
+	// https://packer.io/docs/post-processors/vagrant-cloud.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: VagrantCloud.String(),
+		Settings: []SettingSchema{
+			{Name: "access_token", Required: true},
+			{Name: "box_download_url"},
+			{Name: "box_tag", Required: true},
+			{Name: "no_release"},
+			{Name: "vagrant_cloud_url"},
+			{Name: "version", Required: true},
+			{Name: "version_description"},
+		},
+	},
+	// https://packer.io/docs/post-processors/vsphere.html
+	{
+		Kind: ComponentPostProcessor,
+		Type: VSphere.String(),
+		Settings: []SettingSchema{
+			{Name: "cluster", Required: true},
+			{Name: "datacenter", Required: true},
+			{Name: "datastore"},
+			{Name: "disk_mode"},
+			{Name: "host", Required: true},
+			{Name: "insecure", Kind: SettingBool},
+			{Name: "password", Required: true},
+			{Name: "resource_pool"},
+			{Name: "username", Required: true},
+			{Name: "vm_folder"},
+			{Name: "vm_name", Required: true},
+			{Name: "vm_network"},
+		},
+		// the datastore isn't required if there's a resource pool.
+		OneOf: [][]string{{"datastore", "resource_pool"}},
+	},
+}
+
+// provisionerSchemas are the schemas of the provisioners.  In addition to
+// their settings, except, only, and override are supported.
+var provisionerSchemas = []ComponentSchema{
+	// https://packer.io/docs/provisioners/ansible.html
+	{
+		Kind: ComponentProvisioner,
+		Type: Ansible.String(),
+		Settings: []SettingSchema{
+			{Name: "ansible_env_vars", Kind: SettingArray},
+			{Name: "empty_groups", Kind: SettingArray},
+			{Name: "extra_arguments", Kind: SettingArray},
+			{Name: "groups", Kind: SettingArray},
+			{Name: "host_alias"},
+			{Name: "local_port"},
+			{Name: "playbook_file", Required: true, Resource: true},
+			{Name: "sftp_command", Command: true},
+			{Name: "ssh_authorized_key_file"},
+			{Name: "ssh_host_key_file"},
+			{Name: "user"},
+		},
+	},
+	// https://packer.io/docs/provisioners/ansible-local.html
+	{
+		Kind: ComponentProvisioner,
+		Type: AnsibleLocal.String(),
+		Settings: []SettingSchema{
+			{Name: "command"},
+			{Name: "extra_arguments", Kind: SettingStrings},
+			{Name: "group_vars", Resource: true},
+			{Name: "host_vars", Resource: true},
+			{Name: "inventory_file", Resource: true},
+			{Name: "inventory_groups"},
+			{Name: "playbook_dir", Resource: true},
+			{Name: "playbook_file", Required: true, Resource: true},
+			{Name: "playbook_paths", Kind: SettingStrings, Resource: true},
+			{Name: "role_paths", Kind: SettingStrings, Resource: true},
+			{Name: "staging_directory"},
+		},
+	},
+	// https://packer.io/docs/provisioners/chef-client.html
+	{
+		Kind: ComponentProvisioner,
+		Type: ChefClient.String(),
+		Settings: []SettingSchema{
+			{Name: "chef_environment"},
+			{Name: "client_key"},
+			{Name: "config_template", Resource: true},
+			{Name: "encrypted_data_bag_secret_path"},
+			{Name: "execute_command", Command: true},
+			{Name: "guest_os_type"},
+			{Name: "install_command", Command: true},
+			{Name: "node_name"},
+			{Name: "prevent_sudo", Kind: SettingBool},
+			{Name: "run_list", Kind: SettingStrings},
+			{Name: "server_url"},
+			{Name: "skip_clean_client", Kind: SettingBool},
+			{Name: "skip_clean_node", Kind: SettingBool},
+			{Name: "skip_install", Kind: SettingBool},
+			{Name: "ssl_verify_mode"},
+			{Name: "staging_directory"},
+			{Name: "validation_client_name"},
+			{Name: "validation_key_path"},
+		},
+	},
+	// https://packer.io/docs/provisioners/chef-solo.html
+	{
+		Kind: ComponentProvisioner,
+		Type: ChefSolo.String(),
+		Settings: []SettingSchema{
+			{Name: "chef_environment"},
+			{Name: "config_template", Resource: true},
+			{Name: "cookbook_paths", Kind: SettingStrings, Resource: true},
+			{Name: "data_bags_path", Resource: true},
+			{Name: "encrypted_data_bag_secret_path"},
+			{Name: "environments_path", Resource: true},
+			{Name: "execute_command", Command: true},
+			{Name: "guest_os_type"},
+			{Name: "install_command", Command: true},
+			{Name: "prevent_sudo", Kind: SettingBool},
+			{Name: "remote_cookbook_paths", Kind: SettingStrings},
+			{Name: "roles_path", Resource: true},
+			{Name: "run_list", Kind: SettingStrings},
+			{Name: "skip_install", Kind: SettingBool},
+			{Name: "staging_directory"},
+		},
+	},
+	// https://packer.io/docs/provisioners/file.html
+	{
+		Kind: ComponentProvisioner,
+		Type: File.String(),
+		Settings: []SettingSchema{
+			{Name: "destination", Required: true},
+			{Name: "direction"},
+			{Name: "source", Required: true, Resource: true, TrailingSlash: true},
+		},
+	},
+	// https://packer.io/docs/provisioners/puppet-masterless.html
+	{
+		Kind: ComponentProvisioner,
+		Type: PuppetMasterless.String(),
+		Settings: []SettingSchema{
+			{Name: "execute_command", Command: true},
+			{Name: "extra_arguments", Kind: SettingStrings},
+			{Name: "facter", Kind: SettingObject},
+			{Name: "hiera_config_path", Resource: true},
+			{Name: "ignore_exit_codes", Kind: SettingBool},
+			{Name: "manifest_dir", Resource: true},
+			{Name: "manifest_file", Required: true, Resource: true},
+			{Name: "module_paths", Kind: SettingStrings},
+			{Name: "prevent_sudo", Kind: SettingBool},
+			{Name: "staging_directory"},
+			{Name: "working_directory"},
+		},
+	},
+	// https://packer.io/docs/provisioners/puppet-server.html
+	{
+		Kind: ComponentProvisioner,
+		Type: PuppetServer.String(),
+		Settings: []SettingSchema{
+			{Name: "client_cert_path"},
+			{Name: "client_private_key_path"},
+			{Name: "facter", Kind: SettingObject},
+			{Name: "ignore_exit_codes", Kind: SettingBool},
+			{Name: "options"},
+			{Name: "prevent_sudo", Kind: SettingBool},
+			{Name: "puppet_node"},
+			{Name: "puppet_server"},
+			{Name: "staging_directory"},
+		},
+	},
+	// https://packer.io/docs/provisioners/salt-masterless.html
+	{
+		Kind: ComponentProvisioner,
+		Type: Salt.String(),
+		Settings: []SettingSchema{
+			{Name: "bootstrap_args"},
+			{Name: "disable_sudo", Kind: SettingBool},
+			{Name: "local_pillar_roots", Resource: true},
+			{Name: "local_state_tree", Required: true, Resource: true},
+			{Name: "log_level"},
+			// the directory with the minion file; the file is copied.
+			{Name: "minion_config", Conflicts: []string{"remote_pillar_roots", "remote_state_tree"}, convert: minionConfig},
+			{Name: "no_exit_on_failure", Kind: SettingBool},
+			{Name: "remote_pillar_roots"},
+			{Name: "remote_state_tree"},
+			{Name: "skip_bootstrap", Kind: SettingBool},
+			{Name: "temp_config_dir"},
+		},
+	},
+	// https://packer.io/docs/provisioners/shell.html
+	{
+		Kind: ComponentProvisioner,
+		Type: Shell.String(),
+		Settings: []SettingSchema{
+			{Name: "binary", Kind: SettingBool},
+			{Name: "environment_vars", Kind: SettingStrings},
+			{Name: "execute_command", Command: true},
+			// only one of inline, script, and scripts is used; they are
+			// listed in order of precedence.
+			{Name: "inline", Kind: SettingStrings, Overrides: []string{"script", "scripts"}},
+			{Name: "inline_shebang"},
+			{Name: "remote_file"},
+			{Name: "remote_folder"},
+			{Name: "remote_path"},
+			{Name: "script", Resource: true, Overrides: []string{"scripts"}},
+			{Name: "scripts", Kind: SettingStrings, Resource: true},
+			{Name: "skip_clean", Kind: SettingBool},
+			{Name: "start_retry_timeout"},
+		},
+		OneOf: [][]string{{"inline", "script", "scripts"}},
+	},
+	// https://packer.io/docs/provisioners/shell-local.html
+	{
+		Kind: ComponentProvisioner,
+		Type: ShellLocal.String(),
+		Settings: []SettingSchema{
+			{Name: "command", Required: true, Command: true},
+			{Name: "environment_vars", Kind: SettingArray},
+			{Name: "execute_command", Command: true},
+		},
+	},
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testFactory is a component factory whose components have a greeting.
+type testFactory struct {
+	typ string
+	err error
+}
+
+func (f testFactory) Type() string { return f.typ }
+
+func (f testFactory) Create(r *RawTemplate, id string) (map[string]interface{}, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	settings := map[string]interface{}{"type": f.typ}
+	for _, s := range r.Provisioners[id].Settings {
+		k, v := parseVar(s)
+		settings[k] = r.ReplaceSettingVars(k, v)
+	}
+	return settings, nil
+}
+
+func TestRegisterComponent(t *testing.T) {
+	defer delete(provisionerFactories, "greeting")
+	tests := []struct {
+		register func(ComponentFactory) error
+		typ      string
+		err      string
+	}{
+		{RegisterProvisioner, "greeting", ""},
+		{RegisterProvisioner, "Greeting", "provisioner greeting: already registered"},
+		{RegisterProvisioner, "shell", "provisioner shell: already registered"},
+		{RegisterProvisioner, "", "provisioner: empty component type"},
+		{RegisterBuilder, "common", "builder common: reserved type"},
+		{RegisterBuilder, "virtualbox-iso", "builder virtualbox-iso: already registered"},
+		{RegisterPostProcessor, "compress", "post-processor compress: already registered"},
+	}
+	for i, test := range tests {
+		err := test.register(testFactory{typ: test.typ})
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+		}
+	}
+}
+
+func TestCreateRegisteredComponent(t *testing.T) {
+	defer delete(provisionerFactories, "greeting")
+	defer delete(provisionerFactories, "broken")
+	for _, f := range []testFactory{{typ: "greeting"}, {typ: "broken", err: errors.New("no greeting")}} {
+		err := RegisterProvisioner(f)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+	tests := []struct {
+		section  TemplateSection
+		expected map[string]interface{}
+		err      string
+	}{
+		{
+			TemplateSection{Type: "greeting", Settings: []string{"message = hello :name", "greetng = hi"}},
+			map[string]interface{}{"type": "greeting", "message": "hello test", "greetng": "hi"},
+			"",
+		},
+		{
+			TemplateSection{Type: "Greeting", Settings: []string{"message = hello :undefined"}},
+			nil,
+			"hello: message: :undefined: undefined variable",
+		},
+		{
+			TemplateSection{Type: "broken"},
+			nil,
+			"hello: no greeting",
+		},
+		{
+			TemplateSection{Type: "farewell"},
+			nil,
+			"\"farewell\": invalid provisioner",
+		},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.Delim = ":"
+		r.VarVals = map[string]string{":name": "test"}
+		r.Provisioners = map[string]ProvisionerC{"hello": {test.section}}
+		settings, err := r.createProvisioner("hello")
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(settings, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, settings)
+		}
+		// the factory doesn't list its settings, so none are unknown.
+		if len(r.warnings) != 0 {
+			t.Errorf("%d: expected no warnings, got %v", i, r.warnings)
+		}
+	}
+}
@@ -0,0 +1,737 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	cjsn "github.com/mohae/cjson"
+	"github.com/mohae/feedlot/conf"
+	"github.com/mohae/feedlot/log"
+	json "github.com/mohae/unsafejson"
+)
+
+// The formats a conf file can be converted to.  CJSN is JSON that can have
+// comments; comments are only carried over to the formats that support them:
+// CJSN, TOML, and YAML.
+const (
+	ConvertJSON = "json"
+	ConvertCJSN = "cjsn"
+	ConvertTOML = "toml"
+	ConvertYAML = "yaml"
+)
+
+// ConvertErr is an error converting a conf file.
+type ConvertErr struct {
+	name string
+	Err  error
+}
+
+func (e ConvertErr) Error() string {
+	return fmt.Sprintf("convert %s: %s", e.name, e.Err)
+}
+
+// Conversion is the result of converting a conf file: the converted content
+// along with any comments that couldn't be carried over.
+type Conversion struct {
+	Src     string
+	Dst     string
+	Content []byte
+	// Dropped are the comments that couldn't be carried over, either because
+	// the target format doesn't support comments or because the setting they
+	// were attached to couldn't be found in the output.
+	Dropped []string
+}
+
+// ConfComparison is the result of comparing two conf files.  If the file only
+// exists on one side, the other side's name is empty.  Err is set if either
+// file couldn't be decoded.
+type ConfComparison struct {
+	Name    string
+	A       string
+	B       string
+	Changes []TemplateChange
+	Err     error
+}
+
+// Equal returns whether the two files are semantically the same.
+func (c ConfComparison) Equal() bool {
+	return c.A != "" && c.B != "" && c.Err == nil && len(c.Changes) == 0
+}
+
+// ConvertConf converts the named Feedlot conf file, of any kind, to the
+// target format and returns the result.  The file's format is determined by
+// its extension.  The file is converted as is: every setting is carried over,
+// even ones that Feedlot doesn't use.  Comments, full lines that start with
+// '#', are carried over if the target format supports them: each comment is
+// placed before the setting that followed it in the original file and the
+// comments at the top of the file stay at the top.
+func ConvertConf(name, to string) (Conversion, error) {
+	c := Conversion{Src: name}
+	ext, err := convertExt(to)
+	if err != nil {
+		return c, ConvertErr{name, err}
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return c, ConvertErr{name, err}
+	}
+	format := conf.FileFormat(name)
+	v, err := decodeConf(b, format)
+	if err != nil {
+		return c, ConvertErr{name, err}
+	}
+	comments := extractComments(b, format)
+	out, err := encodeConf(v, to)
+	if err != nil {
+		return c, ConvertErr{name, err}
+	}
+	if to == ConvertJSON {
+		for _, cmt := range comments {
+			c.Dropped = append(c.Dropped, cmt.lines...)
+		}
+	} else {
+		out, c.Dropped = injectComments(out, conf.FileFormat("."+ext), comments)
+	}
+	c.Content = out
+	c.Dst = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)) + "." + ext
+	log.Debugf("converted %s to %s: %d comments dropped", name, to, len(c.Dropped))
+	return c, nil
+}
+
+// ConvertConfFiles converts each of the named conf files to the target format
+// and writes them to dir, using the file's name with the target format's
+// extension.  An existing file is only replaced if overwrite is true.
+func ConvertConfFiles(names []string, to, dir string, overwrite bool) ([]Conversion, error) {
+	convs := make([]Conversion, 0, len(names))
+	for _, name := range names {
+		c, err := ConvertConf(name, to)
+		if err != nil {
+			log.Error(err)
+			return convs, err
+		}
+		c.Dst = filepath.Join(dir, c.Dst)
+		if !overwrite {
+			_, err = os.Stat(c.Dst)
+			if err == nil {
+				err = ConvertErr{name, fmt.Errorf("%s already exists", c.Dst)}
+				log.Error(err)
+				return convs, err
+			}
+		}
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			err = ConvertErr{name, err}
+			log.Error(err)
+			return convs, err
+		}
+		err = ioutil.WriteFile(c.Dst, c.Content, 0644)
+		if err != nil {
+			err = ConvertErr{name, err}
+			log.Error(err)
+			return convs, err
+		}
+		convs = append(convs, c)
+	}
+	return convs, nil
+}
+
+// CompareConf checks whether two conf files, or two conf dirs, are
+// semantically the same, regardless of their format: the settings and their
+// values are compared, comments and formatting are not.  The spacing around
+// the `=` of key=value settings and variables doesn't matter, as it is
+// trimmed when they are parsed; see parseVar.  Two files are
+// compared with each other.  For dirs, the files are matched by their names,
+// without the extension; only files in one of the supported formats are
+// compared.
+func CompareConf(a, b string) ([]ConfComparison, error) {
+	aFi, err := os.Stat(a)
+	if err != nil {
+		return nil, err
+	}
+	bFi, err := os.Stat(b)
+	if err != nil {
+		return nil, err
+	}
+	if !aFi.IsDir() && !bFi.IsDir() {
+		c := ConfComparison{Name: strings.TrimSuffix(aFi.Name(), filepath.Ext(a)), A: a, B: b}
+		c.Changes, c.Err = compareConfFiles(a, b)
+		return []ConfComparison{c}, nil
+	}
+	aFiles, err := confFiles(a)
+	if err != nil {
+		return nil, err
+	}
+	bFiles, err := confFiles(b)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(aFiles)+len(bFiles))
+	for n := range aFiles {
+		names = append(names, n)
+	}
+	for n := range bFiles {
+		if _, ok := aFiles[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	comps := make([]ConfComparison, 0, len(names))
+	for _, n := range names {
+		c := ConfComparison{Name: n, A: aFiles[n], B: bFiles[n]}
+		if c.A != "" && c.B != "" {
+			c.Changes, c.Err = compareConfFiles(c.A, c.B)
+		}
+		comps = append(comps, c)
+	}
+	return comps, nil
+}
+
+// compareConfFiles returns the differences between the contents of the two
+// conf files.
+func compareConfFiles(a, b string) ([]TemplateChange, error) {
+	av, err := loadConfValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bv, err := loadConfValue(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffTemplateValues("", normalizeConfSettings(av), normalizeConfSettings(bv), nil), nil
+}
+
+// normalizeConfSettings returns v with the key=value strings in its settings
+// and variables lists rewritten the way that they are parsed, so that
+// `key = value` and `key=value` are the same.
+func normalizeConfSettings(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, x := range vv {
+			if k != "settings" && k != "variables" {
+				vv[k] = normalizeConfSettings(x)
+				continue
+			}
+			l, ok := x.([]interface{})
+			if !ok {
+				continue
+			}
+			for i, s := range l {
+				str, ok := s.(string)
+				if !ok || !strings.Contains(str, "=") {
+					continue
+				}
+				key, val := parseVar(str)
+				l[i] = key + "=" + val
+			}
+		}
+	case []interface{}:
+		for i, x := range vv {
+			vv[i] = normalizeConfSettings(x)
+		}
+	}
+	return v
+}
+
+// confFiles returns the conf files at p, keyed by their names without the
+// extension.  If p is a file, only it is returned.
+func confFiles(p string) (map[string]string, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return map[string]string{strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name())): p}, nil
+	}
+	_, fnames, err := indexDir(p)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(fnames))
+	for _, fname := range fnames {
+		if conf.FileFormat(fname) == conf.UnsupportedConfFormat {
+			continue
+		}
+		n := strings.TrimSuffix(fname, filepath.Ext(fname))
+		if f, ok := files[n]; ok {
+			return nil, fmt.Errorf("%s: %s and %s have the same name", p, filepath.Base(f), fname)
+		}
+		files[n] = filepath.Join(p, fname)
+	}
+	return files, nil
+}
+
+// loadConfValue returns the named conf file's decoded contents.
+func loadConfValue(name string) (interface{}, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeConf(b, conf.FileFormat(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return v, nil
+}
+
+// convertExt returns the file extension for the target format.
+func convertExt(to string) (string, error) {
+	switch to {
+	case ConvertJSON, ConvertCJSN, ConvertTOML, ConvertYAML:
+		return to, nil
+	}
+	return "", fmt.Errorf("%s: unsupported conversion format", to)
+}
+
+// decodeConf decodes the conf file contents, b, without regard to what kind
+// of conf file it is.  All numbers are returned as float64s, tables as
+// map[string]interface{}, and arrays as []interface{}, so that the results
+// of decoding the different formats can be compared.
+func decodeConf(b []byte, format conf.ConfFormat) (interface{}, error) {
+	var v interface{}
+	switch format {
+	case conf.JSON:
+		err := cjsn.Unmarshal(b, &v)
+		if err != nil {
+			return nil, err
+		}
+	case conf.TOML:
+		var m map[string]interface{}
+		_, err := toml.Decode(string(b), &m)
+		if err != nil {
+			return nil, err
+		}
+		v = m
+	case conf.YAML:
+		err := yaml.Unmarshal(b, &v)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, conf.ErrUnsupportedFormat
+	}
+	return normalizeConfValue(v), nil
+}
+
+// normalizeConfValue returns v with the numbers, tables, and arrays converted
+// to the types that JSON decodes to.
+func normalizeConfValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, x := range vv {
+			vv[k] = normalizeConfValue(x)
+		}
+		return vv
+	case []map[string]interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = normalizeConfValue(x)
+		}
+		return s
+	case []interface{}:
+		for i, x := range vv {
+			vv[i] = normalizeConfValue(x)
+		}
+		return vv
+	case int64:
+		return float64(vv)
+	case int:
+		return float64(vv)
+	}
+	return v
+}
+
+// encodeConf encodes v in the target format.
+func encodeConf(v interface{}, to string) ([]byte, error) {
+	switch to {
+	case ConvertJSON, ConvertCJSN:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case ConvertTOML:
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(tomlConfValue(v))
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ConvertYAML:
+		return yaml.Marshal(v)
+	}
+	return nil, fmt.Errorf("%s: unsupported conversion format", to)
+}
+
+// tomlConfValue returns v with the whole numbers converted to int64s so that
+// they aren't written as floats.
+func tomlConfValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, x := range vv {
+			m[k] = tomlConfValue(x)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = tomlConfValue(x)
+		}
+		return s
+	case float64:
+		if vv == math.Trunc(vv) && math.Abs(vv) < 1<<53 {
+			return int64(vv)
+		}
+	}
+	return v
+}
+
+// confComment is a block of comment lines and the path of the setting that
+// follows it; the comments at the top of a file have an empty path.
+type confComment struct {
+	path  string
+	lines []string
+}
+
+// extractComments returns the comments in the conf file contents, b, in the
+// order they appear.  A comment is a full line whose first non-space
+// character is '#'.
+func extractComments(b []byte, format conf.ConfFormat) []confComment {
+	lines := splitLines(string(b))
+	paths := confKeyPaths(lines, format)
+	var comments []confComment
+	var pending []string
+	header := true
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "#") {
+			pending = append(pending, t)
+			continue
+		}
+		if t == "" {
+			continue
+		}
+		if header {
+			header = false
+			if len(pending) > 0 {
+				comments = append(comments, confComment{lines: pending})
+				pending = nil
+			}
+		}
+		if paths[i] != "" && len(pending) > 0 {
+			comments = append(comments, confComment{path: paths[i], lines: pending})
+			pending = nil
+		}
+	}
+	if len(pending) > 0 {
+		if header {
+			comments = append(comments, confComment{lines: pending})
+		} else {
+			// trailing comments stay at the end of the file.
+			comments = append(comments, confComment{path: "\x00end", lines: pending})
+		}
+	}
+	return comments
+}
+
+// injectComments places the comments in the encoded conf file contents, b:
+// the header comments go at the top, the trailing comments at the end, and
+// the others before the first line that defines the setting with their path.
+// The comments that couldn't be placed are returned.
+func injectComments(b []byte, format conf.ConfFormat, comments []confComment) ([]byte, []string) {
+	if len(comments) == 0 {
+		return b, nil
+	}
+	lines := splitLines(strings.TrimRight(string(b), "\n"))
+	paths := confKeyPaths(lines, format)
+	before := map[int][]string{}
+	var header, trailer, dropped []string
+	for _, c := range comments {
+		switch c.path {
+		case "":
+			header = append(header, c.lines...)
+			continue
+		case "\x00end":
+			trailer = append(trailer, c.lines...)
+			continue
+		}
+		i := indexOf(paths, c.path)
+		if i < 0 {
+			dropped = append(dropped, c.lines...)
+			continue
+		}
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		for _, l := range c.lines {
+			before[i] = append(before[i], indent+l)
+		}
+	}
+	var buf bytes.Buffer
+	for _, l := range header {
+		buf.WriteString(l + "\n")
+	}
+	for i, l := range lines {
+		for _, cl := range before[i] {
+			buf.WriteString(cl + "\n")
+		}
+		buf.WriteString(l + "\n")
+	}
+	for _, l := range trailer {
+		buf.WriteString(l + "\n")
+	}
+	return buf.Bytes(), dropped
+}
+
+// indexOf returns the index of the first element of sl that is s, or -1.
+func indexOf(sl []string, s string) int {
+	for i, v := range sl {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// confKeyPaths returns, for each line, the path of the setting it defines,
+// or an empty string if it doesn't define one.  A path is the setting's key
+// along with the keys of the tables it is in, separated by dots, e.g.
+// 1604-64.provisioners.shell.  Array indexes aren't part of the path.
+func confKeyPaths(lines []string, format conf.ConfFormat) []string {
+	switch format {
+	case conf.JSON:
+		return jsonKeyPaths(lines)
+	case conf.TOML:
+		return tomlKeyPaths(lines)
+	case conf.YAML:
+		return yamlKeyPaths(lines)
+	}
+	return make([]string, len(lines))
+}
+
+// jsonKeyPaths returns the path of the first key on each line of JSON, which
+// may have comment lines.
+func jsonKeyPaths(lines []string) []string {
+	type frame struct {
+		obj bool
+		key string
+	}
+	paths := make([]string, len(lines))
+	var stack []frame
+	var key string
+	var inString, escaped, expectKey bool
+	var str bytes.Buffer
+	path := func(k string) string {
+		var keys []string
+		for _, f := range stack {
+			if f.key != "" {
+				keys = append(keys, f.key)
+			}
+		}
+		return strings.Join(append(keys, k), ".")
+	}
+	for i, l := range lines {
+		if !inString && strings.HasPrefix(strings.TrimSpace(l), "#") {
+			continue
+		}
+		for _, r := range l {
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+					str.WriteRune(r)
+				case r == '\\':
+					escaped = true
+				case r == '"':
+					inString = false
+					if expectKey {
+						key = str.String()
+						if paths[i] == "" {
+							paths[i] = path(key)
+						}
+					}
+				default:
+					str.WriteRune(r)
+				}
+				continue
+			}
+			switch r {
+			case '"':
+				inString = true
+				str.Reset()
+			case ':':
+				expectKey = false
+			case ',':
+				expectKey = len(stack) > 0 && stack[len(stack)-1].obj
+			case '{', '[':
+				var k string
+				if len(stack) == 0 || stack[len(stack)-1].obj {
+					k = key
+				}
+				stack = append(stack, frame{obj: r == '{', key: k})
+				expectKey = r == '{'
+				key = ""
+			case '}', ']':
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+				expectKey = false
+			}
+		}
+	}
+	return paths
+}
+
+// tomlKeyRe matches a TOML key/value line; the key may be dotted and quoted.
+var tomlKeyRe = regexp.MustCompile(`^\s*((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)\s*=`)
+
+// tomlKeyPaths returns the path of the table or key defined on each line of
+// TOML.
+func tomlKeyPaths(lines []string) []string {
+	paths := make([]string, len(lines))
+	var table []string
+	var depth int
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if depth > 0 {
+			depth += bracketDepth(t)
+			continue
+		}
+		if strings.HasPrefix(t, "#") || t == "" {
+			continue
+		}
+		if strings.HasPrefix(t, "[") {
+			h := strings.TrimPrefix(strings.TrimPrefix(t, "["), "[")
+			if end := strings.Index(h, "]"); end >= 0 {
+				h = h[:end]
+			}
+			table = splitTOMLKey(h)
+			paths[i] = strings.Join(table, ".")
+			continue
+		}
+		m := tomlKeyRe.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		paths[i] = strings.Join(append(append([]string{}, table...), splitTOMLKey(m[1])...), ".")
+		depth = bracketDepth(l[len(m[0]):])
+	}
+	return paths
+}
+
+// bracketDepth returns the number of unclosed '[' in s, ignoring the ones in
+// strings and comments.
+func bracketDepth(s string) int {
+	var depth int
+	var quote rune
+	var escaped bool
+	for _, r := range s {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\' && quote == '"':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '"', '\'':
+			quote = r
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '#':
+			return depth
+		}
+	}
+	return depth
+}
+
+// splitTOMLKey splits a dotted TOML key into its parts, removing any quotes.
+func splitTOMLKey(s string) []string {
+	var parts []string
+	var cur bytes.Buffer
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		case r == ' ' || r == '\t':
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(cur.String()))
+}
+
+// yamlKeyRe matches a YAML mapping key at the start of a line: the key and
+// its value, if it has one on the same line.
+var yamlKeyRe = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"\-][^:#]*?|-[^\s:#][^:#]*?)\s*:(?:\s+(.*))?$`)
+
+// yamlKeyPaths returns the path of the key defined on each line of YAML.  The
+// keys' tables are determined by their indentation.
+func yamlKeyPaths(lines []string) []string {
+	type frame struct {
+		indent int
+		key    string
+	}
+	paths := make([]string, len(lines))
+	var stack []frame
+	block := -1
+	for i, l := range lines {
+		t := strings.TrimLeft(l, " ")
+		indent := len(l) - len(t)
+		if block >= 0 {
+			if indent > block || strings.TrimSpace(t) == "" {
+				continue
+			}
+			block = -1
+		}
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		// the keys of a mapping in a sequence are indented past the '- '.
+		for strings.HasPrefix(t, "- ") {
+			t = strings.TrimLeft(t[2:], " ")
+			indent = len(l) - len(t)
+		}
+		m := yamlKeyRe.FindStringSubmatch(t)
+		if m == nil {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		key := strings.Trim(m[1], `"'`)
+		keys := make([]string, 0, len(stack)+1)
+		for _, f := range stack {
+			keys = append(keys, f.key)
+		}
+		paths[i] = strings.Join(append(keys, key), ".")
+		stack = append(stack, frame{indent: indent, key: key})
+		if strings.HasPrefix(m[2], "|") || strings.HasPrefix(m[2], ">") {
+			block = indent
+		}
+	}
+	return paths
+}
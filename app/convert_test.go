@@ -0,0 +1,212 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mohae/feedlot/conf"
+)
+
+func TestConfKeyPaths(t *testing.T) {
+	tests := []struct {
+		format   conf.ConfFormat
+		lines    []string
+		expected []string
+	}{
+		{
+			conf.JSON,
+			[]string{
+				`{`,
+				`  "1604-64": {`,
+				`    "distro": "ubuntu",`,
+				`    "builder_types": [`,
+				`      "virtualbox-iso"`,
+				`    ],`,
+				`    "provisioners": {"shell": {}}`,
+				`  }`,
+				`}`,
+			},
+			[]string{"", "1604-64", "1604-64.distro", "1604-64.builder_types", "", "", "1604-64.provisioners", "", ""},
+		},
+		{
+			conf.TOML,
+			[]string{
+				`format = "toml"`,
+				`[1604-64]`,
+				`distro = "ubuntu"`,
+				`builder_types = [`,
+				`	"virtualbox-iso",`,
+				`]`,
+				`	[1604-64.provisioners.shell]`,
+				`	type = "shell"`,
+			},
+			[]string{"format", "1604-64", "1604-64.distro", "1604-64.builder_types", "", "", "1604-64.provisioners.shell", "1604-64.provisioners.shell.type"},
+		},
+		{
+			conf.YAML,
+			[]string{
+				`1604-64:`,
+				`  distro: ubuntu`,
+				`  builder_types:`,
+				`  - virtualbox-iso`,
+				`  provisioners:`,
+				`    shell:`,
+				`      type: shell`,
+				`format: yaml`,
+			},
+			[]string{"1604-64", "1604-64.distro", "1604-64.builder_types", "", "1604-64.provisioners", "1604-64.provisioners.shell", "1604-64.provisioners.shell.type", "format"},
+		},
+	}
+	for i, test := range tests {
+		paths := confKeyPaths(test.lines, test.format)
+		if !reflect.DeepEqual(paths, test.expected) {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, paths)
+		}
+	}
+}
+
+func TestConvertConf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feedlot-convert-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "build.cjsn")
+	err = ioutil.WriteFile(src, []byte(`# header
+{
+  "1604-64": {
+    # the distro
+    "distro": "ubuntu",
+    "release": 16.04,
+    "cpus": 2
+  }
+}
+# trailer
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		to       string
+		expected string
+		dropped  []string
+	}{
+		{ConvertJSON, "{\n  \"1604-64\": {\n    \"cpus\": 2,\n    \"distro\": \"ubuntu\",\n    \"release\": 16.04\n  }\n}\n", []string{"# header", "# the distro", "# trailer"}},
+		{ConvertCJSN, "# header\n{\n  \"1604-64\": {\n    \"cpus\": 2,\n    # the distro\n    \"distro\": \"ubuntu\",\n    \"release\": 16.04\n  }\n}\n# trailer\n", nil},
+		{ConvertTOML, "# header\n[1604-64]\n  cpus = 2\n  # the distro\n  distro = \"ubuntu\"\n  release = 16.04\n# trailer\n", nil},
+		{ConvertYAML, "# header\n1604-64:\n  cpus: 2\n  # the distro\n  distro: ubuntu\n  release: 16.04\n# trailer\n", nil},
+	}
+	for i, test := range tests {
+		c, err := ConvertConf(src, test.to)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if string(c.Content) != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, string(c.Content))
+		}
+		if !reflect.DeepEqual(c.Dropped, test.dropped) {
+			t.Errorf("%d: expected dropped %q, got %q", i, test.dropped, c.Dropped)
+		}
+		if c.Dst != "build."+test.to {
+			t.Errorf("%d: expected %q, got %q", i, "build."+test.to, c.Dst)
+		}
+		// the conversion must have the same settings as the original.
+		dst := filepath.Join(dir, "converted."+test.to)
+		err = ioutil.WriteFile(dst, c.Content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes, err := compareConfFiles(src, dst)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if len(changes) != 0 {
+			t.Errorf("%d: expected no changes, got %v", i, changes)
+		}
+	}
+	_, err = ConvertConf(src, "xml")
+	if err == nil {
+		t.Error("expected an error, got none")
+	} else if err.Error() != "convert "+src+": xml: unsupported conversion format" {
+		t.Errorf("expected %q, got %q", "convert "+src+": xml: unsupported conversion format", err)
+	}
+}
+
+func TestCompareConf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feedlot-compare-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"json/build.cjsn":      "# builds\n{\"server\": {\"distro\": \"ubuntu\", \"cpus\": 2, \"variables\": [\"version = 1\"], \"builders\": {\"common\": {\"settings\": [\"ssh_username = vagrant\", \"headless\"]}}}}",
+		"json/build_list.json": `{"all": {"builds": ["server"]}}`,
+		"json/default.json":    `{"format": "json"}`,
+		"toml/build.toml":      "[server]\ndistro = \"ubuntu\"\ncpus = 2\nvariables = [\"version=1\"]\n[server.builders.common]\nsettings = [\"ssh_username=vagrant\", \"headless\"]\n",
+		"toml/build_list.toml": "[all]\nbuilds = [\"server\", \"desktop\"]\n",
+		"toml/supported.toml":  "[ubuntu]\n",
+	}
+	for name, content := range files {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	comps, err := CompareConf(filepath.Join(dir, "json"), filepath.Join(dir, "toml"))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := []struct {
+		name    string
+		equal   bool
+		changes []TemplateChange
+	}{
+		{"build", true, nil},
+		{"build_list", false, []TemplateChange{{Path: "all.builds[1]", Op: DiffAdd, New: "desktop"}}},
+		{"default", false, nil},
+		{"supported", false, nil},
+	}
+	if len(comps) != len(expected) {
+		t.Fatalf("expected %d comparisons, got %d", len(expected), len(comps))
+	}
+	for i, c := range comps {
+		if c.Name != expected[i].name {
+			t.Errorf("%d: expected %q, got %q", i, expected[i].name, c.Name)
+		}
+		if c.Equal() != expected[i].equal {
+			t.Errorf("%d: expected equal to be %t, got %t", i, expected[i].equal, c.Equal())
+		}
+		if !reflect.DeepEqual(c.Changes, expected[i].changes) {
+			t.Errorf("%d: expected %v, got %v", i, expected[i].changes, c.Changes)
+		}
+	}
+	if comps[2].B != "" {
+		t.Errorf("expected default to only be in json, got %q", comps[2].B)
+	}
+	if comps[3].A != "" {
+		t.Errorf("expected supported to only be in toml, got %q", comps[3].A)
+	}
+	// two files are compared with each other, even if their names differ.
+	comps, err = CompareConf(filepath.Join(dir, "json/build.cjsn"), filepath.Join(dir, "toml/build_list.toml"))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if len(comps) != 1 {
+		t.Fatalf("expected 1 comparison, got %d", len(comps))
+	}
+	if comps[0].Name != "build" {
+		t.Errorf("expected %q, got %q", "build", comps[0].Name)
+	}
+	if len(comps[0].Changes) != 2 {
+		t.Errorf("expected 2 changes, got %v", comps[0].Changes)
+	}
+}
@@ -0,0 +1,427 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cjsn "github.com/mohae/cjson"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+	"github.com/mohae/feedlot/log"
+	json "github.com/mohae/unsafejson"
+)
+
+// Diff operations.
+const (
+	DiffAdd    = "add"
+	DiffChange = "change"
+	DiffRemove = "remove"
+)
+
+// TemplateChange is a difference between a generated Packer template and the
+// one on disk.  The Path is the location of the value within the template,
+// e.g. builders[0].boot_wait.  Old is nil for adds and New is nil for removes.
+type TemplateChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// FileChange is a resource file in the template output directory that would
+// be added, changed, or removed by a build.  Changes are determined by
+// comparing the sha256 of the file contents.  Files in the template output
+// directory that aren't part of the build are removed if the prior build gets
+// archived; otherwise they are left as is.
+type FileChange struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`
+}
+
+// TemplateDiff is the difference between what a build would generate and what
+// is currently in its template output directory.
+type TemplateDiff struct {
+	BuildName string           `json:"build_name"`
+	Filename  string           `json:"filename"`
+	Template  []TemplateChange `json:"template"`
+	Files     []FileChange     `json:"files"`
+}
+
+// HasChanges returns whether or not the build would change anything on disk.
+func (d TemplateDiff) HasChanges() bool {
+	return len(d.Template) > 0 || len(d.Files) > 0
+}
+
+// String returns the diff as text: each change is on its own line and is
+// prefixed with +, ~, or -, for add, change, and remove, respectively.
+func (d TemplateDiff) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: %s", d.BuildName, d.Filename)
+	if !d.HasChanges() {
+		buf.WriteString(": no changes")
+		return buf.String()
+	}
+	if len(d.Template) > 0 {
+		buf.WriteString("\n  template:")
+		for _, c := range d.Template {
+			fmt.Fprintf(&buf, "\n    %s", c)
+		}
+	}
+	if len(d.Files) > 0 {
+		buf.WriteString("\n  files:")
+		for _, f := range d.Files {
+			fmt.Fprintf(&buf, "\n    %s %s", diffOpSymbol(f.Op), f.Path)
+		}
+	}
+	return buf.String()
+}
+
+// String returns the change as text: the change's path and value(s) prefixed
+// with +, ~, or -, for add, change, and remove, respectively.
+func (c TemplateChange) String() string {
+	switch c.Op {
+	case DiffAdd:
+		return fmt.Sprintf("+ %s: %s", c.Path, diffValue(c.New))
+	case DiffChange:
+		return fmt.Sprintf("~ %s: %s => %s", c.Path, diffValue(c.Old), diffValue(c.New))
+	case DiffRemove:
+		return fmt.Sprintf("- %s: %s", c.Path, diffValue(c.Old))
+	}
+	return fmt.Sprintf("? %s", c.Path)
+}
+
+// diffOpSymbol returns the symbol used for the passed op in text output.
+func diffOpSymbol(op string) string {
+	switch op {
+	case DiffAdd:
+		return "+"
+	case DiffChange:
+		return "~"
+	case DiffRemove:
+		return "-"
+	}
+	return "?"
+}
+
+// diffValue returns the passed value as compact JSON.
+func diffValue(v interface{}) string {
+	return json.MarshalToString(v)
+}
+
+// diff returns the differences between the Packer template, along with the
+// resources that would be copied for it, and what is currently in the
+// template output directory.  This mirrors create, but nothing is written,
+// copied, archived, or deleted.
+func (p *PackerTemplate) diff(i IODirInf, b BuildInf, dirs, files map[string]string) (TemplateDiff, error) {
+	i.check()
+	fname := p.filename(i, b.Name)
+	d := TemplateDiff{BuildName: b.BuildName, Filename: fname}
+	var err error
+	if p.format == HCL2Template {
+		err = p.diffHCL2(&d, b.Name)
+	} else {
+		err = p.diffJSON(&d)
+	}
+	if err != nil {
+		return d, Error{b.BuildName, err}
+	}
+	generated := []string{fname}
+	if p.hasVarFile() {
+		vf := p.varFilename(i, b.Name)
+		generated = append(generated, vf)
+		vars, err := p.marshalVarFile()
+		if err != nil {
+			return d, Error{b.BuildName, err}
+		}
+		op, err := diffContent(vf, vars)
+		if err != nil {
+			return d, Error{b.BuildName, err}
+		}
+		if op != "" {
+			d.Files = append(d.Files, FileChange{Path: vf, Op: op})
+		}
+	}
+	changes, err := diffResources(i.TemplateOutputDir, generated, dirs, files, contour.GetBool(conf.ArchivePriorBuild))
+	if err != nil {
+		return d, Error{b.BuildName, err}
+	}
+	d.Files = append(d.Files, changes...)
+	sort.Slice(d.Files, func(i, j int) bool { return d.Files[i].Path < d.Files[j].Path })
+	log.Debugf("%s: diff: %d template changes, %d file changes", b.BuildName, len(d.Template), len(d.Files))
+	return d, nil
+}
+
+// diffJSON sets the template differences between the JSON template and the
+// one in d's file.
+func (p *PackerTemplate) diffJSON(d *TemplateDiff) error {
+	// Go through the JSON representation of both templates so that the
+	// comparison is of what would actually be written.
+	tplJSON, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+	var newTpl, oldTpl interface{}
+	err = cjsn.Unmarshal(tplJSON, &newTpl)
+	if err != nil {
+		return err
+	}
+	oldJSON, err := ioutil.ReadFile(d.Filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		oldTpl = map[string]interface{}{}
+	} else {
+		err = cjsn.Unmarshal(oldJSON, &oldTpl)
+		if err != nil {
+			return fmt.Errorf("%s: %s", d.Filename, err)
+		}
+	}
+	d.Template = diffTemplateValues("", oldTpl, newTpl, nil)
+	return nil
+}
+
+// diffHCL2 sets the template differences between the HCL2 template and the
+// one in d's file.  HCL2 templates are compared line by line.
+func (p *PackerTemplate) diffHCL2(d *TemplateDiff, name string) error {
+	tpl, err := p.hcl2(name)
+	if err != nil {
+		return err
+	}
+	old, err := ioutil.ReadFile(d.Filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.Template = diffLines(splitLines(string(old)), splitLines(string(tpl)))
+	return nil
+}
+
+// splitLines splits s into lines; an empty s has no lines.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the lines that would be removed from old and added to
+// new, in order, using their longest common subsequence.  The path of each
+// change is its line number: in old for removes and in new for adds.
+func diffLines(old, new []string) []TemplateChange {
+	// lcs[i][j] is the length of the lcs of old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+			lcs[i][j] = lcs[i+1][j]
+			if lcs[i][j+1] > lcs[i][j] {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var changes []TemplateChange
+	var i, j int
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case j < len(new) && (i == len(old) || lcs[i][j+1] >= lcs[i+1][j]):
+			changes = append(changes, TemplateChange{Path: "line " + strconv.Itoa(j+1), Op: DiffAdd, New: new[j]})
+			j++
+		default:
+			changes = append(changes, TemplateChange{Path: "line " + strconv.Itoa(i+1), Op: DiffRemove, Old: old[i]})
+			i++
+		}
+	}
+	return changes
+}
+
+// diffTemplateValues compares the old and new values at path p and appends
+// their differences to changes.  Maps are compared key by key, in sorted
+// order, and slices are compared element by element.
+func diffTemplateValues(p string, old, new interface{}, changes []TemplateChange) []TemplateChange {
+	switch n := new.(type) {
+	case map[string]interface{}:
+		o, ok := old.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kp := k
+			if p != "" {
+				kp = p + "." + k
+			}
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inOld:
+				changes = append(changes, TemplateChange{Path: kp, Op: DiffAdd, New: nv})
+			case !inNew:
+				changes = append(changes, TemplateChange{Path: kp, Op: DiffRemove, Old: ov})
+			default:
+				changes = diffTemplateValues(kp, ov, nv, changes)
+			}
+		}
+		return changes
+	case []interface{}:
+		o, ok := old.([]interface{})
+		if !ok {
+			break
+		}
+		for j := 0; j < len(o) || j < len(n); j++ {
+			jp := p + "[" + strconv.Itoa(j) + "]"
+			switch {
+			case j >= len(o):
+				changes = append(changes, TemplateChange{Path: jp, Op: DiffAdd, New: n[j]})
+			case j >= len(n):
+				changes = append(changes, TemplateChange{Path: jp, Op: DiffRemove, Old: o[j]})
+			default:
+				changes = diffTemplateValues(jp, o[j], n[j], changes)
+			}
+		}
+		return changes
+	}
+	if !reflect.DeepEqual(old, new) {
+		changes = append(changes, TemplateChange{Path: p, Op: DiffChange, Old: old, New: new})
+	}
+	return changes
+}
+
+// diffContent returns the operation needed to make the named file's contents
+// b: add, if it doesn't exist, change, if its contents are different, or an
+// empty string if nothing would change.
+func diffContent(name string, b []byte) (string, error) {
+	old, err := ioutil.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DiffAdd, nil
+		}
+		return "", err
+	}
+	if bytes.Equal(old, b) {
+		return "", nil
+	}
+	return DiffChange, nil
+}
+
+// diffResources compares the resources that would be copied to the template
+// output directory, outDir, with what is already there.  The files that
+// Feedlot generates, like the template file, aren't resources.  The other
+// files in outDir are only removed if the prior build is archived, so they
+// are only reported, as removed, if archivePrior is true.  The results are
+// sorted by path.
+func diffResources(outDir string, generated []string, dirs, files map[string]string, archivePrior bool) ([]FileChange, error) {
+	// dst: src of every file that would be copied.
+	want := make(map[string]string, len(files))
+	for dst, src := range files {
+		want[filepath.Clean(dst)] = src
+	}
+	for dst, src := range dirs {
+		dir := Archive{}
+		err := dir.DirWalk(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range dir.Files {
+			if file.info == nil || !file.info.Mode().IsRegular() {
+				continue
+			}
+			want[filepath.Join(dst, file.p)] = filepath.Join(src, file.p)
+		}
+	}
+	var changes []FileChange
+	for dst, src := range want {
+		exists, err := pathExists(dst)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			changes = append(changes, FileChange{Path: dst, Op: DiffAdd})
+			continue
+		}
+		srcHash, err := fileHash(src)
+		if err != nil {
+			return nil, err
+		}
+		dstHash, err := fileHash(dst)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(srcHash, dstHash) {
+			changes = append(changes, FileChange{Path: dst, Op: DiffChange})
+		}
+	}
+	// Anything else in the output dir isn't part of the build; it's only
+	// removed when the prior build is archived.
+	exists, err := pathExists(outDir)
+	if err != nil {
+		return nil, err
+	}
+	if archivePrior && exists {
+		dir := Archive{}
+		err = dir.DirWalk(outDir)
+		if err != nil {
+			return nil, err
+		}
+		gen := make(map[string]bool, len(generated))
+		for _, name := range generated {
+			gen[filepath.Clean(name)] = true
+		}
+		for _, file := range dir.Files {
+			if file.info == nil || !file.info.Mode().IsRegular() {
+				continue
+			}
+			name := filepath.Join(outDir, file.p)
+			if gen[name] {
+				continue
+			}
+			if _, ok := want[name]; !ok {
+				changes = append(changes, FileChange{Path: name, Op: DiffRemove})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// fileHash returns the sha256 of the named file's contents.
+func fileHash(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
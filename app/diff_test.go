@@ -0,0 +1,212 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffTemplateValues(t *testing.T) {
+	old := map[string]interface{}{
+		"description": "test template",
+		"builders": []interface{}{
+			map[string]interface{}{
+				"type":      "virtualbox-iso",
+				"boot_wait": "5s",
+				"headless":  true,
+			},
+		},
+		"variables": map[string]interface{}{
+			"foo": "bar",
+		},
+	}
+	new := map[string]interface{}{
+		"description": "test template",
+		"builders": []interface{}{
+			map[string]interface{}{
+				"type":      "virtualbox-iso",
+				"boot_wait": "10s",
+				"disk_size": float64(20000),
+			},
+			map[string]interface{}{
+				"type": "vmware-iso",
+			},
+		},
+		"min_packer_version": "0.8.0",
+	}
+	expected := []TemplateChange{
+		{Path: "builders[0].boot_wait", Op: DiffChange, Old: "5s", New: "10s"},
+		{Path: "builders[0].disk_size", Op: DiffAdd, New: float64(20000)},
+		{Path: "builders[0].headless", Op: DiffRemove, Old: true},
+		{Path: "builders[1]", Op: DiffAdd, New: map[string]interface{}{"type": "vmware-iso"}},
+		{Path: "min_packer_version", Op: DiffAdd, New: "0.8.0"},
+		{Path: "variables", Op: DiffRemove, Old: map[string]interface{}{"foo": "bar"}},
+	}
+	changes := diffTemplateValues("", old, new, nil)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %#v, got %#v", expected, changes)
+	}
+	changes = diffTemplateValues("", new, new, nil)
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %#v", changes)
+	}
+}
+
+func TestDiffResources(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "feedlot-diff-src-")
+	if err != nil {
+		t.Fatalf("unexpected error while setting up a diff test: %s", err)
+	}
+	defer os.RemoveAll(srcDir)
+	outDir, err := ioutil.TempDir("", "feedlot-diff-out-")
+	if err != nil {
+		t.Fatalf("unexpected error while setting up a diff test: %s", err)
+	}
+	defer os.RemoveAll(outDir)
+	// source files
+	srcFiles := map[string]string{
+		"setup.sh":         "echo setup",
+		"cleanup.sh":       "echo cleanup",
+		"new.sh":           "echo new",
+		"http/preseed.cfg": "d-i foo",
+	}
+	for name, content := range srcFiles {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(srcDir, name)), 0755)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up a diff test: %s", err)
+		}
+		err = ioutil.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up a diff test: %s", err)
+		}
+	}
+	// what's already in the output dir: setup.sh is unchanged, cleanup.sh
+	// has changed, and old.sh is no longer part of the build.
+	outFiles := map[string]string{
+		"test.json":        "{}",
+		"shell/setup.sh":   "echo setup",
+		"shell/cleanup.sh": "echo old cleanup",
+		"shell/old.sh":     "echo old",
+	}
+	for name, content := range outFiles {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(outDir, name)), 0755)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up a diff test: %s", err)
+		}
+		err = ioutil.WriteFile(filepath.Join(outDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up a diff test: %s", err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(outDir, "shell/setup.sh"):   filepath.Join(srcDir, "setup.sh"),
+		filepath.Join(outDir, "shell/cleanup.sh"): filepath.Join(srcDir, "cleanup.sh"),
+		filepath.Join(outDir, "shell/new.sh"):     filepath.Join(srcDir, "new.sh"),
+	}
+	dirs := map[string]string{
+		filepath.Join(outDir, "http"): filepath.Join(srcDir, "http"),
+	}
+	changes := []FileChange{
+		{Path: filepath.Join(outDir, "http/preseed.cfg"), Op: DiffAdd},
+		{Path: filepath.Join(outDir, "shell/cleanup.sh"), Op: DiffChange},
+		{Path: filepath.Join(outDir, "shell/new.sh"), Op: DiffAdd},
+	}
+	tests := []struct {
+		archivePrior bool
+		expected     []FileChange
+	}{
+		// old.sh is only removed if the prior build is archived.
+		{true, []FileChange{changes[0], changes[1], changes[2], {Path: filepath.Join(outDir, "shell/old.sh"), Op: DiffRemove}}},
+		{false, changes},
+	}
+	for i, test := range tests {
+		changes, err := diffResources(outDir, []string{filepath.Join(outDir, "test.json")}, dirs, files, test.archivePrior)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(changes, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, changes)
+		}
+	}
+	// nothing should have been changed
+	for name, content := range outFiles {
+		b, err := ioutil.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Errorf("%s: expected no error, got %q", name, err)
+			continue
+		}
+		if string(b) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, string(b))
+		}
+	}
+	_, err = os.Stat(filepath.Join(outDir, "http"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected %s to not exist", filepath.Join(outDir, "http"))
+	}
+}
+
+func TestTemplateDiffString(t *testing.T) {
+	tests := []struct {
+		diff     TemplateDiff
+		expected string
+	}{
+		{TemplateDiff{BuildName: "test", Filename: "out/test.json"}, "test: out/test.json: no changes"},
+		{
+			TemplateDiff{
+				BuildName: "test",
+				Filename:  "out/test.json",
+				Template: []TemplateChange{
+					{Path: "builders[0].boot_wait", Op: DiffChange, Old: "5s", New: "10s"},
+					{Path: "description", Op: DiffAdd, New: "test"},
+					{Path: "variables", Op: DiffRemove, Old: map[string]interface{}{"foo": "bar"}},
+				},
+				Files: []FileChange{
+					{Path: "out/shell/new.sh", Op: DiffAdd},
+					{Path: "out/shell/old.sh", Op: DiffRemove},
+				},
+			},
+			"test: out/test.json\n  template:\n    ~ builders[0].boot_wait: \"5s\" => \"10s\"\n    + description: \"test\"\n    - variables: {\"foo\":\"bar\"}\n  files:\n    + out/shell/new.sh\n    - out/shell/old.sh",
+		},
+	}
+	for i, test := range tests {
+		s := test.diff.String()
+		if s != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, s)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		old      []string
+		new      []string
+		expected []TemplateChange
+	}{
+		{nil, nil, nil},
+		{
+			nil, []string{"a", "b"},
+			[]TemplateChange{
+				{Path: "line 1", Op: DiffAdd, New: "a"},
+				{Path: "line 2", Op: DiffAdd, New: "b"},
+			},
+		},
+		{
+			[]string{"a", "b", "c"}, []string{"a", "x", "c", "d"},
+			[]TemplateChange{
+				{Path: "line 2", Op: DiffAdd, New: "x"},
+				{Path: "line 2", Op: DiffRemove, Old: "b"},
+				{Path: "line 4", Op: DiffAdd, New: "d"},
+			},
+		},
+		{[]string{"a", "b"}, []string{"a", "b"}, nil},
+	}
+	for i, test := range tests {
+		changes := diffLines(test.old, test.new)
+		if !reflect.DeepEqual(changes, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, changes)
+		}
+	}
+}
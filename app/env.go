@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+)
+
+// envs returns the names of the environments, envs, whose builds should be
+// loaded, in the order they were specified.  Duplicates and empty names are
+// ignored.
+func envs() []string {
+	var names []string
+	for _, env := range strings.Split(contour.GetString(conf.Envs), ",") {
+		env = strings.TrimSpace(env)
+		if env == "" || contains(names, env) {
+			continue
+		}
+		names = append(names, env)
+	}
+	return names
+}
+
+// envBuildName returns the name that a build within an env is addressed by:
+// the env's name and the build's name separated by the env separator.
+func envBuildName(env, name string) string {
+	if env == "" {
+		return name
+	}
+	return env + contour.GetString(conf.EnvSeparator) + name
+}
+
+// envDir returns the env's directory within the conf dir.  An error is
+// returned if the env's directory doesn't exist.
+func envDir(cDir, env string) (string, error) {
+	dir := filepath.Join(cDir, env)
+	fi, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("env %s: not found", env)
+		}
+		return "", fmt.Errorf("env %s: %s", env, err)
+	}
+	if !fi.IsDir() {
+		return "", fmt.Errorf("env %s: %s is not a directory", env, dir)
+	}
+	return dir, nil
+}
+
+// envConfFile returns the location and format of an env's conf file, e.g.
+// its default or build_list file, if the env has one.  If it doesn't, an
+// empty string is returned.
+func envConfFile(env, name string) (string, conf.ConfFormat) {
+	fname := filepath.Join(env, fmt.Sprintf("%s.%s", name, contour.GetString(conf.Format)))
+	found, format, err := conf.ConfFilename(conf.FindConfFile("", fname))
+	if err != nil {
+		return "", format
+	}
+	return found, format
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+)
+
+func TestEnvs(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"", nil},
+		{"prod", []string{"prod"}},
+		{"prod, dev,,prod", []string{"prod", "dev"}},
+	}
+	defer contour.UpdateString(conf.Envs, "")
+	for i, test := range tests {
+		contour.UpdateString(conf.Envs, test.value)
+		envs := envs()
+		if !reflect.DeepEqual(envs, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, envs)
+		}
+	}
+}
+
+func TestEnvBuildName(t *testing.T) {
+	tests := []struct {
+		env       string
+		separator string
+		expected  string
+	}{
+		{"", "-", "server"},
+		{"prod", "-", "prod-server"},
+		{"prod", "/", "prod/server"},
+	}
+	defer contour.UpdateString(conf.EnvSeparator, "-")
+	for i, test := range tests {
+		contour.UpdateString(conf.EnvSeparator, test.separator)
+		name := envBuildName(test.env, "server")
+		if name != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, name)
+		}
+	}
+}
+
+func TestLoadEnvs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feedlot-env-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"build.json":           `{"server": {"distro": "ubuntu"}}`,
+		"build_list.json":      `{"all": {"builds": ["server"]}, "servers": {"builds": ["server"]}}`,
+		"prod/build.json":      `{"server": {"distro": "centos"}}`,
+		"prod/build_list.json": `{"all": {"builds": ["server", "prod-server"]}}`,
+		"dev/build.json":       `{"desktop": {"distro": "ubuntu"}}`,
+	}
+	for name, content := range files {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	contour.UpdateString(conf.Dir, dir)
+	contour.UpdateString(conf.Format, "json")
+	contour.UpdateString(conf.Envs, "prod")
+	defer contour.UpdateString(conf.Envs, "")
+	defer func() { BuildDefs = map[string]Builds{} }()
+	BuildDefs = map[string]Builds{}
+	err = loadBuilds()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	names := allBuildNames()
+	expected := []string{"prod-server", "server"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	bTpl, err := getBuildTemplate("prod-server")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if bTpl.env != "prod" {
+		t.Errorf("expected env %q, got %q", "prod", bTpl.env)
+	}
+	if bTpl.Distro != "centos" {
+		t.Errorf("expected distro %q, got %q", "centos", bTpl.Distro)
+	}
+	bl := &BuildLists{}
+	err = bl.Load("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	lists := map[string]List{
+		"all":     {Builds: []string{"server", "prod-server"}},
+		"servers": {Builds: []string{"server"}},
+	}
+	if !reflect.DeepEqual(bl.Lists, lists) {
+		t.Errorf("expected %v, got %v", lists, bl.Lists)
+	}
+	// an env that doesn't exist is an error
+	contour.UpdateString(conf.Envs, "prod,qa")
+	BuildDefs = map[string]Builds{}
+	err = loadBuilds()
+	if err == nil {
+		t.Error("expected an error, got none")
+	} else if err.Error() != "load builds: env qa: not found" {
+		t.Errorf("expected %q, got %q", "load builds: env qa: not found", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -110,7 +111,12 @@ func init() {
 // whether its been set or not.
 type distroDefaults struct {
 	Templates map[Distro]RawTemplate
-	IsSet     bool
+	// EnvTemplates contains the defaults for each supported distro for every
+	// env that has its own default file, keyed by env name.
+	EnvTemplates map[string]map[Distro]RawTemplate
+	IsSet        bool
+	// supported are the supported distros that the defaults were set from.
+	supported *SupportedDistros
 }
 
 // GetTemplate returns a deep copy of the default template for the passed
@@ -127,6 +133,23 @@ func (d *distroDefaults) GetTemplate(n string) (*RawTemplate, error) {
 	return t.Copy(), nil
 }
 
+// getEnvTemplate returns a deep copy of the default template for the passed
+// distro name within the env.  If the env doesn't have its own defaults, the
+// distro's default template is returned.
+func (d *distroDefaults) getEnvTemplate(env, n string) (*RawTemplate, error) {
+	tpls, ok := d.EnvTemplates[env]
+	if !ok {
+		return d.GetTemplate(n)
+	}
+	t, ok := tpls[ParseDistro(n)]
+	if !ok {
+		err := fmt.Errorf("unsupported distro: %s", n)
+		log.Error(err)
+		return nil, err
+	}
+	return t.Copy(), nil
+}
+
 // Set sets the default templates for each distro.
 func (d *distroDefaults) Set() error {
 	dflts := &Defaults{}
@@ -151,21 +174,56 @@ func (d *distroDefaults) Set() error {
 		log.Error(err)
 		return err
 	}
-	d.Templates = map[Distro]RawTemplate{}
+	d.Templates, err = distroTemplates(dflts, nil, s)
+	if err != nil {
+		return err
+	}
+	d.supported = s
+	// Envs with their own defaults get their own distro templates: the env's
+	// defaults are merged in before the supported distro's settings.
+	d.EnvTemplates = map[string]map[Distro]RawTemplate{}
+	for _, env := range envs() {
+		name, format := envConfFile(env, "default")
+		if name == "" {
+			continue
+		}
+		log.Debugf("load defaults for env %s", env)
+		envDflts := &Defaults{}
+		err = envDflts.load(name, format)
+		if err != nil {
+			err = Error{slug: fmt.Sprintf("set distro defaults: env %s", env), err: err}
+			log.Error(err)
+			return err
+		}
+		d.EnvTemplates[env], err = distroTemplates(dflts, envDflts, s)
+		if err != nil {
+			return err
+		}
+	}
+	DistroDefaults.IsSet = true
+	return nil
+}
+
+// distroTemplates generates the default template for each supported distro
+// from the supported distro's settings.  If envDflts isn't nil, its settings
+// are merged in before the supported distro's settings are.
+func distroTemplates(dflts, envDflts *Defaults, s *SupportedDistros) (map[Distro]RawTemplate, error) {
+	tpls := map[Distro]RawTemplate{}
 	// Generate the default settings for each distro.
 	for k, v := range s.Distros {
 		// See if the base url exists for non centos distros
 		// It isn't required for debian because automatic resolution of iso
 		// information is not supported.
 		if v.BaseURL == "" && k != CentOS.String() {
-			err = Error{slug: fmt.Sprintf("set distro defaults: %s", k), err: RequiredSettingErr{"base_url"}}
+			err := Error{slug: fmt.Sprintf("set distro defaults: %s", k), err: RequiredSettingErr{"base_url"}}
 			log.Error(err)
-			return err
+			return nil, err
 
 		}
 		// Create the struct for the default settings
 		tmp := newRawTemplate()
 		// First assign it all the default settings.
+		tmp.setOrigin(DefaultLayer, dflts.file)
 
 		/*
 			tmp.BuildInf = deepcopy.Iface(dflts.BuildInf).(BuildInf)
@@ -174,19 +232,27 @@ func (d *distroDefaults) Set() error {
 			tmp.build = dflts.build.copy()
 			tmp.Distro = strings.ToLower(k)
 		*/
+		if envDflts != nil {
+			err := tmp.mergeBuildSettings(envDflts.rawTemplate())
+			if err != nil {
+				err = Error{slug: fmt.Sprintf("set distro defaults: %s", k), err: err}
+				log.Error(err)
+				return nil, err
+			}
+		}
 		// Now update it with the distro settings.
+		tmp.setOrigin(SupportedLayer, s.file)
 		tmp.BaseURL = appendSlash(v.BaseURL)
 		tmp.Arch, tmp.Image, tmp.Release = getDefaultISOInfo(v.DefImage)
-		err = tmp.setDefaults(v)
+		err := tmp.setDefaults(v)
 		if err != nil {
 			err = Error{slug: fmt.Sprintf("set distro defaults: %s", k), err: err}
 			log.Error(err)
-			return err
+			return nil, err
 		}
-		d.Templates[ParseDistro(k)] = *tmp
+		tpls[ParseDistro(k)] = *tmp
 	}
-	DistroDefaults.IsSet = true
-	return nil
+	return tpls, nil
 }
 
 // loadBuilds accepts a list of builds and loads the build information for
@@ -200,12 +266,16 @@ func (d *distroDefaults) Set() error {
 // Subdirectories are called environments, envs, and are a way to namespace
 // builds. An envs' name is the same as the subdirectories name. Env names can
 // be concatonated together, using the env_separator_char as the separator; '-'
-// is the default value.
+// is the default value.  Only the envs passed using the envs flag are loaded;
+// a build in an env is addressed by the env's name and the build's name, e.g.
+// prod-server.
+//
+// Once all the builds are loaded, each matrix build is expanded into a build
+// for each of its combinations; see expandMatrixBuilds.
 //
 // The sourceDir and sourceDirIsRelative settings from the defaults file is
 // passed so that each build template's Packer source directory can be set
 // if the template doesn't define its own.
-// TODO: add env support
 func loadBuilds() error {
 	log.Debug("loading builds")
 	// index all the files in the configuration directory, including subdir
@@ -214,17 +284,67 @@ func loadBuilds() error {
 	if contour.GetBool(conf.Example) {
 		cDir = filepath.Join(contour.GetString(conf.ExampleDir), cDir)
 	}
+	err := loadBuildDir(cDir, "")
+	if err != nil {
+		return err
+	}
+	for _, env := range envs() {
+		dir, err := envDir(cDir, env)
+		if err != nil {
+			return Error{slug: "load builds", err: err}
+		}
+		err = loadBuildDir(dir, env)
+		if err != nil {
+			return err
+		}
+	}
+	err = expandMatrixBuilds()
+	if err != nil {
+		return Error{slug: "load builds", err: err}
+	}
+	log.Debug("builds loaded")
+	return nil
+}
+
+// supportedDistros returns the supported distros.  They are loaded if the
+// distro defaults haven't been set.
+func supportedDistros() (*SupportedDistros, error) {
+	if DistroDefaults.supported != nil {
+		return DistroDefaults.supported, nil
+	}
+	s := &SupportedDistros{}
+	err := s.Load("")
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadBuildDir loads the build configuration files in dir into BuildDefs.  If
+// the dir is an env's, the env's name is passed and the builds are named
+// using it.
+//
+// Each file's format is determined by its extension, so a dir can have build
+// files in different formats; files whose extension isn't a supported
+// format's are skipped.  The files are loaded in lexical order.  Build names
+// must be unique: if a build has already been loaded from another file, an
+// error naming both files is returned.
+func loadBuildDir(dir, env string) error {
 	// names come from os.FileInfo.Name() results
-	// TODO: add handling of dir names and recursive for envs support
-	_, fnames, err := indexDir(cDir)
+	_, fnames, err := indexDir(dir)
 	if err != nil {
 		return Error{slug: "load builds", err: err}
 	}
+	sort.Strings(fnames)
 	// for each file
 	for _, fname := range fnames {
 		// get the file name, without the extension
 		ext := filepath.Ext(fname)
 		file := strings.TrimSuffix(fname, ext)
+		if conf.FileFormat(fname) == conf.UnsupportedConfFormat {
+			log.Debugf("skipping %s: not a supported conf format", filepath.Join(dir, fname))
+			continue
+		}
 		// skip non-build files.
 		switch file {
 		case "build_list":
@@ -236,16 +356,47 @@ func loadBuilds() error {
 		case "supported":
 			continue
 		}
-		fname = filepath.Join(cDir, fname)
+		fname = filepath.Join(dir, fname)
 		log.Debugf("loading build file %s", fname)
-		b := Builds{}
+		b := Builds{env: env}
 		err := b.Load(fname)
 		if err != nil {
 			return Error{slug: "load builds", err: err}
 		}
+		if env != "" {
+			tpls := make(map[string]*RawTemplate, len(b.Templates))
+			for name, tpl := range b.Templates {
+				tpls[envBuildName(env, name)] = tpl
+			}
+			b.Templates = tpls
+		}
+		err = checkDuplicateBuilds(fname, b)
+		if err != nil {
+			return Error{slug: "load builds", err: err}
+		}
 		BuildDefs[fname] = b
 	}
-	log.Debug("builds loaded")
+	return nil
+}
+
+// checkDuplicateBuilds returns an error if any of the builds loaded from the
+// file have already been loaded from a different file.
+func checkDuplicateBuilds(fname string, b Builds) error {
+	names := make([]string, 0, len(b.Templates))
+	for name := range b.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for f, blds := range BuildDefs {
+			if f == fname {
+				continue
+			}
+			if _, ok := blds.Templates[name]; ok {
+				return fmt.Errorf("duplicate build %s: defined in %s and %s", name, f, fname)
+			}
+		}
+	}
 	return nil
 }
 
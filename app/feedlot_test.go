@@ -7,9 +7,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
 )
 
 var today = time.Now().Local().Format("2006-01-02")
@@ -1096,3 +1100,49 @@ next:
 done:
 	_ = os.RemoveAll(dir)
 }
+
+func TestLoadBuildDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "feedlot-build-dir-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	files := map[string]string{
+		"a.json":    `{"server": {"distro": "ubuntu"}}`,
+		"b.cjsn":    `{"desktop": {"distro": "ubuntu"}}`,
+		"README.md": "not a build file",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the format setting doesn't matter; each file's extension is used.
+	contour.UpdateString(conf.Format, "toml")
+	defer contour.UpdateString(conf.Format, "json")
+	defer func() { BuildDefs = map[string]Builds{} }()
+	BuildDefs = map[string]Builds{}
+	err = loadBuildDir(tmpDir, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	names := allBuildNames()
+	expected := []string{"desktop", "server"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	// a build defined in more than one file is an error
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "c.json"), []byte(`{"server": {"distro": "centos"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	BuildDefs = map[string]Builds{}
+	err = loadBuildDir(tmpDir, "")
+	expectedErr := fmt.Sprintf("load builds: duplicate build server: defined in %s and %s", filepath.Join(tmpDir, "a.json"), filepath.Join(tmpDir, "c.json"))
+	if err == nil {
+		t.Errorf("expected error %q, got none", expectedErr)
+	} else if err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %q", expectedErr, err)
+	}
+}
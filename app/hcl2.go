@@ -0,0 +1,459 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Packer template formats.
+const (
+	// JSONTemplate is the Packer JSON template format: <name>.json.
+	JSONTemplate = "json"
+	// HCL2Template is the Packer HCL2 template format: <name>.pkr.hcl.
+	HCL2Template = "hcl2"
+)
+
+// parseTemplateFormat returns the Packer template format for s.  An empty
+// string is the JSON format.
+func parseTemplateFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", JSONTemplate:
+		return JSONTemplate, nil
+	case HCL2Template:
+		return HCL2Template, nil
+	}
+	return "", fmt.Errorf("%s: unsupported template format", s)
+}
+
+// hcl2Plugins maps component types to the name of the plugin that provides
+// them.  Components that are built into Packer aren't included.
+var hcl2Plugins = map[string]string{
+	AmazonChroot.String():     "amazon",
+	AmazonEBS.String():        "amazon",
+	AmazonInstance.String():   "amazon",
+	DigitalOcean.String():     "digitalocean",
+	Docker.String():           "docker",
+	GoogleCompute.String():    "googlecompute",
+	OpenStack.String():        "openstack",
+	ParallelsISO.String():     "parallels",
+	ParallelsPVM.String():     "parallels",
+	QEMU.String():             "qemu",
+	VirtualBoxISO.String():    "virtualbox",
+	VirtualBoxOVF.String():    "virtualbox",
+	VMWareISO.String():        "vmware",
+	VMWareVMX.String():        "vmware",
+	DockerImport.String():     "docker",
+	DockerPush.String():       "docker",
+	DockerSave.String():       "docker",
+	DockerTag.String():        "docker",
+	Vagrant.String():          "vagrant",
+	VagrantCloud.String():     "vagrant",
+	VSphere.String():          "vsphere",
+	Ansible.String():          "ansible",
+	AnsibleLocal.String():     "ansible",
+	ChefClient.String():       "chef",
+	ChefSolo.String():         "chef",
+	PuppetMasterless.String(): "puppet",
+	PuppetServer.String():     "puppet",
+	Salt.String():             "salt",
+}
+
+// hcl2PluginVersion is the version constraint used for required plugins.
+const hcl2PluginVersion = ">= 1.0.0"
+
+var (
+	// hcl2Ident matches strings that can be used as HCL2 identifiers.
+	hcl2Ident = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	// hcl2NameChars matches the characters that can't be in a source name.
+	hcl2NameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+	// hcl2TemplateDir matches JSON template template_dir references.
+	hcl2TemplateDir = regexp.MustCompile(`{{\s*template_dir\s*}}`)
+	// hcl2Timestamp matches JSON template timestamp references.
+	hcl2Timestamp = regexp.MustCompile(`{{\s*timestamp\s*}}`)
+)
+
+// hcl2 returns the template in Packer's HCL2 format.  Each builder becomes a
+// source block, which is named after the builder's name setting, if it has
+// one, or name.  The provisioners and post-processors are in the build block;
+// a post-processor that is a sequence is written as a post-processors block.
+// The builders that their only, except, and override settings refer to are
+// referred to by source, <type>.<name>.
+func (p *PackerTemplate) hcl2(name string) ([]byte, error) {
+	var buf bytes.Buffer
+	name = hcl2NameChars.ReplaceAllString(name, "_")
+	// required plugins, from the component types in use
+	plugins := map[string]bool{}
+	for _, c := range p.components() {
+		typ, _ := c["type"].(string)
+		if plugin, ok := hcl2Plugins[typ]; ok {
+			plugins[plugin] = true
+		}
+	}
+	packer := map[string]interface{}{}
+	if p.MinPackerVersion != "" {
+		packer["required_version"] = ">= " + p.MinPackerVersion
+	}
+	if len(plugins) > 0 {
+		req := map[string]interface{}{}
+		for plugin := range plugins {
+			req[plugin] = map[string]interface{}{
+				"source":  "github.com/hashicorp/" + plugin,
+				"version": hcl2PluginVersion,
+			}
+		}
+		packer["required_plugins"] = []interface{}{req}
+	}
+	if len(packer) > 0 {
+		buf.WriteString("\n")
+		hcl2Block(&buf, "packer", nil, packer, 0)
+	}
+	// variables
+	vars := make([]string, 0, len(p.Variables))
+	for k := range p.Variables {
+		vars = append(vars, k)
+	}
+	sort.Strings(vars)
+	for _, k := range vars {
+		body := map[string]interface{}{"default": p.Variables[k]}
+		if contains(p.SensitiveVariables, k) {
+			body["sensitive"] = true
+		}
+		buf.WriteString("\n")
+		hcl2Block(&buf, "variable", []string{k}, body, 0)
+	}
+	// sources; refs are the source references of the builders, by the name
+	// that they have in the JSON template.
+	var sources []interface{}
+	refs := make(map[string]string, len(p.Builders))
+	for i, v := range p.Builders {
+		b, ok := hcl2Object(v)
+		if !ok {
+			return nil, fmt.Errorf("builders[%d]: expected an object, got %v", i, v)
+		}
+		typ, _ := b["type"].(string)
+		srcName := name
+		if n, ok := b["name"].(string); ok && n != "" {
+			srcName = hcl2NameChars.ReplaceAllString(n, "_")
+		}
+		body := make(map[string]interface{}, len(b))
+		for k, v := range b {
+			if k == "type" || k == "name" {
+				continue
+			}
+			body[k] = v
+		}
+		buf.WriteString("\n")
+		hcl2Block(&buf, "source", []string{typ, srcName}, body, 0)
+		sources = append(sources, fmt.Sprintf("source.%s.%s", typ, srcName))
+		bName, _ := b["name"].(string)
+		if bName == "" {
+			bName = typ
+		}
+		refs[bName] = typ + "." + srcName
+	}
+	// the build
+	buf.WriteString("\nbuild {\n")
+	build := map[string]interface{}{"name": name, "sources": sources}
+	if p.Description != "" {
+		build["description"] = p.Description
+	}
+	hcl2Body(&buf, build, 1)
+	for i, v := range p.Provisioners {
+		pr, ok := hcl2Object(v)
+		if !ok {
+			return nil, fmt.Errorf("provisioners[%d]: expected an object, got %v", i, v)
+		}
+		buf.WriteString("\n")
+		hcl2ComponentBlock(&buf, "provisioner", hcl2BuilderRefs(pr, refs), 1)
+	}
+	for i, v := range p.PostProcessors {
+		buf.WriteString("\n")
+		if pp, ok := hcl2Object(v); ok {
+			hcl2ComponentBlock(&buf, "post-processor", hcl2BuilderRefs(pp, refs), 1)
+			continue
+		}
+		seq := reflect.ValueOf(v)
+		if seq.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("post-processors[%d]: expected an object or an array, got %v", i, v)
+		}
+		buf.WriteString("  post-processors {\n")
+		for j := 0; j < seq.Len(); j++ {
+			if j > 0 {
+				buf.WriteString("\n")
+			}
+			pp, ok := hcl2Object(seq.Index(j).Interface())
+			if !ok {
+				return nil, fmt.Errorf("post-processors[%d][%d]: expected an object, got %v", i, j, seq.Index(j).Interface())
+			}
+			hcl2ComponentBlock(&buf, "post-processor", hcl2BuilderRefs(pp, refs), 2)
+		}
+		buf.WriteString("  }\n")
+	}
+	buf.WriteString("}\n")
+	return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+}
+
+// components returns every builder, post-processor, and provisioner in the
+// template.
+func (p *PackerTemplate) components() []map[string]interface{} {
+	var comps []map[string]interface{}
+	for _, v := range p.Builders {
+		if c, ok := hcl2Object(v); ok {
+			comps = append(comps, c)
+		}
+	}
+	for _, v := range p.PostProcessors {
+		if c, ok := hcl2Object(v); ok {
+			comps = append(comps, c)
+			continue
+		}
+		seq := reflect.ValueOf(v)
+		if seq.Kind() != reflect.Slice {
+			continue
+		}
+		for i := 0; i < seq.Len(); i++ {
+			if c, ok := hcl2Object(seq.Index(i).Interface()); ok {
+				comps = append(comps, c)
+			}
+		}
+	}
+	for _, v := range p.Provisioners {
+		if c, ok := hcl2Object(v); ok {
+			comps = append(comps, c)
+		}
+	}
+	return comps
+}
+
+// hcl2BuilderRefs returns the provisioner or post-processor, c, with the
+// builder names in its only and except lists, and its override keys, replaced
+// by their source references in refs.  Names that aren't in refs are left as
+// is.  The vagrant post-processor's override is keyed by Vagrant provider, so
+// it isn't changed.
+func hcl2BuilderRefs(c map[string]interface{}, refs map[string]string) map[string]interface{} {
+	ref := func(s string) string {
+		if r, ok := refs[s]; ok {
+			return r
+		}
+		return s
+	}
+	m := make(map[string]interface{}, len(c))
+	for k, v := range c {
+		m[k] = v
+	}
+	for _, k := range []string{"only", "except"} {
+		switch l := c[k].(type) {
+		case []string:
+			s := make([]string, len(l))
+			for i, name := range l {
+				s[i] = ref(name)
+			}
+			m[k] = s
+		case []interface{}:
+			s := make([]interface{}, len(l))
+			for i, name := range l {
+				if n, ok := name.(string); ok {
+					name = ref(n)
+				}
+				s[i] = name
+			}
+			m[k] = s
+		}
+	}
+	if c["type"] == Vagrant.String() {
+		return m
+	}
+	if o, ok := hcl2Object(c["override"]); ok {
+		override := make(map[string]interface{}, len(o))
+		for name, v := range o {
+			override[ref(name)] = v
+		}
+		m["override"] = override
+	}
+	return m
+}
+
+// hcl2ComponentBlock writes a provisioner or post-processor block; the
+// component's type is the block's label.
+func hcl2ComponentBlock(buf *bytes.Buffer, typ string, c map[string]interface{}, depth int) {
+	t, _ := c["type"].(string)
+	body := make(map[string]interface{}, len(c))
+	for k, v := range c {
+		if k == "type" {
+			continue
+		}
+		body[k] = v
+	}
+	hcl2Block(buf, typ, []string{t}, body, depth)
+}
+
+// hcl2Block writes a block.
+func hcl2Block(buf *bytes.Buffer, typ string, labels []string, body map[string]interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(buf, "%s%s", indent, typ)
+	for _, l := range labels {
+		fmt.Fprintf(buf, " %s", strconv.Quote(l))
+	}
+	if len(body) == 0 {
+		buf.WriteString(" {}\n")
+		return
+	}
+	buf.WriteString(" {\n")
+	hcl2Body(buf, body, depth+1)
+	fmt.Fprintf(buf, "%s}\n", indent)
+}
+
+// hcl2Body writes the attributes of a block followed by its nested blocks.
+// Arrays of objects are written as nested blocks.  Everything is written in
+// key order.  The equal signs of consecutive single line attributes are
+// aligned; an attribute with a multi-line value isn't aligned and starts a
+// new run.
+func hcl2Body(buf *bytes.Buffer, body map[string]interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	var attrs, blocks []string
+	for k, v := range body {
+		if hcl2IsBlocks(v) {
+			blocks = append(blocks, k)
+			continue
+		}
+		attrs = append(attrs, k)
+	}
+	sort.Strings(attrs)
+	sort.Strings(blocks)
+	vals := make([]string, len(attrs))
+	for i, k := range attrs {
+		vals[i] = hcl2Value(body[k], depth)
+	}
+	for i := 0; i < len(attrs); {
+		// find the run of single line attributes
+		j := i
+		var width int
+		for ; j < len(attrs) && !strings.Contains(vals[j], "\n"); j++ {
+			if len(hcl2Key(attrs[j])) > width {
+				width = len(hcl2Key(attrs[j]))
+			}
+		}
+		for ; i < j; i++ {
+			fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, hcl2Key(attrs[i]), vals[i])
+		}
+		if i < len(attrs) {
+			fmt.Fprintf(buf, "%s%s = %s\n", indent, hcl2Key(attrs[i]), vals[i])
+			i++
+		}
+	}
+	for n, k := range blocks {
+		v := reflect.ValueOf(body[k])
+		for i := 0; i < v.Len(); i++ {
+			if len(attrs) > 0 || n > 0 || i > 0 {
+				buf.WriteString("\n")
+			}
+			obj, _ := hcl2Object(v.Index(i).Interface())
+			hcl2Block(buf, k, nil, obj, depth)
+		}
+	}
+}
+
+// hcl2IsBlocks returns whether or not v is a non-empty array of objects.
+func hcl2IsBlocks(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Len() == 0 {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if _, ok := hcl2Object(rv.Index(i).Interface()); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hcl2Object returns v as a map[string]interface{}, if it is a map with
+// string keys.
+func hcl2Object(v interface{}) (map[string]interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	for _, k := range rv.MapKeys() {
+		m[k.String()] = rv.MapIndex(k).Interface()
+	}
+	return m, true
+}
+
+// hcl2Key returns k as an attribute or object key; keys that aren't
+// identifiers are quoted.
+func hcl2Key(k string) string {
+	if hcl2Ident.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
+
+// hcl2Value returns v as an HCL2 expression.  Arrays that fit on a line are
+// written on one line; otherwise each element is on its own line.
+func hcl2Value(v interface{}, depth int) string {
+	if v == nil {
+		return "null"
+	}
+	if s, ok := v.(string); ok {
+		return hcl2String(s)
+	}
+	if obj, ok := hcl2Object(v); ok {
+		if len(obj) == 0 {
+			return "{}"
+		}
+		var buf bytes.Buffer
+		buf.WriteString("{\n")
+		hcl2Body(&buf, obj, depth+1)
+		fmt.Fprintf(&buf, "%s}", strings.Repeat("  ", depth))
+		return buf.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, rv.Len())
+		multiline := false
+		for i := range elems {
+			elems[i] = hcl2Value(rv.Index(i).Interface(), depth+1)
+			if strings.Contains(elems[i], "\n") {
+				multiline = true
+			}
+		}
+		s := "[" + strings.Join(elems, ", ") + "]"
+		if !multiline && len(s)+depth*2 <= 80 {
+			return s
+		}
+		indent := strings.Repeat("  ", depth+1)
+		return "[\n" + indent + strings.Join(elems, ",\n"+indent) + ",\n" + strings.Repeat("  ", depth) + "]"
+	}
+	return hcl2String(fmt.Sprint(v))
+}
+
+// hcl2String returns s as a quoted HCL2 string.  HCL2 template sequences are
+// escaped and the JSON template user, template_dir, and timestamp functions
+// are converted to their HCL2 equivalents.
+func hcl2String(s string) string {
+	s = strings.Replace(s, "${", "$${", -1)
+	s = strings.Replace(s, "%{", "%%{", -1)
+	s = userVarRef.ReplaceAllString(s, "${var.$1}")
+	s = hcl2TemplateDir.ReplaceAllString(s, "${path.root}")
+	s = hcl2Timestamp.ReplaceAllString(s, "${timestamp()}")
+	return strconv.Quote(s)
+}
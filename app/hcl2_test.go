@@ -0,0 +1,191 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestPackerTemplateHCL2(t *testing.T) {
+	p := PackerTemplate{
+		Description:      "test template",
+		MinPackerVersion: "1.5.0",
+		Builders: []interface{}{
+			map[string]interface{}{
+				"type":           "virtualbox-iso",
+				"boot_command":   []string{"<esc><wait>", "linux ks=http://{{ .HTTPIP }}:{{ .HTTPPort }}/ks.cfg<enter>"},
+				"disk_size":      40000,
+				"headless":       true,
+				"http_directory": "http",
+				"vboxmanage":     [][]string{{"modifyvm", "{{.Name}}", "--memory", "1024"}},
+				"vm_name":        "test-{{timestamp}}",
+			},
+			map[string]interface{}{
+				"type":     "amazon-ebs",
+				"name":     "aws.east",
+				"ami_name": "{{user `ami_name`}}",
+				"launch_block_device_mappings": []map[string]interface{}{
+					{"device_name": "/dev/sda1", "volume_size": 40},
+				},
+				"tags": map[string]string{"Name": "test", "cost.center": "ops"},
+			},
+		},
+		Provisioners: []interface{}{
+			map[string]interface{}{
+				"type":             "shell",
+				"scripts":          []string{"shell/setup.sh"},
+				"environment_vars": []string{"HOME_DIR=${HOME}"},
+				"only":             []string{"virtualbox-iso"},
+			},
+			map[string]interface{}{
+				"type":        "file",
+				"source":      "app.tar.gz",
+				"destination": "/tmp/app.tar.gz",
+				"except":      []interface{}{"aws.east"},
+				"override": map[string]interface{}{
+					"aws.east": map[string]interface{}{"source": "aws.tar.gz"},
+				},
+			},
+		},
+		PostProcessors: []interface{}{
+			map[string]interface{}{"type": "compress", "only": []string{"aws.east"}},
+			map[string]interface{}{
+				"type":     "vagrant",
+				"override": map[string]interface{}{"virtualbox": map[string]interface{}{"compression_level": 9}},
+			},
+			[]interface{}{
+				map[string]interface{}{"type": "docker-tag", "repository": "feedlot/test"},
+				map[string]interface{}{"type": "docker-push"},
+			},
+		},
+		Variables:          map[string]interface{}{"ami_name": "test"},
+		SensitiveVariables: []string{"ami_name"},
+	}
+	expected := `packer {
+  required_version = ">= 1.5.0"
+
+  required_plugins {
+    amazon = {
+      source  = "github.com/hashicorp/amazon"
+      version = ">= 1.0.0"
+    }
+    docker = {
+      source  = "github.com/hashicorp/docker"
+      version = ">= 1.0.0"
+    }
+    vagrant = {
+      source  = "github.com/hashicorp/vagrant"
+      version = ">= 1.0.0"
+    }
+    virtualbox = {
+      source  = "github.com/hashicorp/virtualbox"
+      version = ">= 1.0.0"
+    }
+  }
+}
+
+variable "ami_name" {
+  default   = "test"
+  sensitive = true
+}
+
+source "virtualbox-iso" "test" {
+  boot_command   = ["<esc><wait>", "linux ks=http://{{ .HTTPIP }}:{{ .HTTPPort }}/ks.cfg<enter>"]
+  disk_size      = 40000
+  headless       = true
+  http_directory = "http"
+  vboxmanage     = [["modifyvm", "{{.Name}}", "--memory", "1024"]]
+  vm_name        = "test-${timestamp()}"
+}
+
+source "amazon-ebs" "aws_east" {
+  ami_name = "${var.ami_name}"
+  tags = {
+    Name          = "test"
+    "cost.center" = "ops"
+  }
+
+  launch_block_device_mappings {
+    device_name = "/dev/sda1"
+    volume_size = 40
+  }
+}
+
+build {
+  description = "test template"
+  name        = "test"
+  sources     = ["source.virtualbox-iso.test", "source.amazon-ebs.aws_east"]
+
+  provisioner "shell" {
+    environment_vars = ["HOME_DIR=$${HOME}"]
+    only             = ["virtualbox-iso.test"]
+    scripts          = ["shell/setup.sh"]
+  }
+
+  provisioner "file" {
+    destination = "/tmp/app.tar.gz"
+    except      = ["amazon-ebs.aws_east"]
+    override = {
+      "amazon-ebs.aws_east" = {
+        source = "aws.tar.gz"
+      }
+    }
+    source = "app.tar.gz"
+  }
+
+  post-processor "compress" {
+    only = ["amazon-ebs.aws_east"]
+  }
+
+  post-processor "vagrant" {
+    override = {
+      virtualbox = {
+        compression_level = 9
+      }
+    }
+  }
+
+  post-processors {
+    post-processor "docker-tag" {
+      repository = "feedlot/test"
+    }
+
+    post-processor "docker-push" {}
+  }
+}
+`
+	b, err := p.hcl2("test")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, string(b))
+	}
+}
+
+func TestParseTemplateFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		err      string
+	}{
+		{"", JSONTemplate, ""},
+		{"json", JSONTemplate, ""},
+		{"HCL2", HCL2Template, ""},
+		{"yaml", "", "yaml: unsupported template format"},
+	}
+	for i, test := range tests {
+		f, err := parseTemplateFormat(test.value)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected error %q, got none", i, test.err)
+			continue
+		}
+		if f != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, f)
+		}
+	}
+}
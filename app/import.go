@@ -0,0 +1,624 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	cjsn "github.com/mohae/cjson"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+	"github.com/mohae/feedlot/log"
+	json "github.com/mohae/unsafejson"
+)
+
+// importBuilderResources are the builder settings whose values are resources
+// that get copied with the template.  Builder resources are not kept in a
+// component directory.
+var importBuilderResources = []string{"http_directory"}
+
+// importPostProcessorResources are the settings, by post-processor type, whose
+// values are resources that get copied with the template.
+var importPostProcessorResources = map[string][]string{
+	Vagrant.String(): {"vagrantfile_template"},
+}
+
+// importProvisionerResources are the settings, by provisioner type, whose
+// values are resources that get copied with the template.
+var importProvisionerResources = map[string][]string{
+	Ansible.String():          {"playbook_file"},
+	AnsibleLocal.String():     {"playbook_file", "playbook_dir", "playbook_paths", "group_vars", "host_vars", "role_paths"},
+	ChefClient.String():       {"config_template"},
+	ChefSolo.String():         {"config_template", "cookbook_paths", "data_bags_path", "environments_path", "roles_path"},
+	File.String():             {"source"},
+	PuppetMasterless.String(): {"manifest_file", "manifest_dir", "hiera_config_path", "module_paths"},
+	Salt.String():             {"local_state_tree", "local_pillar_roots", "minion_config"},
+	Shell.String():            {"script", "scripts"},
+}
+
+// ImportedBuild is a Feedlot build definition that was created from a Packer
+// template.  Only the settings that differ from the distro defaults are
+// included.
+type ImportedBuild struct {
+	Description      string                    `toml:"description,omitempty" json:"description,omitempty"`
+	MinPackerVersion string                    `toml:"min_packer_version,omitempty" json:"min_packer_version,omitempty"`
+	Distro           string                    `toml:"distro" json:"distro"`
+	Arch             string                    `toml:"arch,omitempty" json:"arch,omitempty"`
+	Image            string                    `toml:"image,omitempty" json:"image,omitempty"`
+	Release          string                    `toml:"release,omitempty" json:"release,omitempty"`
+	BuilderIDs       []string                  `toml:"builder_ids" json:"builder_ids"`
+	Builders         map[string]BuilderC       `toml:"builders" json:"builders"`
+	PostProcessorIDs []string                  `toml:"post_processor_ids" json:"post_processor_ids"`
+	PostProcessors   map[string]PostProcessorC `toml:"post_processors,omitempty" json:"post_processors,omitempty"`
+	ProvisionerIDs   []string                  `toml:"provisioner_ids" json:"provisioner_ids"`
+	Provisioners     map[string]ProvisionerC   `toml:"provisioners,omitempty" json:"provisioners,omitempty"`
+}
+
+// ImportResult is the result of importing a Packer template.
+type ImportResult struct {
+	// BuildName is the name of the imported build.
+	BuildName string
+	// File is the build file that was written.
+	File string
+	// Resources are the files and directories that were copied to the
+	// source_dir.
+	Resources []string
+	// Warnings are the parts of the Packer template that could not be fully
+	// imported.
+	Warnings []string
+}
+
+// importer converts a Packer template to a Feedlot build.
+type importer struct {
+	// name is the name of the build being created.
+	name string
+	// tplDir is the directory of the Packer template; relative resource
+	// paths are relative to it.
+	tplDir string
+	// srcDir is the directory the build's resources are copied to:
+	// source_dir/distro/build_name.
+	srcDir string
+	// dflt is the distro's default template; settings that match it are
+	// left out.
+	dflt *RawTemplate
+	// files and dirs map the destination of each resource to its source.
+	files    map[string]string
+	dirs     map[string]string
+	warnings []string
+}
+
+func newImporter(name, tplDir, srcDir string, dflt *RawTemplate) *importer {
+	return &importer{
+		name:   name,
+		tplDir: tplDir,
+		srcDir: srcDir,
+		dflt:   dflt,
+		files:  map[string]string{},
+		dirs:   map[string]string{},
+	}
+}
+
+// warnf adds a warning about something that couldn't be fully imported.
+func (im *importer) warnf(format string, v ...interface{}) {
+	s := fmt.Sprintf(format, v...)
+	log.Infof("import %s: %s", im.name, s)
+	im.warnings = append(im.warnings, s)
+}
+
+// ImportTemplate reads the Packer template, src, and writes a Feedlot build,
+// name, to a new file in the conf_dir.  If name is empty, the template's
+// filename, without its extension, is used.  The build targets the distro
+// that was set with the -distro flag; the -arch, -image, and -release flags
+// are optional.  Any resources that the template references are copied to
+// source_dir/distro/build_name so that they will be found when the build is
+// run.
+func ImportTemplate(src, name string) (ImportResult, error) {
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	}
+	res := ImportResult{BuildName: name}
+	distro := contour.GetString("distro")
+	if distro == "" {
+		err := errors.New("import: a distro must be specified")
+		log.Error(err)
+		return res, err
+	}
+	if !DistroDefaults.IsSet {
+		log.Debug("loading distro defaults")
+		err := DistroDefaults.Set()
+		if err != nil {
+			err = fmt.Errorf("import failed: %s", err)
+			log.Error(err)
+			return res, err
+		}
+	}
+	err := loadBuilds()
+	if err != nil {
+		err = fmt.Errorf("import failed: %s", err)
+		log.Error(err)
+		return res, err
+	}
+	for _, n := range allBuildNames() {
+		if n == name {
+			err = Error{name, errors.New("a build with this name already exists")}
+			log.Error(err)
+			return res, err
+		}
+	}
+	dflt, err := DistroDefaults.GetTemplate(distro)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	dflt.updateSourceDirSetting()
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	var tpl map[string]interface{}
+	err = cjsn.Unmarshal(b, &tpl)
+	if err != nil {
+		err = Error{name, fmt.Errorf("%s: %s", src, err)}
+		log.Error(err)
+		return res, err
+	}
+	im := newImporter(name, filepath.Dir(src), filepath.Join(dflt.SourceDir, dflt.Distro, name), dflt)
+	bld, err := im.build(tpl)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	bld.Arch = contour.GetString("arch")
+	bld.Image = contour.GetString("image")
+	bld.Release = contour.GetString("release")
+	format := strings.ToLower(contour.GetString(conf.Format))
+	res.File = filepath.Join(contour.GetString(conf.Dir), fmt.Sprintf("%s.%s", name, format))
+	exists, err := pathExists(res.File)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	if exists {
+		err = Error{name, fmt.Errorf("%s: file already exists", res.File)}
+		log.Error(err)
+		return res, err
+	}
+	out, err := encodeBuild(map[string]ImportedBuild{name: bld}, conf.ParseConfFormat(format))
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	res.Resources, err = im.copyResources()
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	err = ioutil.WriteFile(res.File, out, 0644)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return res, err
+	}
+	res.Warnings = im.warnings
+	log.Infof("import %s: written to %s", name, res.File)
+	return res, nil
+}
+
+// encodeBuild returns the builds encoded in the conf format.
+func encodeBuild(v interface{}, format conf.ConfFormat) ([]byte, error) {
+	switch format {
+	case conf.TOML:
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case conf.JSON:
+		return json.MarshalIndent(v, "", "\t")
+	case conf.YAML:
+		return yaml.Marshal(v)
+	}
+	return nil, conf.ErrUnsupportedFormat
+}
+
+// build converts the Packer template to a Feedlot build.
+func (im *importer) build(tpl map[string]interface{}) (ImportedBuild, error) {
+	bld := ImportedBuild{
+		Distro:           im.dflt.Distro,
+		Builders:         map[string]BuilderC{},
+		PostProcessorIDs: []string{},
+		PostProcessors:   map[string]PostProcessorC{},
+		ProvisionerIDs:   []string{},
+		Provisioners:     map[string]ProvisionerC{},
+	}
+	for k, v := range tpl {
+		switch k {
+		case "description":
+			s := importValueString(v)
+			if s != im.dflt.Description {
+				bld.Description = s
+			}
+		case "min_packer_version":
+			s := importValueString(v)
+			if s != im.dflt.MinPackerVersion {
+				bld.MinPackerVersion = s
+			}
+		case "variables":
+			im.warnf("variables are not imported; references to them are kept as is")
+		case "builders", "post-processors", "provisioners":
+		default:
+			im.warnf("%s: unsupported template key; not imported", k)
+		}
+	}
+	if _, ok := tpl["description"]; !ok && im.dflt.Description != "" {
+		im.warnf("description: not in the Packer template; the default will be used")
+	}
+	if _, ok := tpl["min_packer_version"]; !ok && im.dflt.MinPackerVersion != "" {
+		im.warnf("min_packer_version: not in the Packer template; the default will be used")
+	}
+	builders, err := importComponents("builders", tpl["builders"])
+	if err != nil {
+		return bld, err
+	}
+	if len(builders) == 0 {
+		return bld, errors.New("no builders found")
+	}
+	common, hasCommon := im.dflt.Builders[Common.String()]
+	for _, c := range importIDs(builders) {
+		var dSettings []string
+		var dArrays map[string]interface{}
+		if d, ok := im.dflt.Builders[c.ID]; ok && d.Type == c.Type {
+			dSettings = d.Settings
+			dArrays = d.Arrays
+		}
+		if hasCommon {
+			dSettings, err = mergeSettingsSlices(commonSettings(common.Settings, c.Type), dSettings)
+			if err != nil {
+				return bld, err
+			}
+		}
+		sec, err := im.section(c, dSettings, dArrays, "", importBuilderResources)
+		if err != nil {
+			return bld, err
+		}
+		bld.BuilderIDs = append(bld.BuilderIDs, c.ID)
+		bld.Builders[c.ID] = BuilderC{sec}
+	}
+	postProcessors, err := importComponents("post-processors", tpl["post-processors"])
+	if err != nil {
+		return bld, err
+	}
+	postProcessors = importIDs(postProcessors)
+	for i, c := range postProcessors {
+		var dSettings []string
+		var dArrays map[string]interface{}
+		if d, ok := im.dflt.PostProcessors[c.ID]; ok && d.Type == c.Type {
+			dSettings = d.Settings
+			dArrays = d.Arrays
+		}
+		sec, err := im.section(c, dSettings, dArrays, c.Type, importPostProcessorResources[c.Type])
+		if err != nil {
+			return bld, err
+		}
+		bld.PostProcessors[c.ID] = PostProcessorC{sec}
+		// the post-processors in a sequence share a post_processor_ids entry.
+		n := len(bld.PostProcessorIDs)
+		if c.Seq > 0 && i > 0 && postProcessors[i-1].Seq == c.Seq {
+			bld.PostProcessorIDs[n-1] += " " + PostProcessorSeqSep + " " + c.ID
+			continue
+		}
+		bld.PostProcessorIDs = append(bld.PostProcessorIDs, c.ID)
+	}
+	provisioners, err := importComponents("provisioners", tpl["provisioners"])
+	if err != nil {
+		return bld, err
+	}
+	for _, c := range importIDs(provisioners) {
+		var dSettings []string
+		var dArrays map[string]interface{}
+		if d, ok := im.dflt.Provisioners[c.ID]; ok && d.Type == c.Type {
+			dSettings = d.Settings
+			dArrays = d.Arrays
+		}
+		sec, err := im.section(c, dSettings, dArrays, c.Type, importProvisionerResources[c.Type])
+		if err != nil {
+			return bld, err
+		}
+		bld.ProvisionerIDs = append(bld.ProvisionerIDs, c.ID)
+		bld.Provisioners[c.ID] = ProvisionerC{sec}
+	}
+	return bld, nil
+}
+
+// importComponent is a component from a Packer template.  Seq is the
+// position, starting at 1, of the post-processor sequence the component is
+// in; it is 0 for components that aren't in a sequence.
+type importComponent struct {
+	ID       string
+	Type     string
+	Settings map[string]interface{}
+	Seq      int
+}
+
+// importComponents returns the components in a Packer template section, typ,
+// in order.  Post-processors may be defined with just their type or in a
+// sequence; each post-processor in a sequence is returned as its own
+// component with the sequence's position.
+func importComponents(typ string, v interface{}) ([]importComponent, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an array, got %s", typ, reflect.TypeOf(v))
+	}
+	var comps []importComponent
+	for i, x := range list {
+		switch c := x.(type) {
+		case string:
+			comps = append(comps, importComponent{Type: c, Settings: map[string]interface{}{}})
+			continue
+		case []interface{}:
+			if typ == "post-processors" {
+				seq, err := importComponents(typ, c)
+				if err != nil {
+					return nil, err
+				}
+				for j := range seq {
+					seq[j].Seq = i + 1
+				}
+				comps = append(comps, seq...)
+				continue
+			}
+		case map[string]interface{}:
+			t, _ := c["type"].(string)
+			if t == "" {
+				return nil, fmt.Errorf("%s[%d]: type not set", typ, i)
+			}
+			comp := importComponent{Type: t, Settings: map[string]interface{}{}}
+			for k, val := range c {
+				switch k {
+				case "type":
+				case "name":
+					comp.ID = importValueString(val)
+				default:
+					comp.Settings[k] = val
+				}
+			}
+			comps = append(comps, comp)
+			continue
+		}
+		return nil, fmt.Errorf("%s[%d]: unsupported value: %v", typ, i, x)
+	}
+	return comps, nil
+}
+
+// importIDs sets the ID of each component that doesn't have one.  The type
+// is used as the ID; if more than one component has the same type, the ID of
+// each subsequent one is suffixed with its count, e.g. shell-2.
+func importIDs(comps []importComponent) []importComponent {
+	used := map[string]bool{}
+	for _, c := range comps {
+		if c.ID != "" {
+			used[c.ID] = true
+		}
+	}
+	for i, c := range comps {
+		if c.ID != "" {
+			continue
+		}
+		ID := c.Type
+		for n := 2; used[ID]; n++ {
+			ID = fmt.Sprintf("%s-%d", c.Type, n)
+		}
+		used[ID] = true
+		comps[i].ID = ID
+	}
+	return comps
+}
+
+// section converts the component's settings to a TemplateSection.  Simple
+// values become key=value settings and everything else becomes an array.
+// Settings and arrays that match the defaults are left out.  The values of
+// the resource settings, resources, are copied to the component dir within the
+// build's source dir.
+func (im *importer) section(c importComponent, dSettings []string, dArrays map[string]interface{}, component string, resources []string) (TemplateSection, error) {
+	sec := TemplateSection{Type: c.Type}
+	dflts := varMapFromSlice(dSettings)
+	keys := make([]string, 0, len(c.Settings))
+	for k := range c.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := c.Settings[k]
+		if contains(resources, k) {
+			var err error
+			v, err = im.resourceValue(c.ID, k, v, component)
+			if err != nil {
+				return sec, err
+			}
+		}
+		switch v.(type) {
+		case []interface{}, map[string]interface{}:
+			if d, ok := dArrays[k]; ok && reflect.DeepEqual(d, v) {
+				continue
+			}
+			if sec.Arrays == nil {
+				sec.Arrays = map[string]interface{}{}
+			}
+			sec.Arrays[k] = v
+		default:
+			s := importValueString(v)
+			if d, ok := dflts[k]; ok && d == s {
+				continue
+			}
+			sec.Settings = append(sec.Settings, fmt.Sprintf("%s = %s", k, s))
+		}
+	}
+	// The build inherits the default settings and arrays that the component
+	// doesn't have, so the build won't reproduce the template.
+	var inherited []string
+	for k := range dflts {
+		if _, ok := c.Settings[k]; !ok {
+			inherited = append(inherited, k)
+		}
+	}
+	for k := range dArrays {
+		if _, ok := c.Settings[k]; !ok {
+			inherited = append(inherited, k)
+		}
+	}
+	sort.Strings(inherited)
+	for _, k := range inherited {
+		im.warnf("%s: %s: not in the Packer template; the default will be used", c.ID, k)
+	}
+	return sec, nil
+}
+
+// commonSettings returns the common builder's settings that the builder type,
+// typ, supports; the others aren't used by it.  If the builder's factory
+// doesn't list its settings, all of them are returned.
+func commonSettings(settings []string, typ string) []string {
+	l, ok := builderFactories[strings.ToLower(typ)].(SettingsLister)
+	if !ok {
+		return settings
+	}
+	var supported []string
+	for _, v := range settings {
+		k, _ := parseVar(v)
+		if contains(communicatorSettings, k) || contains(l.Settings(), k) {
+			supported = append(supported, v)
+		}
+	}
+	return supported
+}
+
+// resourceValue handles the value of a resource setting, which is either a
+// path or an array of paths, and returns the value to use in the build.
+func (im *importer) resourceValue(ID, k string, v interface{}, component string) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return im.resource(ID, k, val, component)
+	case []interface{}:
+		paths := make([]interface{}, len(val))
+		for i, x := range val {
+			s, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s: expected a string, got %v", ID, k, x)
+			}
+			p, err := im.resource(ID, k, s, component)
+			if err != nil {
+				return nil, err
+			}
+			paths[i] = p
+		}
+		return paths, nil
+	}
+	return nil, fmt.Errorf("%s: %s: unsupported value: %v", ID, k, v)
+}
+
+// resource adds the resource, p, to the files or dirs that will be copied and
+// returns the path that the build should use for it: the path relative to
+// the Packer template.  Paths that aren't within the Packer template's
+// directory are reduced to their base.  Paths that use Packer template
+// functions, other than template_dir, can't be resolved; they are left as is.
+func (im *importer) resource(ID, k, p, component string) (string, error) {
+	for _, prefix := range []string{"{{template_dir}}/", "{{ template_dir }}/", "{{.TemplateDir}}/"} {
+		p = strings.TrimPrefix(p, prefix)
+	}
+	if strings.Contains(p, "{{") {
+		im.warnf("%s: %s: %s: uses a Packer template function; not copied", ID, k, p)
+		return p, nil
+	}
+	src := p
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(im.tplDir, src)
+	}
+	rel := filepath.Clean(p)
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(rel)
+	}
+	dst := filepath.Join(im.srcDir, component, rel)
+	fi, err := os.Stat(src)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		im.warnf("%s: %s: %s: not found; not copied", ID, k, src)
+		return filepath.ToSlash(rel), nil
+	}
+	if fi.IsDir() {
+		im.dirs[dst] = src
+	} else {
+		im.files[dst] = src
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// copyResources copies all of the resources to the build's source dir and
+// returns the destinations, sorted.
+func (im *importer) copyResources() ([]string, error) {
+	var copied []string
+	for dst, src := range im.dirs {
+		log.Debugf("import %s: copy %s to %s", im.name, src, dst)
+		err := copyDir(src, dst)
+		if err != nil {
+			return copied, err
+		}
+		copied = append(copied, dst)
+	}
+	for dst, src := range im.files {
+		log.Debugf("import %s: copy %s to %s", im.name, src, dst)
+		_, err := copyFile(src, dst)
+		if err != nil {
+			return copied, err
+		}
+		copied = append(copied, dst)
+	}
+	sort.Strings(copied)
+	return copied, nil
+}
+
+// importValueString returns the string representation of a simple value from
+// a Packer template.  Numbers are formatted without exponents.
+func importValueString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// contains returns whether or not s is in the slice.
+func contains(sl []string, s string) bool {
+	for _, v := range sl {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
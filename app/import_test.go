@@ -0,0 +1,171 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImportBuild(t *testing.T) {
+	tplDir, err := ioutil.TempDir("", "feedlot-import-")
+	if err != nil {
+		t.Fatalf("unexpected error while setting up an import test: %s", err)
+	}
+	defer os.RemoveAll(tplDir)
+	for _, name := range []string{"scripts/setup.sh", "http/preseed.cfg"} {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(tplDir, name)), 0755)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up an import test: %s", err)
+		}
+		err = ioutil.WriteFile(filepath.Join(tplDir, name), []byte(name), 0644)
+		if err != nil {
+			t.Fatalf("unexpected error while setting up an import test: %s", err)
+		}
+	}
+	dflt := newRawTemplate()
+	dflt.Distro = "ubuntu"
+	dflt.MinPackerVersion = "0.8.0"
+	dflt.Builders = map[string]BuilderC{
+		"common": {TemplateSection{Type: "common", Settings: []string{"boot_wait = 5s", "guest_additions_mode = disable", "http_directory = http", "ssh_username = vagrant"}}},
+		"virtualbox-iso": {TemplateSection{
+			Type:     "virtualbox-iso",
+			Settings: []string{"headless = true"},
+			Arrays:   map[string]interface{}{"vboxmanage": []interface{}{"cpus=1"}},
+		}},
+	}
+	dflt.Provisioners = map[string]ProvisionerC{
+		"shell": {TemplateSection{Type: "shell", Settings: []string{"execute_command = echo 'vagrant'|sudo -S sh '{{.Path}}'"}}},
+	}
+	tpl := map[string]interface{}{
+		"description":        "imported",
+		"min_packer_version": "0.8.0",
+		"variables":          map[string]interface{}{"foo": "bar"},
+		"builders": []interface{}{
+			map[string]interface{}{
+				"type":           "virtualbox-iso",
+				"boot_wait":      "10s",
+				"headless":       true,
+				"disk_size":      float64(40000),
+				"http_directory": "http",
+				"ssh_username":   "vagrant",
+				"vboxmanage":     []interface{}{"cpus=1"},
+				"boot_command":   []interface{}{"<esc>"},
+			},
+			map[string]interface{}{
+				"type": "vmware-iso",
+				"name": "vmware",
+			},
+		},
+		"provisioners": []interface{}{
+			map[string]interface{}{
+				"type":   "shell",
+				"script": "{{template_dir}}/scripts/setup.sh",
+			},
+			map[string]interface{}{
+				"type":    "shell",
+				"scripts": []interface{}{"scripts/setup.sh", "/missing/cleanup.sh"},
+			},
+		},
+		"post-processors": []interface{}{
+			[]interface{}{"compress", map[string]interface{}{"type": "vagrant", "output": "out.box"}},
+			"atlas",
+		},
+	}
+	srcDir := filepath.Join("src", "ubuntu", "test")
+	im := newImporter("test", tplDir, srcDir, dflt)
+	bld, err := im.build(tpl)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := ImportedBuild{
+		Description: "imported",
+		Distro:      "ubuntu",
+		BuilderIDs:  []string{"virtualbox-iso", "vmware"},
+		Builders: map[string]BuilderC{
+			"virtualbox-iso": {TemplateSection{
+				Type:     "virtualbox-iso",
+				Settings: []string{"boot_wait = 10s", "disk_size = 40000"},
+				Arrays:   map[string]interface{}{"boot_command": []interface{}{"<esc>"}},
+			}},
+			"vmware": {TemplateSection{Type: "vmware-iso"}},
+		},
+		PostProcessorIDs: []string{"compress -> vagrant", "atlas"},
+		PostProcessors: map[string]PostProcessorC{
+			"atlas":    {TemplateSection{Type: "atlas"}},
+			"compress": {TemplateSection{Type: "compress"}},
+			"vagrant":  {TemplateSection{Type: "vagrant", Settings: []string{"output = out.box"}}},
+		},
+		ProvisionerIDs: []string{"shell", "shell-2"},
+		Provisioners: map[string]ProvisionerC{
+			"shell": {TemplateSection{Type: "shell", Settings: []string{"script = scripts/setup.sh"}}},
+			"shell-2": {TemplateSection{
+				Type:   "shell",
+				Arrays: map[string]interface{}{"scripts": []interface{}{"scripts/setup.sh", "cleanup.sh"}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(bld, expected) {
+		t.Errorf("expected %#v, got %#v", expected, bld)
+	}
+	expectedFiles := map[string]string{
+		filepath.Join(srcDir, "shell", "scripts/setup.sh"): filepath.Join(tplDir, "scripts/setup.sh"),
+	}
+	if !reflect.DeepEqual(im.files, expectedFiles) {
+		t.Errorf("expected %v, got %v", expectedFiles, im.files)
+	}
+	expectedDirs := map[string]string{
+		filepath.Join(srcDir, "http"): filepath.Join(tplDir, "http"),
+	}
+	if !reflect.DeepEqual(im.dirs, expectedDirs) {
+		t.Errorf("expected %v, got %v", expectedDirs, im.dirs)
+	}
+	// the variables, the default settings that the template doesn't have,
+	// and the missing script are warned about; vmware-iso doesn't support
+	// guest_additions_mode.
+	expectedWarnings := []string{
+		"variables are not imported; references to them are kept as is",
+		"virtualbox-iso: guest_additions_mode: not in the Packer template; the default will be used",
+		"vmware: boot_wait: not in the Packer template; the default will be used",
+		"vmware: http_directory: not in the Packer template; the default will be used",
+		"vmware: ssh_username: not in the Packer template; the default will be used",
+		"shell: execute_command: not in the Packer template; the default will be used",
+		"shell-2: scripts: /missing/cleanup.sh: not found; not copied",
+	}
+	if !reflect.DeepEqual(im.warnings, expectedWarnings) {
+		t.Errorf("expected %v, got %v", expectedWarnings, im.warnings)
+	}
+}
+
+func TestImportBuildNoBuilders(t *testing.T) {
+	dflt := newRawTemplate()
+	im := newImporter("test", "", "", dflt)
+	_, err := im.build(map[string]interface{}{"description": "no builders"})
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if err.Error() != "no builders found" {
+		t.Errorf("expected %q, got %q", "no builders found", err)
+	}
+}
+
+func TestImportValueString(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"5s", "5s"},
+		{true, "true"},
+		{float64(40000), "40000"},
+		{float64(1000000), "1000000"},
+		{1.5, "1.5"},
+		{nil, ""},
+	}
+	for i, test := range tests {
+		s := importValueString(test.value)
+		if s != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"sort"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// BuildSummary contains information about a Feedlot build template: where it
+// was defined and what it targets.  Values that aren't defined by the build
+// template are filled in with its distro's defaults.
+type BuildSummary struct {
+	Name       string   `json:"name"`
+	File       string   `json:"file"`
+	Distro     string   `json:"distro"`
+	Release    string   `json:"release"`
+	Arch       string   `json:"arch"`
+	BuilderIDs []string `json:"builder_ids"`
+}
+
+// BuildListSummary contains a build list's name and its members.
+type BuildListSummary struct {
+	Name   string   `json:"name"`
+	Builds []string `json:"builds"`
+}
+
+// DistroSummary contains the arch, image, and release matrix of a supported
+// distro along with its default image.
+type DistroSummary struct {
+	Name         string   `json:"name"`
+	Arch         []string `json:"arch"`
+	Image        []string `json:"image"`
+	Release      []string `json:"release"`
+	DefaultImage []string `json:"default_image"`
+}
+
+// Inventory is everything that Feedlot can generate Packer templates from:
+// the build templates, the build lists, and the supported distros.  Each
+// slice is sorted by name.
+type Inventory struct {
+	Builds     []BuildSummary     `json:"builds"`
+	BuildLists []BuildListSummary `json:"build_lists"`
+	Distros    []DistroSummary    `json:"distros"`
+}
+
+// LoadInventory loads the build templates, build lists, and supported distros
+// and returns a summary of them.
+func LoadInventory() (Inventory, error) {
+	var inv Inventory
+	s := &SupportedDistros{}
+	err := s.Load("")
+	if err != nil {
+		err = Error{slug: "load inventory", err: err}
+		log.Error(err)
+		return inv, err
+	}
+	bl := &BuildLists{}
+	err = bl.Load("")
+	if err != nil {
+		err = Error{slug: "load inventory", err: err}
+		log.Error(err)
+		return inv, err
+	}
+	err = loadBuilds()
+	if err != nil {
+		err = Error{slug: "load inventory", err: err}
+		log.Error(err)
+		return inv, err
+	}
+	inv.Distros = distroSummaries(s)
+	inv.BuildLists = buildListSummaries(bl)
+	inv.Builds = buildSummaries(s)
+	log.Debugf("inventory: %d builds, %d build lists, %d distros", len(inv.Builds), len(inv.BuildLists), len(inv.Distros))
+	return inv, nil
+}
+
+// buildSummaries returns a summary of every build template in BuildDefs,
+// sorted by name.  Any distro, release, arch, or builder id that the build
+// template doesn't define is taken from its supported distro's settings.
+func buildSummaries(s *SupportedDistros) []BuildSummary {
+	var sums []BuildSummary
+	for fname, blds := range BuildDefs {
+		for name, tpl := range blds.Templates {
+			// matrix builds are listed as the builds they were expanded
+			// into.
+			if tpl.Matrix != nil {
+				continue
+			}
+			sum := BuildSummary{
+				Name:       name,
+				File:       fname,
+				Distro:     tpl.Distro,
+				Release:    tpl.Release,
+				Arch:       tpl.Arch,
+				BuilderIDs: tpl.BuilderIDs,
+			}
+			d, ok := s.Distros[tpl.Distro]
+			if ok {
+				arch, _, release := getDefaultISOInfo(d.DefImage)
+				if sum.Arch == "" {
+					sum.Arch = arch
+				}
+				if sum.Release == "" {
+					sum.Release = release
+				}
+				if len(sum.BuilderIDs) == 0 {
+					sum.BuilderIDs = d.BuilderIDs
+				}
+			}
+			sums = append(sums, sum)
+		}
+	}
+	sort.Slice(sums, func(i, j int) bool {
+		if sums[i].Name == sums[j].Name {
+			return sums[i].File < sums[j].File
+		}
+		return sums[i].Name < sums[j].Name
+	})
+	return sums
+}
+
+// buildListSummaries returns a summary of every build list, sorted by name.
+func buildListSummaries(bl *BuildLists) []BuildListSummary {
+	sums := make([]BuildListSummary, 0, len(bl.Lists))
+	for name, l := range bl.Lists {
+		sums = append(sums, BuildListSummary{Name: name, Builds: l.Builds})
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i].Name < sums[j].Name })
+	return sums
+}
+
+// distroSummaries returns a summary of every supported distro, sorted by
+// name.
+func distroSummaries(s *SupportedDistros) []DistroSummary {
+	sums := make([]DistroSummary, 0, len(s.Distros))
+	for name, d := range s.Distros {
+		sums = append(sums, DistroSummary{
+			Name:         name,
+			Arch:         d.Arch,
+			Image:        d.Image,
+			Release:      d.Release,
+			DefaultImage: d.DefImage,
+		})
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i].Name < sums[j].Name })
+	return sums
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSummaries(t *testing.T) {
+	s := &SupportedDistros{
+		Distros: map[string]*SupportedDistro{
+			"ubuntu": testSupportedUbuntu,
+		},
+	}
+	BuildDefs = map[string]Builds{
+		"conf/build.json": {
+			Templates: map[string]*RawTemplate{
+				"test2": &RawTemplate{Distro: "ubuntu", Release: "14.04", Arch: "i386", Build: Build{BuilderIDs: []string{"vmware-iso"}}},
+				"test1": &RawTemplate{Distro: "ubuntu"},
+			},
+		},
+		"conf/other.json": {
+			Templates: map[string]*RawTemplate{
+				"test0": &RawTemplate{Distro: "slackware", Release: "14.1"},
+			},
+		},
+	}
+	defer func() { BuildDefs = map[string]Builds{} }()
+	expected := []BuildSummary{
+		{Name: "test0", File: "conf/other.json", Distro: "slackware", Release: "14.1"},
+		{Name: "test1", File: "conf/build.json", Distro: "ubuntu", Release: "12.04", Arch: "amd64", BuilderIDs: []string{"virtualbox-iso", "vmware-iso"}},
+		{Name: "test2", File: "conf/build.json", Distro: "ubuntu", Release: "14.04", Arch: "i386", BuilderIDs: []string{"vmware-iso"}},
+	}
+	sums := buildSummaries(s)
+	if !reflect.DeepEqual(sums, expected) {
+		t.Errorf("expected %#v, got %#v", expected, sums)
+	}
+}
+
+func TestBuildListSummaries(t *testing.T) {
+	bl := &BuildLists{
+		Lists: map[string]List{
+			"ubuntu-all": {Builds: []string{"ubuntu-server", "ubuntu-desktop"}},
+			"centos-all": {Builds: []string{"centos-minimal"}},
+		},
+	}
+	expected := []BuildListSummary{
+		{Name: "centos-all", Builds: []string{"centos-minimal"}},
+		{Name: "ubuntu-all", Builds: []string{"ubuntu-server", "ubuntu-desktop"}},
+	}
+	sums := buildListSummaries(bl)
+	if !reflect.DeepEqual(sums, expected) {
+		t.Errorf("expected %#v, got %#v", expected, sums)
+	}
+}
+
+func TestDistroSummaries(t *testing.T) {
+	s := &SupportedDistros{
+		Distros: map[string]*SupportedDistro{
+			"ubuntu": testSupportedUbuntu,
+			"centos": testSupportedCentOS,
+		},
+	}
+	sums := distroSummaries(s)
+	if len(sums) != 2 {
+		t.Fatalf("expected 2 distro summaries, got %d", len(sums))
+	}
+	for i, name := range []string{"centos", "ubuntu"} {
+		d := s.Distros[name]
+		expected := DistroSummary{Name: name, Arch: d.Arch, Image: d.Image, Release: d.Release, DefaultImage: d.DefImage}
+		if !reflect.DeepEqual(sums[i], expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, expected, sums[i])
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// Matrix expands a build template into a build for every combination of its
+// releases, arches, and images.  A dimension without any values uses the
+// build's value or, if the build doesn't have one, its distro's default.
+// Each combination must be supported by the build's distro.
+type Matrix struct {
+	Release []string `toml:"release" json:"release"`
+	Arch    []string `toml:"arch" json:"arch"`
+	Image   []string `toml:"image" json:"image"`
+	// Exclude lists the combinations that aren't built.
+	Exclude []MatrixCell `toml:"exclude" json:"exclude"`
+	// Override lists settings that are merged into the builds of the
+	// combinations they match, after the build template's settings.
+	Override []MatrixOverride `toml:"override" json:"override"`
+	// cells are the names of the builds that the matrix was expanded into.
+	cells []string
+}
+
+// MatrixCell is a combination of a matrix's release, arch, and image.  When
+// it's used to match combinations, an empty value matches any value.
+type MatrixCell struct {
+	Release string `toml:"release" json:"release"`
+	Arch    string `toml:"arch" json:"arch"`
+	Image   string `toml:"image" json:"image"`
+}
+
+// matches returns whether or not the combination, c, is matched by m.
+func (m MatrixCell) matches(c MatrixCell) bool {
+	return (m.Release == "" || m.Release == c.Release) && (m.Arch == "" || m.Arch == c.Arch) && (m.Image == "" || m.Image == c.Image)
+}
+
+// MatrixOverride is a build template whose settings are merged into the
+// builds of the combinations that it matches.  It can't set the distro,
+// release, arch, or image, extend other builds, or have a matrix.
+type MatrixOverride struct {
+	Match MatrixCell `toml:"match" json:"match"`
+	RawTemplate
+}
+
+// matrixBuildName returns the name of the build for the combination, c, of
+// the distro; this is the same naming pattern that distro builds use.
+func matrixBuildName(distro string, c MatrixCell) string {
+	return fmt.Sprintf("%s-%s-%s-%s", distro, c.Release, c.Arch, c.Image)
+}
+
+// expandMatrixBuilds expands every matrix build in BuildDefs into the builds
+// of its combinations; they are added to the BuildDefs of the matrix build's
+// file.  The matrix build's name can be used to refer to all of them; see
+// expandBuildNames.  A generated build can't have the same name as another
+// build.
+func expandMatrixBuilds() error {
+	fnames := make([]string, 0, len(BuildDefs))
+	for fname := range BuildDefs {
+		fnames = append(fnames, fname)
+	}
+	sort.Strings(fnames)
+	var s *SupportedDistros
+	for _, fname := range fnames {
+		blds := BuildDefs[fname]
+		var names []string
+		for name, tpl := range blds.Templates {
+			if tpl.Matrix != nil {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if s == nil {
+			var err error
+			s, err = supportedDistros()
+			if err != nil {
+				return err
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			tpl := blds.Templates[name]
+			cells, err := matrixBuilds(name, tpl, blds.env, s)
+			if err != nil {
+				return Error{slug: name, err: err}
+			}
+			tpl.Matrix.cells = make([]string, 0, len(cells))
+			for _, cell := range cells {
+				for f, b := range BuildDefs {
+					if _, ok := b.Templates[cell.BuildName]; ok {
+						return fmt.Errorf("duplicate build %s: defined in %s and generated by %s's matrix in %s", cell.BuildName, f, name, fname)
+					}
+				}
+				blds.Templates[cell.BuildName] = cell
+				tpl.Matrix.cells = append(tpl.Matrix.cells, cell.BuildName)
+			}
+			log.Debugf("%s: matrix expanded into %v", name, tpl.Matrix.cells)
+		}
+	}
+	return nil
+}
+
+// matrixBuilds returns the build templates for each of the combinations of
+// the named matrix build, tpl, in the order of its releases, arches, and
+// images.  Excluded combinations are skipped.  Each build is a copy of the
+// matrix build with its combination's release, arch, and image; the
+// overrides that match the combination are in its MatrixOverrides.
+func matrixBuilds(name string, tpl *RawTemplate, env string, s *SupportedDistros) ([]*RawTemplate, error) {
+	chain, err := getBuildChain(name)
+	if err != nil {
+		return nil, err
+	}
+	distro := chainDistro(chain)
+	var supported *SupportedDistro
+	for k, v := range s.Distros {
+		if ParseDistro(k) == ParseDistro(distro) {
+			supported = v
+			break
+		}
+	}
+	if supported == nil {
+		return nil, fmt.Errorf("%s: not a supported distro", distro)
+	}
+	// the values of the dimensions that the matrix doesn't list are
+	// inherited like the distro is.
+	var dflt MatrixCell
+	dflt.Arch, dflt.Image, dflt.Release = getDefaultISOInfo(supported.DefImage)
+	for _, bTpl := range chain {
+		if bTpl.Release != "" {
+			dflt.Release = bTpl.Release
+		}
+		if bTpl.Arch != "" {
+			dflt.Arch = bTpl.Arch
+		}
+		if bTpl.Image != "" {
+			dflt.Image = bTpl.Image
+		}
+	}
+	m := tpl.Matrix
+	dims := []struct {
+		name      string
+		vals      []string
+		dflt      string
+		supported []string
+	}{
+		{"release", m.Release, dflt.Release, supported.Release},
+		{"arch", m.Arch, dflt.Arch, supported.Arch},
+		{"image", m.Image, dflt.Image, supported.Image},
+	}
+	vals := make([][]string, len(dims))
+	for i, dim := range dims {
+		vals[i] = dim.vals
+		if len(vals[i]) == 0 {
+			if dim.dflt == "" {
+				return nil, Error{slug: "matrix", err: fmt.Errorf("no %s", dim.name)}
+			}
+			vals[i] = []string{dim.dflt}
+		}
+		for _, v := range vals[i] {
+			if !contains(dim.supported, v) {
+				return nil, Error{slug: "matrix", err: fmt.Errorf("%s %s: not a supported %s %s", dim.name, v, distro, dim.name)}
+			}
+		}
+	}
+	// Exclusions and overrides can only match values that are in the
+	// matrix; otherwise, a typo would silently match nothing.
+	checkMatch := func(path string, c MatrixCell) error {
+		for i, v := range []string{c.Release, c.Arch, c.Image} {
+			if v != "" && !contains(vals[i], v) {
+				return Error{slug: path, err: fmt.Errorf("%s %s: not in the matrix", dims[i].name, v)}
+			}
+		}
+		return nil
+	}
+	for i, c := range m.Exclude {
+		err := checkMatch(fmt.Sprintf("matrix.exclude[%d]", i), c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	overrides := make([]*RawTemplate, len(m.Override))
+	for i, o := range m.Override {
+		path := fmt.Sprintf("matrix.override[%d]", i)
+		err := checkMatch(path, o.Match)
+		if err != nil {
+			return nil, err
+		}
+		for _, set := range []struct {
+			k  string
+			ok bool
+		}{
+			{"distro", o.Distro != ""},
+			{"release", o.Release != ""},
+			{"arch", o.Arch != ""},
+			{"image", o.Image != ""},
+			{"extends", len(o.Extends) > 0},
+			{"matrix", o.Matrix != nil},
+		} {
+			if set.ok {
+				return nil, Error{slug: path, err: fmt.Errorf("%s: can't be overridden", set.k)}
+			}
+		}
+		overrides[i] = o.RawTemplate.Copy()
+		overrides[i].BuildName = fmt.Sprintf("%s.%s", name, path)
+	}
+	var tpls []*RawTemplate
+	for _, release := range vals[0] {
+		for _, arch := range vals[1] {
+		image:
+			for _, image := range vals[2] {
+				c := MatrixCell{Release: release, Arch: arch, Image: image}
+				for _, x := range m.Exclude {
+					if x.matches(c) {
+						log.Debugf("%s: matrix: exclude %s", name, matrixBuildName(distro, c))
+						continue image
+					}
+				}
+				cell := tpl.Copy()
+				cell.Matrix = nil
+				cell.BuildName = envBuildName(env, matrixBuildName(distro, c))
+				cell.Distro = distro
+				cell.Release, cell.Arch, cell.Image = release, arch, image
+				for i, o := range m.Override {
+					if o.Match.matches(c) {
+						cell.MatrixOverrides = append(cell.MatrixOverrides, overrides[i])
+					}
+				}
+				tpls = append(tpls, cell)
+			}
+		}
+	}
+	if len(tpls) == 0 {
+		return nil, Error{slug: "matrix", err: errors.New("every combination is excluded")}
+	}
+	return tpls, nil
+}
+
+// expandBuildNames returns the passed build names with the name of each
+// matrix build replaced by the names of the builds it was expanded into.
+func expandBuildNames(names []string) []string {
+	var expanded []string
+	for _, name := range names {
+		cells := matrixCells(name)
+		if cells == nil {
+			expanded = append(expanded, name)
+			continue
+		}
+		expanded = append(expanded, cells...)
+	}
+	return expanded
+}
+
+// matrixCells returns the names of the builds that the named build's matrix
+// was expanded into; nil is returned if it isn't a matrix build.
+func matrixCells(name string) []string {
+	for _, blds := range BuildDefs {
+		tpl, ok := blds.Templates[name]
+		if ok && tpl.Matrix != nil {
+			return tpl.Matrix.cells
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandMatrixBuilds(t *testing.T) {
+	DistroDefaults.supported = &SupportedDistros{
+		Distros: map[string]*SupportedDistro{
+			"ubuntu": {
+				Arch:     []string{"amd64", "i386"},
+				Image:    []string{"server", "desktop"},
+				Release:  []string{"14.04", "16.04"},
+				DefImage: []string{"release = 16.04", "arch = amd64", "image = server"},
+			},
+		},
+	}
+	defer func() {
+		BuildDefs = map[string]Builds{}
+		DistroDefaults.supported = nil
+	}()
+	tests := []struct {
+		tpls     map[string]*RawTemplate
+		cells    []string
+		releases []string
+		err      string
+	}{
+		{
+			map[string]*RawTemplate{
+				"base": {Distro: "ubuntu"},
+				"ubuntu": {
+					Extends: []string{"base"},
+					Matrix:  &Matrix{Release: []string{"14.04", "16.04"}, Arch: []string{"amd64", "i386"}},
+				},
+			},
+			[]string{"ubuntu-14.04-amd64-server", "ubuntu-14.04-i386-server", "ubuntu-16.04-amd64-server", "ubuntu-16.04-i386-server"},
+			[]string{"14.04", "14.04", "16.04", "16.04"},
+			"",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu": {
+					Distro: "ubuntu",
+					Image:  "desktop",
+					Matrix: &Matrix{
+						Release: []string{"14.04", "16.04"},
+						Arch:    []string{"amd64", "i386"},
+						Exclude: []MatrixCell{{Release: "14.04", Arch: "i386"}, {Release: "16.04", Arch: "amd64"}},
+					},
+				},
+			},
+			[]string{"ubuntu-14.04-amd64-desktop", "ubuntu-16.04-i386-desktop"},
+			[]string{"14.04", "16.04"},
+			"",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu": {Distro: "ubuntu", Matrix: &Matrix{Release: []string{"12.04", "16.04"}}},
+			},
+			nil,
+			nil,
+			"ubuntu: matrix: release 12.04: not a supported ubuntu release",
+		},
+		{
+			map[string]*RawTemplate{
+				"slackware": {Distro: "slackware", Matrix: &Matrix{Release: []string{"14.2"}}},
+			},
+			nil,
+			nil,
+			"slackware: slackware: not a supported distro",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu": {Distro: "ubuntu", Matrix: &Matrix{Arch: []string{"amd64"}, Exclude: []MatrixCell{{Arch: "i386"}}}},
+			},
+			nil,
+			nil,
+			"ubuntu: matrix.exclude[0]: arch i386: not in the matrix",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu": {Distro: "ubuntu", Matrix: &Matrix{Exclude: []MatrixCell{{Image: "server"}}}},
+			},
+			nil,
+			nil,
+			"ubuntu: matrix: every combination is excluded",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu": {
+					Distro: "ubuntu",
+					Matrix: &Matrix{Override: []MatrixOverride{{Match: MatrixCell{Arch: "amd64"}, RawTemplate: RawTemplate{Release: "14.04"}}}},
+				},
+			},
+			nil,
+			nil,
+			"ubuntu: matrix.override[0]: release: can't be overridden",
+		},
+		{
+			map[string]*RawTemplate{
+				"ubuntu-16.04-amd64-server": {Distro: "ubuntu"},
+				"ubuntu":                    {Distro: "ubuntu", Matrix: &Matrix{}},
+			},
+			nil,
+			nil,
+			"duplicate build ubuntu-16.04-amd64-server: defined in conf/build.toml and generated by ubuntu's matrix in conf/build.toml",
+		},
+	}
+	for i, test := range tests {
+		BuildDefs = map[string]Builds{"conf/build.toml": {Templates: test.tpls}}
+		err := expandMatrixBuilds()
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		cells := expandBuildNames([]string{"ubuntu"})
+		if !reflect.DeepEqual(cells, test.cells) {
+			t.Errorf("%d: expected %v, got %v", i, test.cells, cells)
+			continue
+		}
+		for j, name := range cells {
+			tpl := BuildDefs["conf/build.toml"].Templates[name]
+			if tpl.Release != test.releases[j] {
+				t.Errorf("%d: %s: expected release %q, got %q", i, name, test.releases[j], tpl.Release)
+			}
+			if tpl.Matrix != nil {
+				t.Errorf("%d: %s: expected matrix to be nil", i, name)
+			}
+		}
+		names := allBuildNames()
+		if contains(names, "ubuntu") {
+			t.Errorf("%d: expected the matrix build to not be in %v", i, names)
+		}
+	}
+}
+
+func TestMatrixOverrides(t *testing.T) {
+	DistroDefaults.supported = &SupportedDistros{
+		Distros: map[string]*SupportedDistro{
+			"ubuntu": {
+				Arch:     []string{"amd64", "i386"},
+				Image:    []string{"server"},
+				Release:  []string{"14.04", "16.04"},
+				DefImage: []string{"release = 16.04", "arch = amd64", "image = server"},
+			},
+		},
+	}
+	BuildDefs = map[string]Builds{
+		"conf/build.toml": {
+			Templates: map[string]*RawTemplate{
+				"ubuntu": {
+					Distro: "ubuntu",
+					Matrix: &Matrix{
+						Release: []string{"14.04", "16.04"},
+						Arch:    []string{"amd64", "i386"},
+						Override: []MatrixOverride{
+							{Match: MatrixCell{Arch: "i386"}, RawTemplate: RawTemplate{Build: Build{Vars: []string{"mem = 512"}}}},
+							{Match: MatrixCell{Release: "16.04", Arch: "i386"}, RawTemplate: RawTemplate{Build: Build{Vars: []string{"mem = 1024"}}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	defer func() {
+		BuildDefs = map[string]Builds{}
+		DistroDefaults.supported = nil
+	}()
+	err := expandMatrixBuilds()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"ubuntu-14.04-amd64-server", []string{"ubuntu-14.04-amd64-server"}},
+		{"ubuntu-14.04-i386-server", []string{"ubuntu-14.04-i386-server", "ubuntu.matrix.override[0]"}},
+		{"ubuntu-16.04-i386-server", []string{"ubuntu-16.04-i386-server", "ubuntu.matrix.override[0]", "ubuntu.matrix.override[1]"}},
+	}
+	for i, test := range tests {
+		chain, err := getBuildChain(test.name)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		var names []string
+		for _, r := range chain {
+			names = append(names, r.BuildName)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, names)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// The merge strategies for a section's arrays.
+const (
+	// MergeReplace replaces the existing array with the new one; this is the
+	// default.
+	MergeReplace = "replace"
+	// MergeAppend adds the new list's values after the existing ones.
+	MergeAppend = "append"
+	// MergePrepend adds the new list's values before the existing ones.
+	MergePrepend = "prepend"
+	// MergeDeep merges the new object into the existing one: the objects
+	// within them are merged and everything else is replaced.
+	MergeDeep = "merge"
+	// MergeRemove removes the new list's values from the existing list.
+	MergeRemove = "remove"
+)
+
+// mergeArray returns the result of merging an array's new value, n, with its
+// existing value, old, using the strategy; exists is whether there is an
+// existing value.  If the result is that the array no longer exists, e.g.
+// removing values from an array that doesn't exist, false is returned.
+//
+// Lists are merged in place of their existing values: if both lists are of
+// the same type, the result is of that type, otherwise it's []interface{}.
+func mergeArray(old interface{}, exists bool, n interface{}, strategy string) (interface{}, bool, error) {
+	switch strategy {
+	case "", MergeReplace:
+		return n, true, nil
+	case MergeAppend, MergePrepend:
+		if !isList(n) {
+			return nil, false, fmt.Errorf("%s: expected a list, got %s", strategy, settingTypeName(n))
+		}
+		if !exists {
+			return n, true, nil
+		}
+		if !isList(old) {
+			return nil, false, fmt.Errorf("%s: can't merge a list with %s", strategy, settingTypeName(old))
+		}
+		if strategy == MergePrepend {
+			return concatLists(n, old), true, nil
+		}
+		return concatLists(old, n), true, nil
+	case MergeRemove:
+		if !isList(n) {
+			return nil, false, fmt.Errorf("%s: expected a list, got %s", strategy, settingTypeName(n))
+		}
+		if !exists {
+			return nil, false, nil
+		}
+		if !isList(old) {
+			return nil, false, fmt.Errorf("%s: can't remove values from %s", strategy, settingTypeName(old))
+		}
+		return removeListValues(old, n), true, nil
+	case MergeDeep:
+		nm, ok := n.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s: expected an object, got %s", strategy, settingTypeName(n))
+		}
+		if !exists {
+			return n, true, nil
+		}
+		om, ok := old.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s: can't merge an object with %s", strategy, settingTypeName(old))
+		}
+		return deepMerge(om, nm), true, nil
+	}
+	return nil, false, fmt.Errorf("%s: unknown merge strategy", strategy)
+}
+
+// isList returns whether v is a slice.
+func isList(v interface{}) bool {
+	return v != nil && reflect.TypeOf(v).Kind() == reflect.Slice
+}
+
+// concatLists returns a list with a's values followed by b's.
+func concatLists(a, b interface{}) interface{} {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.Type() == bv.Type() {
+		l := reflect.MakeSlice(av.Type(), 0, av.Len()+bv.Len())
+		return reflect.AppendSlice(reflect.AppendSlice(l, av), bv).Interface()
+	}
+	l := make([]interface{}, 0, av.Len()+bv.Len())
+	for i := 0; i < av.Len(); i++ {
+		l = append(l, av.Index(i).Interface())
+	}
+	for i := 0; i < bv.Len(); i++ {
+		l = append(l, bv.Index(i).Interface())
+	}
+	return l
+}
+
+// removeListValues returns a list, of the same type as l, with every value
+// in l that is also in values removed.
+func removeListValues(l, values interface{}) interface{} {
+	lv, vv := reflect.ValueOf(l), reflect.ValueOf(values)
+	res := reflect.MakeSlice(lv.Type(), 0, lv.Len())
+	for i := 0; i < lv.Len(); i++ {
+		var found bool
+		for j := 0; j < vv.Len(); j++ {
+			if reflect.DeepEqual(lv.Index(i).Interface(), vv.Index(j).Interface()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = reflect.Append(res, lv.Index(i))
+		}
+	}
+	return res.Interface()
+}
+
+// deepMerge returns the result of merging n into old.  When both have an
+// object for the same key, the objects are merged; otherwise n's value
+// replaces old's.  Neither old nor n are modified.
+func deepMerge(old, n map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(old)+len(n))
+	for k, v := range old {
+		merged[k] = v
+	}
+	for k, v := range n {
+		nm, ok := v.(map[string]interface{})
+		if !ok {
+			merged[k] = v
+			continue
+		}
+		om, ok := merged[k].(map[string]interface{})
+		if !ok {
+			merged[k] = v
+			continue
+		}
+		merged[k] = deepMerge(om, nm)
+	}
+	return merged
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeArray(t *testing.T) {
+	tests := []struct {
+		old      interface{}
+		exists   bool
+		new      interface{}
+		strategy string
+		expected interface{}
+		keep     bool
+		err      string
+	}{
+		{[]string{"a"}, true, []string{"b"}, "", []string{"b"}, true, ""},
+		{[]string{"a"}, true, []string{"b"}, MergeReplace, []string{"b"}, true, ""},
+		{[]string{"a"}, true, []string{"b"}, MergeAppend, []string{"a", "b"}, true, ""},
+		{[]interface{}{"a"}, true, []string{"b"}, MergeAppend, []interface{}{"a", "b"}, true, ""},
+		{nil, false, []string{"b"}, MergeAppend, []string{"b"}, true, ""},
+		{[]string{"a", "b"}, true, []string{"c"}, MergePrepend, []string{"c", "a", "b"}, true, ""},
+		{[]string{"a", "b", "c", "b"}, true, []interface{}{"b"}, MergeRemove, []string{"a", "c"}, true, ""},
+		{nil, false, []string{"b"}, MergeRemove, nil, false, ""},
+		{
+			map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": "2", "d": "3"}},
+			true,
+			map[string]interface{}{"b": map[string]interface{}{"d": "4"}, "e": "5"},
+			MergeDeep,
+			map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": "2", "d": "4"}, "e": "5"},
+			true,
+			"",
+		},
+		{[]string{"a"}, true, "b", MergeAppend, nil, false, "append: expected a list, got string"},
+		{map[string]interface{}{}, true, []string{"b"}, MergeAppend, nil, false, "append: can't merge a list with object"},
+		{[]string{"a"}, true, []string{"b"}, MergeDeep, nil, false, "merge: expected an object, got []string"},
+		{[]string{"a"}, true, []string{"b"}, "add", nil, false, "add: unknown merge strategy"},
+	}
+	for i, test := range tests {
+		v, keep, err := mergeArray(test.old, test.exists, test.new, test.strategy)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if keep != test.keep {
+			t.Errorf("%d: expected keep to be %t, got %t", i, test.keep, keep)
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, v)
+		}
+	}
+}
+
+func TestTemplateSectionMergeArraysStrategies(t *testing.T) {
+	ts := TemplateSection{
+		Arrays: map[string]interface{}{
+			"scripts":    []string{"setup", "cleanup"},
+			"vboxmanage": []string{"memory", "cpus"},
+			"only":       []string{"virtualbox-iso"},
+		},
+	}
+	err := ts.mergeArrays(
+		map[string]interface{}{
+			"scripts":    []string{"vagrant"},
+			"vboxmanage": []string{"cpus"},
+			"only":       []string{"vmware-iso"},
+		},
+		map[string]string{
+			"scripts":    MergeAppend,
+			"vboxmanage": MergeRemove,
+		},
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := map[string]interface{}{
+		"scripts":    []string{"setup", "cleanup", "vagrant"},
+		"vboxmanage": []string{"memory"},
+		"only":       []string{"vmware-iso"},
+	}
+	if !reflect.DeepEqual(ts.Arrays, expected) {
+		t.Errorf("expected %#v, got %#v", expected, ts.Arrays)
+	}
+	// a strategy for an array that isn't being merged is an error.
+	err = ts.mergeArrays(map[string]interface{}{"only": []string{"qemu"}}, map[string]string{"scripts": MergeAppend})
+	if err == nil {
+		t.Error("expected an error, got none")
+	} else if err.Error() != "merge.scripts: no array to merge" {
+		t.Errorf("expected %q, got %q", "merge.scripts: no array to merge", err)
+	}
+	// a section without anything to merge into only keeps its strategies'
+	// results.
+	ts = TemplateSection{
+		Arrays: map[string]interface{}{
+			"scripts":    []string{"vagrant"},
+			"vboxmanage": []string{"cpus"},
+		},
+		Merge: map[string]string{
+			"scripts":    MergePrepend,
+			"vboxmanage": MergeRemove,
+		},
+	}
+	err = ts.resolveArrays()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected = map[string]interface{}{"scripts": []string{"vagrant"}}
+	if !reflect.DeepEqual(ts.Arrays, expected) {
+		t.Errorf("expected %#v, got %#v", expected, ts.Arrays)
+	}
+	if ts.Merge != nil {
+		t.Errorf("expected the strategies to be cleared, got %v", ts.Merge)
+	}
+}
+
+func TestUpdateBuildersMergeStrategies(t *testing.T) {
+	r := newRawTemplate()
+	r.Builders = map[string]BuilderC{
+		"common": {TemplateSection{Arrays: map[string]interface{}{"floppy_files": []string{"a"}}}},
+		"vbox":   {TemplateSection{Type: "virtualbox-iso", Arrays: map[string]interface{}{"vboxmanage": []string{"memory"}}}},
+	}
+	err := r.updateBuilders(map[string]BuilderC{
+		"common": {TemplateSection{
+			Arrays: map[string]interface{}{"floppy_files": []string{"b"}},
+			Merge:  map[string]string{"floppy_files": MergeAppend},
+		}},
+		"vbox": {TemplateSection{
+			Arrays: map[string]interface{}{"vboxmanage": []string{"cpus"}},
+			Merge:  map[string]string{"vboxmanage": MergePrepend},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := []string{"a", "b"}
+	if !reflect.DeepEqual(r.Builders["common"].Arrays["floppy_files"], expected) {
+		t.Errorf("expected %v, got %v", expected, r.Builders["common"].Arrays["floppy_files"])
+	}
+	expected = []string{"cpus", "memory"}
+	if !reflect.DeepEqual(r.Builders["vbox"].Arrays["vboxmanage"], expected) {
+		t.Errorf("expected %v, got %v", expected, r.Builders["vbox"].Arrays["vboxmanage"])
+	}
+	err = r.updateBuilders(map[string]BuilderC{
+		"vbox": {TemplateSection{
+			Arrays: map[string]interface{}{"vboxmanage": []string{"cpus"}},
+			Merge:  map[string]string{"vboxmanage": "insert"},
+		}},
+	})
+	if err == nil {
+		t.Error("expected an error, got none")
+	} else if err.Error() != "builder vbox: arrays.vboxmanage: insert: unknown merge strategy" {
+		t.Errorf("expected %q, got %q", "builder vbox: arrays.vboxmanage: insert: unknown merge strategy", err)
+	}
+}
@@ -1,8 +1,9 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
@@ -17,6 +18,57 @@ type PackerTemplate struct {
 	PostProcessors   []interface{}          `json:"post-processors,omitempty"`
 	Provisioners     []interface{}          `json:"provisioners,omitempty"`
 	Variables        map[string]interface{} `json:"variables,omitempty"`
+	// SensitiveVariables are the names of the variables whose values are
+	// sensitive.
+	SensitiveVariables []string `json:"sensitive-variables,omitempty"`
+	// format is the format the template is written in: json or hcl2.
+	format string
+	// varFile is whether or not a var-file with the variables' default values
+	// is written with the template.
+	varFile bool
+}
+
+// filename returns the name of the template's file within the template
+// output directory.  name is the template's name.
+func (p *PackerTemplate) filename(i IODirInf, name string) string {
+	if p.format == HCL2Template {
+		return filepath.Join(i.TemplateOutputDir, fmt.Sprintf("%s.pkr.hcl", name))
+	}
+	return filepath.Join(i.TemplateOutputDir, fmt.Sprintf("%s.json", name))
+}
+
+// varFilename returns the name of the template's var-file within the
+// template output directory.  name is the template's name.
+func (p *PackerTemplate) varFilename(i IODirInf, name string) string {
+	if p.format == HCL2Template {
+		return filepath.Join(i.TemplateOutputDir, fmt.Sprintf("%s.pkrvars.hcl", name))
+	}
+	return filepath.Join(i.TemplateOutputDir, fmt.Sprintf("%s.vars.json", name))
+}
+
+// hasVarFile returns whether or not a var-file gets written with the
+// template.
+func (p *PackerTemplate) hasVarFile() bool {
+	return p.varFile && len(p.Variables) > 0
+}
+
+// marshalVarFile returns the var-file, with each variable's default value,
+// in the template's format.
+func (p *PackerTemplate) marshalVarFile() ([]byte, error) {
+	if p.format == HCL2Template {
+		var buf bytes.Buffer
+		hcl2Body(&buf, p.Variables, 0)
+		return buf.Bytes(), nil
+	}
+	return json.MarshalIndent(p.Variables, "", "\t")
+}
+
+// marshal returns the template in its format.
+func (p *PackerTemplate) marshal(name string) ([]byte, error) {
+	if p.format == HCL2Template {
+		return p.hcl2(name)
+	}
+	return json.MarshalIndent(p, "", "\t")
 }
 
 // create a Packer build template based on the current configuration. The
@@ -60,14 +112,14 @@ func (p *PackerTemplate) create(i IODirInf, b BuildInf, dirs, files map[string]s
 			return err
 		}
 	}
-	// Write it out as JSON
-	tplJSON, err := json.MarshalIndent(p, "", "\t")
+	// Write it out in the template's format
+	tpl, err := p.marshal(b.Name)
 	if err != nil {
 		err = Error{b.BuildName, err}
 		log.Error(err)
 		return err
 	}
-	fname := filepath.Join(i.TemplateOutputDir, fmt.Sprintf("%s.json", b.Name))
+	fname := p.filename(i, b.Name)
 	f, err := os.Create(fname)
 	if err != nil {
 		err = Error{b.BuildName, err}
@@ -82,12 +134,29 @@ func (p *PackerTemplate) create(i IODirInf, b BuildInf, dirs, files map[string]s
 		}
 	}()
 
-	_, err = io.WriteString(f, string(tplJSON[:]))
+	_, err = f.Write(tpl)
 	if err != nil {
 		err = Error{b.BuildName, err}
 		log.Error(err)
 		return err
 	}
 	log.Infof("%s: packer template written to %s", b.BuildName, fname)
+	if !p.hasVarFile() {
+		return nil
+	}
+	vars, err := p.marshalVarFile()
+	if err != nil {
+		err = Error{b.BuildName, err}
+		log.Error(err)
+		return err
+	}
+	fname = p.varFilename(i, b.Name)
+	err = ioutil.WriteFile(fname, vars, 0644)
+	if err != nil {
+		err = Error{b.BuildName, err}
+		log.Error(err)
+		return err
+	}
+	log.Infof("%s: packer var-file written to %s", b.BuildName, fname)
 	return nil
 }
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// createPassthroughBuilder creates the settings for the passthrough builder,
+// ID; see createPassthrough.  Like any other builder, the common builder's
+// settings are merged into its own.
+func (r *RawTemplate) createPassthroughBuilder(ID string) (map[string]interface{}, error) {
+	t := r.Builders[ID].TemplateSection
+	common, ok := r.Builders[Common.String()]
+	if ok {
+		var err error
+		t.Settings, err = mergeSettingsSlices(common.Settings, t.Settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.createPassthrough(t)
+}
+
+// createPassthrough creates the settings for a passthrough component, whose
+// section is t.  The component's type is the section's type, which doesn't
+// have to be one that Feedlot supports, and its settings and arrays are used
+// as they are, after their variables have been replaced.  A setting whose
+// value is a command file is replaced by the file's command or, for
+// boot_command, by the file's commands.  The settings and arrays in the
+// section's resources are paths whose sources are found, see findSource, and
+// copied to the template output directory.
+func (r *RawTemplate) createPassthrough(t TemplateSection) (map[string]interface{}, error) {
+	log.Infof("%s: create passthrough component: %s", r.Name, t.Type)
+	keys := t.keys()
+	for _, k := range t.Resources {
+		if !contains(keys, k) {
+			return nil, Error{slug: "resources", err: fmt.Errorf("%s: not a setting or array", k)}
+		}
+	}
+	settings := map[string]interface{}{}
+	for _, s := range t.Settings {
+		k, v := parseVar(s)
+		v = r.replaceSettingVars(k, v)
+		if contains(t.Resources, k) {
+			p, err := r.componentResource(t.Type, v, true)
+			if err != nil {
+				return nil, SettingErr{k, v, err}
+			}
+			settings[k] = p
+			continue
+		}
+		if !stringIsCommandFilename(v) {
+			settings[k] = v
+			continue
+		}
+		cmds, err := r.commandsFromFile(v, t.Type)
+		if err != nil {
+			return nil, SettingErr{k, v, err}
+		}
+		if k == "boot_command" {
+			if len(cmds) == 0 {
+				return nil, SettingErr{k, v, ErrNoCommands}
+			}
+			settings[k] = cmds
+			continue
+		}
+		cmd := commandFromSlice(cmds)
+		if cmd == "" {
+			return nil, SettingErr{k, v, ErrNoCommands}
+		}
+		settings[k] = cmd
+	}
+	// sorted so that the first resource error is always the one returned.
+	names := make([]string, 0, len(t.Arrays))
+	for name := range t.Arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := r.replaceSettingValueVars(name, t.Arrays[name])
+		if contains(t.Resources, name) {
+			var err error
+			v, err = r.componentResources(name, t.Type, v, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		settings[name] = v
+	}
+	settings["type"] = t.Type
+	log.Infof("%s: created passthrough component: %s", r.Name, t.Type)
+	return settings, nil
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCreatePassthrough(t *testing.T) {
+	tests := []struct {
+		section  TemplateSection
+		expected map[string]interface{}
+		files    map[string]string
+		err      string
+	}{
+		{
+			TemplateSection{
+				Type:      "chef-client",
+				Settings:  []string{"config_template = chef.cfg", "execute_command = execute.command", "node_name = :name", "retries = 2"},
+				Arrays:    map[string]interface{}{"run_list": []interface{}{"recipe[:name]"}, "json": map[string]interface{}{"env": ":distro"}},
+				Resources: []string{"config_template"},
+			},
+			map[string]interface{}{
+				"type":            "chef-client",
+				"config_template": "chef.cfg",
+				"execute_command": "{{if .Sudo}}sudo {{end}}chef-client --no-color -c {{.ConfigPath}} -j {{.JsonPath}}",
+				"node_name":       "test",
+				"retries":         "2",
+				"run_list":        []interface{}{"recipe[test]"},
+				"json":            map[string]interface{}{"env": "ubuntu"},
+			},
+			map[string]string{"out/chef.cfg": "../test_files/src/chef-client/chef.cfg"},
+			"",
+		},
+		{
+			TemplateSection{
+				Type:      "chef-client",
+				Arrays:    map[string]interface{}{"configs": []interface{}{"chef.cfg"}},
+				Resources: []string{"configs"},
+			},
+			map[string]interface{}{
+				"type":    "chef-client",
+				"configs": []string{"chef.cfg"},
+			},
+			map[string]string{"out/chef.cfg": "../test_files/src/chef-client/chef.cfg"},
+			"",
+		},
+		{
+			TemplateSection{Type: "goss", Settings: []string{"version = 0.3.9"}, Resources: []string{"tests"}},
+			nil,
+			nil,
+			"resources: tests: not a setting or array",
+		},
+		{
+			TemplateSection{Type: "goss", Arrays: map[string]interface{}{"tests": []interface{}{float64(1)}}, Resources: []string{"tests"}},
+			nil,
+			nil,
+			"tests[0]: expected a resource path, got number",
+		},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.Delim = ":"
+		r.SourceDir = "../test_files/src"
+		r.TemplateOutputDir = "out"
+		r.VarVals = map[string]string{":name": "test", ":distro": "ubuntu"}
+		settings, err := r.createPassthrough(test.section)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(settings, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, settings)
+		}
+		if !reflect.DeepEqual(r.Files, test.files) {
+			t.Errorf("%d: expected %v, got %v", i, test.files, r.Files)
+		}
+	}
+}
+
+func TestPassthroughComponents(t *testing.T) {
+	r := newRawTemplate()
+	r.Delim = ":"
+	r.VarVals = map[string]string{":name": "test"}
+	r.BuilderIDs = []string{"hyperv-iso"}
+	r.Builders = map[string]BuilderC{
+		"common":     {TemplateSection{Settings: []string{"ssh_username = vagrant", "disk_size = 20000"}}},
+		"hyperv-iso": {TemplateSection{Type: "hyperv-iso", Settings: []string{"generation = 2", "disk_size = 40000"}, Passthrough: true}},
+	}
+	r.PostProcessors = map[string]PostProcessorC{
+		"manifest": {TemplateSection{Type: "manifest", Settings: []string{"output = :name.json"}, Passthrough: true}},
+	}
+	r.Provisioners = map[string]ProvisionerC{
+		"goss": {TemplateSection{Type: "goss", Arrays: map[string]interface{}{"only": []interface{}{"hyperv-iso"}}, Passthrough: true}},
+	}
+	err := r.checkCommonBuilder()
+	if err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+	if len(r.warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", r.warnings)
+	}
+	b, err := r.createBuilder("hyperv-iso")
+	if err != nil {
+		t.Fatalf("builder: expected no error, got %q", err)
+	}
+	expected := map[string]interface{}{"type": "hyperv-iso", "ssh_username": "vagrant", "disk_size": "40000", "generation": "2"}
+	if !reflect.DeepEqual(b, expected) {
+		t.Errorf("builder: expected %v, got %v", expected, b)
+	}
+	pp, err := r.createPostProcessor("manifest")
+	if err != nil {
+		t.Fatalf("post-processor: expected no error, got %q", err)
+	}
+	expected = map[string]interface{}{"type": "manifest", "output": "test.json"}
+	if !reflect.DeepEqual(pp, expected) {
+		t.Errorf("post-processor: expected %v, got %v", expected, pp)
+	}
+	p, err := r.createProvisioner("goss")
+	if err != nil {
+		t.Fatalf("provisioner: expected no error, got %q", err)
+	}
+	expected = map[string]interface{}{"type": "goss", "only": []string{"hyperv-iso"}}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("provisioner: expected %v, got %v", expected, p)
+	}
+	r.Provisioners["goss"] = ProvisionerC{TemplateSection{Type: "goss", Arrays: map[string]interface{}{"only": []interface{}{"vbox"}}, Passthrough: true}}
+	_, err = r.createProvisioner("goss")
+	if err == nil || err.Error() != "goss: only: vbox: builder not in builder_ids" {
+		t.Errorf("provisioner: expected %q, got %v", "goss: only: vbox: builder not in builder_ids", err)
+	}
+}
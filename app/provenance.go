@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// The layers that a template's settings can come from, in the order they are
+// merged.
+const (
+	// DefaultLayer is the Feedlot defaults: the default conf file.
+	DefaultLayer = "default"
+	// SupportedLayer is a distro's section of the supported conf file.
+	SupportedLayer = "supported"
+	// BuildLayer is a build template.
+	BuildLayer = "build"
+)
+
+// Origin is where a setting's value came from: the layer and file it was
+// defined in and, for component settings, the component section, e.g.
+// builders.common.
+type Origin struct {
+	Layer   string `json:"layer" toml:"layer"`
+	File    string `json:"file" toml:"file"`
+	Section string `json:"section,omitempty" toml:"section"`
+	Value   string `json:"value" toml:"value"`
+}
+
+func (o Origin) String() string {
+	s := fmt.Sprintf("%s: %s", o.Layer, o.File)
+	if o.Section != "" {
+		s += ": " + o.Section
+	}
+	return s
+}
+
+// Provenance maps each setting to the values it was assigned while its
+// template was being merged, in the order they were assigned: the last one is
+// the setting's final value.  Top level settings use their conf name, e.g.
+// template_output_dir.  Component settings are prefixed with their section,
+// e.g. builders.virtualbox-iso.boot_wait; component arrays also have the
+// arrays prefix, e.g. builders.virtualbox-iso.arrays.vboxmanage.
+type Provenance map[string][]Origin
+
+// Blame is a setting's final value along with where it came from and the
+// values that it replaced.
+type Blame struct {
+	Key      string   `json:"key" toml:"key"`
+	Value    string   `json:"value" toml:"value"`
+	Origin   Origin   `json:"origin" toml:"origin"`
+	Replaced []Origin `json:"replaced,omitempty" toml:"replaced"`
+}
+
+// setOrigin sets the layer and file of the settings that are about to be
+// merged into the template.
+func (r *RawTemplate) setOrigin(layer, file string) {
+	r.origin = Origin{Layer: layer, File: file}
+}
+
+// recordOrigin records the value of the setting, k, as coming from the
+// current origin.  If the template doesn't have an origin set, nothing is
+// recorded.
+func (r *RawTemplate) recordOrigin(section, k, v string) {
+	if r.origin.Layer == "" {
+		return
+	}
+	if r.Provenance == nil {
+		r.Provenance = Provenance{}
+	}
+	o := r.origin
+	o.Section = section
+	o.Value = v
+	if section != "" {
+		k = section + "." + k
+	}
+	r.Provenance[k] = append(r.Provenance[k], o)
+}
+
+// recordInfOrigins records the origin of every field in v, a settings struct
+// like IODirInf, that will be used by its update method: fields without a
+// value are skipped.
+func (r *RawTemplate) recordInfOrigins(v interface{}) {
+	if r.origin.Layer == "" {
+		return
+	}
+	val := reflect.ValueOf(v)
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		f := val.Field(i)
+		if f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				continue
+			}
+			f = f.Elem()
+		}
+		s := fmt.Sprint(f.Interface())
+		if s == "" {
+			continue
+		}
+		r.recordOrigin("", name, s)
+	}
+}
+
+// recordIDsOrigin records the origin of a component ID list.
+func (r *RawTemplate) recordIDsOrigin(k string, ids []string) {
+	r.recordOrigin("", k, strings.Join(ids, ", "))
+}
+
+// recordSectionOrigins records the origin of every setting and array in a
+// component's section; typ is the component type's section name, e.g.
+// builders.
+func (r *RawTemplate) recordSectionOrigins(typ, ID string, t TemplateSection) {
+	if r.origin.Layer == "" {
+		return
+	}
+	section := typ + "." + ID
+	for _, s := range t.Settings {
+		k, v := parseVar(s)
+		r.recordOrigin(section, k, v)
+	}
+	for k, v := range t.Arrays {
+		r.recordOrigin(section, "arrays."+k, fmt.Sprint(v))
+	}
+}
+
+// blame returns the final value of every setting in the template, sorted by
+// key, along with its origin and the values it replaced.  As when the Packer
+// template is created, the common builder's settings are merged into the
+// settings of each builder: a builder's own setting replaces the common
+// builder's setting.
+func (r *RawTemplate) blame() []Blame {
+	commonPrefix := "builders." + Common.String() + "."
+	chains := make(map[string][]Origin, len(r.Provenance))
+	common := map[string][]Origin{}
+	for k, origins := range r.Provenance {
+		if !strings.HasPrefix(k, commonPrefix) {
+			chains[k] = origins
+			continue
+		}
+		// Only the common builder's settings are used by the other builders.
+		if !strings.HasPrefix(k, commonPrefix+"arrays.") {
+			common[strings.TrimPrefix(k, commonPrefix)] = origins
+		}
+	}
+	for ID := range r.Builders {
+		if ID == Common.String() {
+			continue
+		}
+		for k, origins := range common {
+			key := "builders." + ID + "." + k
+			chains[key] = append(append([]Origin{}, origins...), chains[key]...)
+		}
+	}
+	blames := make([]Blame, 0, len(chains))
+	for k, origins := range chains {
+		blames = append(blames, newBlame(k, origins))
+	}
+	sort.Slice(blames, func(i, j int) bool { return blames[i].Key < blames[j].Key })
+	return blames
+}
+
+// newBlame returns the Blame for the setting, k, using its origins.
+func newBlame(k string, origins []Origin) Blame {
+	last := origins[len(origins)-1]
+	b := Blame{Key: k, Value: last.Value, Origin: last}
+	if len(origins) > 1 {
+		b.Replaced = append([]Origin{}, origins[:len(origins)-1]...)
+	}
+	return b
+}
+
+// BlameBuild returns the final value of every setting in the named build along
+// with where it came from and any values it replaced.  The values are as they
+// were defined; Feedlot variables are not replaced.
+func BlameBuild(name string) ([]Blame, error) {
+	if !DistroDefaults.IsSet {
+		log.Debug("loading distro defaults")
+		err := DistroDefaults.Set()
+		if err != nil {
+			err = fmt.Errorf("blame build failed: %s", err)
+			log.Error(err)
+			return nil, err
+		}
+	}
+	err := loadBuilds()
+	if err != nil {
+		err = fmt.Errorf("blame build failed: %s", err)
+		log.Error(err)
+		return nil, err
+	}
+	rTpl, err := buildRawTemplate(name)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return nil, err
+	}
+	return rTpl.blame(), nil
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordOrigin(t *testing.T) {
+	r := newRawTemplate()
+	// without an origin nothing gets recorded
+	r.recordOrigin("", "arch", "amd64")
+	if len(r.Provenance) != 0 {
+		t.Errorf("expected no provenance, got %v", r.Provenance)
+	}
+	r.setOrigin(DefaultLayer, "conf/default.toml")
+	r.recordInfOrigins(IODirInf{TemplateOutputDir: "out/:distro", SourceDir: "src"})
+	r.recordInfOrigins(PackerInf{MinPackerVersion: "0.8.0"})
+	r.recordSectionOrigins("builders", "common", TemplateSection{
+		Settings: []string{"boot_wait=5s"},
+		Arrays:   map[string]interface{}{"boot_command": "boot.command"},
+	})
+	r.setOrigin(BuildLayer, "conf/build.toml")
+	r.recordOrigin("", "arch", "amd64")
+	r.recordSectionOrigins("builders", "common", TemplateSection{Settings: []string{"boot_wait = 10s"}})
+	expected := Provenance{
+		"template_output_dir": {{Layer: DefaultLayer, File: "conf/default.toml", Value: "out/:distro"}},
+		"source_dir":          {{Layer: DefaultLayer, File: "conf/default.toml", Value: "src"}},
+		"min_packer_version":  {{Layer: DefaultLayer, File: "conf/default.toml", Value: "0.8.0"}},
+		"builders.common.boot_wait": {
+			{Layer: DefaultLayer, File: "conf/default.toml", Section: "builders.common", Value: "5s"},
+			{Layer: BuildLayer, File: "conf/build.toml", Section: "builders.common", Value: "10s"},
+		},
+		"builders.common.arrays.boot_command": {{Layer: DefaultLayer, File: "conf/default.toml", Section: "builders.common", Value: "boot.command"}},
+		"arch":                                {{Layer: BuildLayer, File: "conf/build.toml", Value: "amd64"}},
+	}
+	if !reflect.DeepEqual(r.Provenance, expected) {
+		t.Errorf("expected %v, got %v", expected, r.Provenance)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	dflt := Origin{Layer: DefaultLayer, File: "default.toml"}
+	bld := Origin{Layer: BuildLayer, File: "build.toml"}
+	origin := func(o Origin, section, v string) Origin {
+		o.Section = section
+		o.Value = v
+		return o
+	}
+	r := newRawTemplate()
+	r.Builders = map[string]BuilderC{
+		"common":         {TemplateSection{Type: "common"}},
+		"virtualbox-iso": {TemplateSection{Type: "virtualbox-iso"}},
+	}
+	r.Provenance = Provenance{
+		"arch": {origin(dflt, "", "i386"), origin(bld, "", "amd64")},
+		"builders.common.boot_wait": {
+			origin(dflt, "builders.common", "5s"),
+		},
+		"builders.common.arrays.boot_command": {origin(dflt, "builders.common", "boot.command")},
+		"builders.virtualbox-iso.boot_wait":   {origin(bld, "builders.virtualbox-iso", "10s")},
+	}
+	expected := []Blame{
+		{Key: "arch", Value: "amd64", Origin: origin(bld, "", "amd64"), Replaced: []Origin{origin(dflt, "", "i386")}},
+		{Key: "builders.virtualbox-iso.boot_wait", Value: "10s", Origin: origin(bld, "builders.virtualbox-iso", "10s"), Replaced: []Origin{origin(dflt, "builders.common", "5s")}},
+	}
+	blames := r.blame()
+	if !reflect.DeepEqual(blames, expected) {
+		t.Errorf("expected %v, got %v", expected, blames)
+	}
+}
+
+func TestOriginString(t *testing.T) {
+	tests := []struct {
+		origin   Origin
+		expected string
+	}{
+		{Origin{Layer: BuildLayer, File: "conf/build.toml"}, "build: conf/build.toml"},
+		{Origin{Layer: DefaultLayer, File: "conf/default.toml", Section: "builders.common"}, "default: conf/default.toml: builders.common"},
+	}
+	for i, test := range tests {
+		s := test.origin.String()
+		if s != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, s)
+		}
+	}
+}
@@ -90,7 +90,8 @@ type RawTemplate struct {
 	// Current date in ISO 8601
 	Date string
 	// The character(s) used to identify variables for Feedlot. By default
-	// this is a colon, :. Currently only a starting delimeter is supported.
+	// this is a colon, :. The delimeter also ends a reference, e.g.
+	// :name:_suffix.
 	Delim string
 	// The distro that this template targets. The type must be a supported
 	// type, i.e. defined in supported.toml. The values for type are
@@ -120,6 +121,38 @@ type RawTemplate struct {
 	// the directory will be copied. The same resolution rules apply for dirs as for
 	// files. The destination directory is the key, the source directory is the value
 	Dirs map[string]string
+	// Extends lists the builds that this build template inherits from, in the
+	// order they are merged.  Each parent's settings are merged, using the same
+	// rules as a build's settings, before this template's settings are.
+	Extends []string
+	// Tags are labels that selectors can select the build by, e.g. ci.  A
+	// build also has the tags of the builds it extends.
+	Tags []string
+	// Matrix, if set, expands the build template into a build for each of
+	// the matrix's combinations of releases, arches, and images.
+	Matrix *Matrix
+	// MatrixOverrides are the overrides of the matrix combination that the
+	// build was generated for, if any.  They are merged, in order, after the
+	// build's settings.
+	MatrixOverrides []*RawTemplate `toml:"-" json:"-"`
+	// chain is the resolved inheritance chain of the build: the names of the
+	// builds whose settings were merged, in the order they were merged.  The
+	// last one is the build itself.
+	chain []string
+	// Provenance records where each setting came from as the template's
+	// settings are merged.
+	Provenance Provenance `toml:"-" json:"-"`
+	// origin is the layer and file of the settings currently being merged.
+	origin Origin
+	// env is the name of the environment the build template was loaded from,
+	// if any.
+	env string
+	// varErrs are the undefined variable errors recorded while creating a
+	// component; see replaceSettingVars.
+	varErrs []UndefinedVarErr
+	// warnings are the unknown settings found while creating the
+	// components, when not in strict mode; see unknownSetting.
+	warnings []string
 }
 
 // mewRawTemplate returns a rawTemplate with current date in ISO 8601 format.
@@ -144,11 +177,26 @@ func (r *RawTemplate) createPackerTemplate() (PackerTemplate, error) {
 	log.Infof("%s: create packer template", r.Name)
 	var err error
 	// Resolve the Feedlot variables to their final values.
-	r.mergeVariables()
+	err = r.mergeVariables()
+	if err != nil {
+		err = Error{slug: r.BuildInf.Name, err: err}
+		log.Error(err)
+		return PackerTemplate{}, err
+	}
 	// General Packer Stuff
 	p := PackerTemplate{}
 	p.MinPackerVersion = r.MinPackerVersion
 	p.Description = r.Description
+	format := r.TemplateFormat
+	if format == "" {
+		format = contour.GetString(conf.TemplateFormat)
+	}
+	p.format, err = parseTemplateFormat(format)
+	if err != nil {
+		err = Error{slug: r.BuildInf.Name, err: err}
+		log.Error(err)
+		return p, err
+	}
 	// Builders
 	p.Builders, err = r.createBuilders()
 	if err != nil {
@@ -170,23 +218,97 @@ func (r *RawTemplate) createPackerTemplate() (PackerTemplate, error) {
 		log.Error(err)
 		return p, err
 	}
+	// Variables: a user variable that is referenced but not declared is a
+	// warning, or an error in strict mode.
+	p.Variables, p.SensitiveVariables, err = r.createVariables()
+	if err != nil {
+		err = Error{slug: r.BuildInf.Name, err: err}
+		log.Error(err)
+		return p, err
+	}
+	for _, e := range p.undeclaredUserVariables() {
+		err = r.strictErr(e)
+		if err != nil {
+			err = Error{slug: r.BuildInf.Name, err: err}
+			log.Error(err)
+			return p, err
+		}
+	}
+	p.varFile = r.VarFile != nil && *r.VarFile
 	// Return the generated Packer Template.
 	log.Infof("%s: packer template created", r.Name)
 	return p, nil
 }
 
+// validate goes through the Packer template creation process for every
+// component in the template and returns all of the problems found.  Unlike
+// createPackerTemplate, processing doesn't stop on the first error.
+func (r *RawTemplate) validate() []error {
+	log.Infof("%s: validate template", r.Name)
+	var errs []error
+	err := r.mergeVariables()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	_, err = parseTemplateFormat(r.TemplateFormat)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(r.BuilderIDs) == 0 {
+		errs = append(errs, BuilderErr{Err: errors.New("no builders specified")})
+	}
+	// the created components are kept so that their variable references
+	// can be checked.
+	var p PackerTemplate
+	err = r.checkCommonBuilder()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, ID := range r.BuilderIDs {
+		b, err := r.createBuilder(ID)
+		if err != nil {
+			errs = append(errs, componentErrs(err)...)
+			continue
+		}
+		p.Builders = append(p.Builders, b)
+	}
+	for _, ID := range r.PostProcessorIDs {
+		pp, err := r.createPostProcessorSeq(ID)
+		if err != nil {
+			errs = append(errs, componentErrs(err)...)
+			continue
+		}
+		p.PostProcessors = append(p.PostProcessors, pp)
+	}
+	for _, ID := range r.ProvisionerIDs {
+		pr, err := r.createProvisioner(ID)
+		if err != nil {
+			errs = append(errs, componentErrs(err)...)
+			continue
+		}
+		p.Provisioners = append(p.Provisioners, pr)
+	}
+	p.Variables, p.SensitiveVariables, err = r.createVariables()
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, e := range p.undeclaredUserVariables() {
+			err = r.strictErr(e)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	log.Infof("%s: %d problems found", r.Name, len(errs))
+	return errs
+}
+
 // replaceVariables checks incoming string for variables and replaces them with
-// their values.
+// their values.  Variables that aren't defined are left as is and a doubled
+// delimiter is replaced by a single one.
 func (r *RawTemplate) replaceVariables(s string) string {
 	log.Debugf("replace vars: %s", s)
-	//see if the delim is in the string, if not, nothing to replace
-	if strings.Index(s, r.Delim) < 0 {
-		return s
-	}
-	// Go through each variable and replace as applicable.
-	for vName, vVal := range r.VarVals {
-		s = strings.Replace(s, vName, vVal, -1)
-	}
+	s, _ = expandVars(s, r.Delim, r.lookupVar)
 	log.Debugf("vars replaced: %s", s)
 	return s
 }
@@ -200,6 +322,9 @@ func (r *RawTemplate) setDefaults(d *SupportedDistro) error {
 	// Note: Arch, Image, and Release are not updated here as how these fields
 	// are updated depends on whether this is a build from a distribution's
 	// default template or from a defined build template.
+	r.recordInfOrigins(d.IODirInf)
+	r.recordInfOrigins(d.PackerInf)
+	r.recordInfOrigins(d.BuildInf)
 	r.IODirInf.update(d.IODirInf)
 	r.PackerInf.update(d.PackerInf)
 	r.BuildInf.update(d.BuildInf)
@@ -218,6 +343,10 @@ func (r *RawTemplate) setDefaults(d *SupportedDistro) error {
 	for _, v := range d.DefImage {
 		k, vv := parseVar(v)
 		switch k {
+		case "arch", "image", "release":
+			r.recordOrigin("", k, vv)
+		}
+		switch k {
 		case "arch":
 			r.Arch = vv
 		case "image":
@@ -229,20 +358,31 @@ func (r *RawTemplate) setDefaults(d *SupportedDistro) error {
 	// If defined, BuilderTypes override any prior BuilderTypes Settings
 	if d.BuilderIDs != nil {
 		log.Debugf("%s: override builder IDs with %v", r.Name, d.BuilderIDs)
+		r.recordIDsOrigin("builder_ids", d.BuilderIDs)
 		r.BuilderIDs = d.BuilderIDs
 	}
 	// If defined, PostProcessorTypes override any prior PostProcessorTypes Settings
 	if d.PostProcessorIDs != nil {
 		log.Debugf("%s: override post-processor IDs with %v", r.Name, d.PostProcessorIDs)
+		r.recordIDsOrigin("post_processor_ids", d.PostProcessorIDs)
 		r.PostProcessorIDs = d.PostProcessorIDs
 	}
 	// If defined, ProvisionerTypes override any prior ProvisionerTypes Settings
 	if d.ProvisionerIDs != nil {
 		log.Debugf("%s: override provisioner IDs with %v", r.Name, d.ProvisionerIDs)
+		r.recordIDsOrigin("provisioner_ids", d.ProvisionerIDs)
 		r.ProvisionerIDs = d.ProvisionerIDs
 	}
+	err := r.updateVariables(d.Variables, d.SensitiveVariables)
+	if err != nil {
+		return Error{slug: "set variable defaults", err: err}
+	}
+	err = r.updateVars(d.Vars)
+	if err != nil {
+		return Error{slug: "set var defaults", err: err}
+	}
 	// merge the build portions.
-	err := r.updateBuilders(d.Builders)
+	err = r.updateBuilders(d.Builders)
 	if err != nil {
 		return Error{slug: "set builder defaults", err: err}
 	}
@@ -258,34 +398,67 @@ func (r *RawTemplate) setDefaults(d *SupportedDistro) error {
 	return nil
 }
 
-// r.updateBuildSettings merges Settings between an old and new template.
+// rawTemplate returns a copy of the defaults' settings as a RawTemplate whose
+// origin is the defaults' file.  This is used to merge an env's defaults into
+// its distro templates.
+func (d *Defaults) rawTemplate() *RawTemplate {
+	dflts := deepcopy.Copy(d).(*Defaults)
+	r := &RawTemplate{
+		PackerInf: dflts.PackerInf,
+		IODirInf:  dflts.IODirInf,
+		BuildInf:  dflts.BuildInf,
+		Build:     dflts.Build,
+	}
+	r.setOrigin(DefaultLayer, d.file)
+	return r
+}
+
+// r.updateBuildSettings merges Settings between an old and new template and
+// then resolves the template's relative source and template output dirs.
 // Note:  Arch, Image, and Release are not updated here as how these fields are
 // updated depends on whether this is a build from a distribution's default
 // template or from a defined build template.
 func (r *RawTemplate) updateBuildSettings(bld *RawTemplate) error {
-	r.IODirInf.update(bld.IODirInf)
-	r.updateSourceDirSetting()
-	err := r.updateTemplateOutputDirSetting()
+	err := r.mergeBuildSettings(bld)
 	if err != nil {
 		return err
 	}
+	r.updateSourceDirSetting()
+	return r.updateTemplateOutputDirSetting()
+}
+
+// r.mergeBuildSettings merges Settings between an old and new template.  Unlike
+// updateBuildSettings, the relative dirs are not resolved; this allows the
+// settings of several templates, e.g. a build's parents, to be merged before
+// the dirs are resolved once.
+func (r *RawTemplate) mergeBuildSettings(bld *RawTemplate) error {
+	// the build's settings come from the build's origin.
+	r.origin = bld.origin
+	r.recordInfOrigins(bld.IODirInf)
+	r.IODirInf.update(bld.IODirInf)
+	r.recordInfOrigins(bld.PackerInf)
 	r.PackerInf.update(bld.PackerInf)
+	r.recordInfOrigins(bld.BuildInf)
 	r.BuildInf.update(bld.BuildInf)
 	if bld.Arch != "" {
 		log.Debugf("%s: set arch from %s: %s", r.Name, bld.Name, bld.Arch)
+		r.recordOrigin("", "arch", bld.Arch)
 		r.Arch = bld.Arch
 	}
 	if bld.Image != "" {
 		log.Debugf("%s: set image from %s: %s", r.Name, bld.Name, bld.Image)
+		r.recordOrigin("", "image", bld.Image)
 		r.Image = bld.Image
 	}
 	if bld.Release != "" {
 		log.Debugf("%s: set release from %s: %s", r.Name, bld.Name, bld.Release)
+		r.recordOrigin("", "release", bld.Release)
 		r.Release = bld.Release
 	}
 	// If defined, Builders override any prior builder Settings.
 	if len(bld.BuilderIDs) > 0 {
 		log.Debugf("%s: set builder ids from %s: %s", r.Name, bld.Name, bld.BuilderIDs)
+		r.recordIDsOrigin("builder_ids", bld.BuilderIDs)
 		r.BuilderIDs = bld.BuilderIDs
 	}
 	//   if nil don't do anything (this means prior settings are used, e.g. default)
@@ -295,17 +468,32 @@ func (r *RawTemplate) updateBuildSettings(bld *RawTemplate) error {
 	//   if len > 0 replace the existing types with the builder's.
 	if bld.PostProcessorIDs != nil {
 		log.Debugf("%s: set post-processor ids from %s: %s", r.Name, bld.Name, bld.PostProcessorIDs)
+		r.recordIDsOrigin("post_processor_ids", bld.PostProcessorIDs)
 		r.PostProcessorIDs = bld.PostProcessorIDs
 	}
 	if bld.ProvisionerIDs != nil {
 		log.Debugf("%s: set provisioner ids from %s: %s", r.Name, bld.Name, bld.ProvisionerIDs)
+		r.recordIDsOrigin("provisioner_ids", bld.ProvisionerIDs)
 		r.ProvisionerIDs = bld.ProvisionerIDs
 	}
+	err := r.updateVariables(bld.Variables, bld.SensitiveVariables)
+	if err != nil {
+		return err
+	}
+	err = r.updateVars(bld.Vars)
+	if err != nil {
+		return err
+	}
 	// merge the build portions.
-	r.updateBuilders(bld.Builders)
-	r.updatePostProcessors(bld.PostProcessors)
-	r.updateProvisioners(bld.Provisioners)
-	return nil
+	err = r.updateBuilders(bld.Builders)
+	if err != nil {
+		return err
+	}
+	err = r.updatePostProcessors(bld.PostProcessors)
+	if err != nil {
+		return err
+	}
+	return r.updateProvisioners(bld.Provisioners)
 }
 
 // updateTemplateOutputDirSetting updates the template_output_dir setting
@@ -345,29 +533,29 @@ func (r *RawTemplate) updateSourceDirSetting() {
 //  image                the image used, e.g. server
 //  date                 the current datetime, time.Now()
 //  build_name           the name of the build template
+//  name                 the name of the template
 //  template_output_dir  the directory to write the template build output to
 //  packer_output_dir    the directory to write the Packer build artifact to
 //  source_dir           the directory of any source files used in the build*
+//  env.NAME             the value of the environment variable NAME
+//
+// Any variable defined in the template's vars is also supported.  The name,
+// dirs, and vars are resolved in dependency order; see resolveVars.
 //
 // Note: source_dir must be set. Feedlot searches for referenced files and
 // uses source_dir/distro as the last search directory. This directory is
 // also used as the base directory for any specified src directories.
-func (r *RawTemplate) mergeVariables() {
+func (r *RawTemplate) mergeVariables() error {
 	// Get the delim and set the replacement map, resolve name information
 	r.setBaseVarVals()
-	// get final value for name first
-	r.Name = r.replaceVariables(r.Name)
-	r.VarVals[r.Delim+"name"] = r.Name
-	// then merge the sourc and out dirs and set them
-	r.SourceDir = r.replaceVariables(r.SourceDir)
-	r.TemplateOutputDir = r.replaceVariables(r.TemplateOutputDir)
-	r.PackerOutputDir = r.replaceVariables(r.PackerOutputDir)
-	r.VarVals[r.Delim+"template_output_dir"] = r.TemplateOutputDir
-	r.VarVals[r.Delim+"packer_output_dir"] = r.PackerOutputDir
-	r.VarVals[r.Delim+"source_dir"] = r.SourceDir
+	err := r.resolveVars()
+	if err != nil {
+		return err
+	}
 	log.Debugf("%s: merged SourceDir: %s", r.Name, r.SourceDir)
 	log.Debugf("%s: merged TemplateOutputDir: %s", r.Name, r.TemplateOutputDir)
 	log.Debugf("%s: merged PackerOutputDir: %s", r.Name, r.PackerOutputDir)
+	return nil
 }
 
 // setBaseVarVals sets the varVals for the base variables
@@ -2400,7 +2400,7 @@ func TestCreateAmazonChroot(t *testing.T) {
 		},
 		"type": "amazon-chroot",
 	}
-	bldr, err := testAllBuilders.createAmazonChroot("amazon-chroot")
+	bldr, err := builderFactories["amazon-chroot"].Create(&testAllBuilders, "amazon-chroot")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2437,7 +2437,7 @@ func TestCreateAmazonChroot(t *testing.T) {
 		"ssh_timeout":                  "10m",
 		"type":                         "amazon-chroot",
 	}
-	bldr, err = testAllBuildersSSH.createAmazonChroot("amazon-chroot")
+	bldr, err = builderFactories["amazon-chroot"].Create(&testAllBuildersSSH, "amazon-chroot")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2467,7 +2467,7 @@ func TestCreateAmazonChroot(t *testing.T) {
 		"winrm_use_ssl":           true,
 		"winrm_insecure":          true,
 	}
-	bldr, err = testAllBuildersWinRM.createAmazonChroot("amazon-chroot")
+	bldr, err = builderFactories["amazon-chroot"].Create(&testAllBuildersWinRM, "amazon-chroot")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2547,7 +2547,7 @@ func TestCreateAmazonEBS(t *testing.T) {
 		"vpc_id":                   "VPC_ID",
 		"windows_password_timeout": "10m",
 	}
-	bldr, err := testAllBuilders.createAmazonEBS("amazon-ebs")
+	bldr, err := builderFactories["amazon-ebs"].Create(&testAllBuilders, "amazon-ebs")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2594,7 +2594,7 @@ func TestCreateAmazonEBS(t *testing.T) {
 		"user_data_file":               "amazon-ebs/amazon.userdata",
 		"vpc_id":                       "VPC_ID",
 	}
-	bldr, err = testAllBuildersSSH.createAmazonEBS("amazon-ebs")
+	bldr, err = builderFactories["amazon-ebs"].Create(&testAllBuildersSSH, "amazon-ebs")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2635,7 +2635,7 @@ func TestCreateAmazonEBS(t *testing.T) {
 		"winrm_use_ssl":               true,
 		"winrm_insecure":              true,
 	}
-	bldr, err = testAllBuildersWinRM.createAmazonEBS("amazon-ebs")
+	bldr, err = builderFactories["amazon-ebs"].Create(&testAllBuildersWinRM, "amazon-ebs")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2737,7 +2737,7 @@ func TestCreateAmazonInstance(t *testing.T) {
 		"x509_upload_path":         "/etc/x509",
 	}
 	contour.UpdateString("source_dir", "../test_files/src")
-	bldr, err := testAllBuilders.createAmazonInstance("amazon-instance")
+	bldr, err := builderFactories["amazon-instance"].Create(&testAllBuilders, "amazon-instance")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2797,7 +2797,7 @@ func TestCreateAmazonInstance(t *testing.T) {
 		"x509_key_path":    "/path/to/x509/key",
 		"x509_upload_path": "/etc/x509",
 	}
-	bldr, err = testAllBuildersSSH.createAmazonInstance("amazon-instance")
+	bldr, err = builderFactories["amazon-instance"].Create(&testAllBuildersSSH, "amazon-instance")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2849,7 +2849,7 @@ func TestCreateAmazonInstance(t *testing.T) {
 		"x509_key_path":            "/path/to/x509/key",
 		"x509_upload_path":         "/etc/x509",
 	}
-	bldr, err = testAllBuildersWinRM.createAmazonInstance("amazon-instance")
+	bldr, err = builderFactories["amazon-instance"].Create(&testAllBuildersWinRM, "amazon-instance")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2872,7 +2872,7 @@ func TestCreateDigitalOcean(t *testing.T) {
 		"type":               "digitalocean",
 		"user_data":          "userdata",
 	}
-	bldr, err := testAllBuilders.createDigitalOcean("digitalocean")
+	bldr, err := builderFactories["digitalocean"].Create(&testAllBuilders, "digitalocean")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2908,7 +2908,7 @@ func TestCreateDigitalOcean(t *testing.T) {
 		"type":                         "digitalocean",
 		"user_data":                    "userdata",
 	}
-	bldr, err = testAllBuildersSSH.createDigitalOcean("digitalocean")
+	bldr, err = builderFactories["digitalocean"].Create(&testAllBuildersSSH, "digitalocean")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -2938,7 +2938,7 @@ func TestCreateDigitalOcean(t *testing.T) {
 		"winrm_use_ssl":      true,
 		"winrm_insecure":     true,
 	}
-	bldr, err = testAllBuildersWinRM.createDigitalOcean("digitalocean")
+	bldr, err = builderFactories["digitalocean"].Create(&testAllBuildersWinRM, "digitalocean")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3014,7 +3014,7 @@ func TestCreateDocker(t *testing.T) {
 		},
 		"type": "docker",
 	}
-	bldr, err := testAllBuilders.createDocker("docker")
+	bldr, err := builderFactories["docker"].Create(&testAllBuilders, "docker")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3022,7 +3022,7 @@ func TestCreateDocker(t *testing.T) {
 			t.Errorf("Expected %#v, got %#v", expected, bldr)
 		}
 	}
-	bldr, err = testDockerRunComandFile.createDocker("docker")
+	bldr, err = builderFactories["docker"].Create(&testDockerRunComandFile, "docker")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3030,7 +3030,7 @@ func TestCreateDocker(t *testing.T) {
 			t.Errorf("Expected %#v, got %#v", expectedCommandFile, bldr)
 		}
 	}
-	bldr, err = testDockerRunComand.createDocker("docker")
+	bldr, err = builderFactories["docker"].Create(&testDockerRunComand, "docker")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3066,7 +3066,7 @@ func TestCreateDocker(t *testing.T) {
 		"ssh_timeout":                  "10m",
 		"type":                         "docker",
 	}
-	bldr, err = testAllBuildersSSH.createDocker("docker")
+	bldr, err = builderFactories["docker"].Create(&testAllBuildersSSH, "docker")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3095,7 +3095,7 @@ func TestCreateDocker(t *testing.T) {
 		"winrm_insecure": true,
 		"type":           "docker",
 	}
-	bldr, err = testAllBuildersWinRM.createDocker("docker")
+	bldr, err = builderFactories["docker"].Create(&testAllBuildersWinRM, "docker")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3131,7 +3131,7 @@ func TestCreateGoogleCompute(t *testing.T) {
 		"zone":            "us-central1-a",
 	}
 
-	bldr, err := testAllBuilders.createGoogleCompute("googlecompute")
+	bldr, err := builderFactories["googlecompute"].Create(&testAllBuilders, "googlecompute")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3173,7 +3173,7 @@ func TestCreateGoogleCompute(t *testing.T) {
 		"zone":                         "us-central1-a",
 	}
 
-	bldr, err = testAllBuildersSSH.createGoogleCompute("googlecompute")
+	bldr, err = builderFactories["googlecompute"].Create(&testAllBuildersSSH, "googlecompute")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3208,7 +3208,7 @@ func TestCreateGoogleCompute(t *testing.T) {
 		"zone":              "us-central1-a",
 	}
 
-	bldr, err = testAllBuildersWinRM.createGoogleCompute("googlecompute")
+	bldr, err = builderFactories["googlecompute"].Create(&testAllBuildersWinRM, "googlecompute")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3220,8 +3220,8 @@ func TestCreateGoogleCompute(t *testing.T) {
 
 func TestBuilderNull(t *testing.T) {
 	// a communicator of none or no communicator setting should result in an error
-	expected := "null: null: communicator: required setting not found"
-	_, err := testAllBuilders.createNull("null")
+	expected := "communicator: required setting not found"
+	_, err := builderFactories["null"].Create(&testAllBuilders, "null")
 	if err == nil {
 		t.Errorf("expected an error, got none")
 	} else {
@@ -3248,7 +3248,7 @@ func TestBuilderNull(t *testing.T) {
 		"ssh_timeout":                  "10m",
 		"type":                         "null",
 	}
-	bldr, err := testAllBuildersSSH.createNull("null")
+	bldr, err := builderFactories["null"].Create(&testAllBuildersSSH, "null")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3268,7 +3268,7 @@ func TestBuilderNull(t *testing.T) {
 		"winrm_insecure": true,
 		"type":           "null",
 	}
-	bldr, err = testAllBuildersWinRM.createNull("null")
+	bldr, err = builderFactories["null"].Create(&testAllBuildersWinRM, "null")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3310,7 +3310,7 @@ func TestCreateOpenstack(t *testing.T) {
 		"use_floating_ip": true,
 		"username":        "packer",
 	}
-	ret, err := testAllBuilders.createOpenStack("openstack1")
+	ret, err := builderFactories["openstack"].Create(&testAllBuilders, "openstack1")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3349,7 +3349,7 @@ func TestCreateOpenstack(t *testing.T) {
 		"use_floating_ip": true,
 		"username":        "packer",
 	}
-	ret, err = testAllBuilders.createOpenStack("openstack2")
+	ret, err = builderFactories["openstack"].Create(&testAllBuilders, "openstack2")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3391,7 +3391,7 @@ func TestCreateOpenstack(t *testing.T) {
 		"type":                         "openstack",
 		"use_floating_ip":              true,
 	}
-	ret, err = testAllBuildersSSH.createOpenStack("openstack")
+	ret, err = builderFactories["openstack"].Create(&testAllBuildersSSH, "openstack")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3424,7 +3424,7 @@ func TestCreateOpenstack(t *testing.T) {
 		"winrm_use_ssl":     true,
 		"winrm_insecure":    true,
 	}
-	ret, err = testAllBuildersWinRM.createOpenStack("openstack")
+	ret, err = builderFactories["openstack"].Create(&testAllBuildersWinRM, "openstack")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -3498,7 +3498,7 @@ func TestCreateParallelsISO(t *testing.T) {
 		"vm_name":             "test-iso",
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err := testAllBuilders.createParallelsISO("parallels-iso")
+	settings, err := builderFactories["parallels-iso"].Create(&testAllBuilders, "parallels-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3544,7 +3544,7 @@ func TestCreateParallelsISO(t *testing.T) {
 		"vm_name": "test-iso",
 	}
 	testAllBuildersSSH.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createParallelsISO("parallels-iso")
+	settings, err = builderFactories["parallels-iso"].Create(&testAllBuildersSSH, "parallels-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3584,7 +3584,7 @@ func TestCreateParallelsISO(t *testing.T) {
 		"winrm_insecure":             true,
 	}
 	testAllBuildersWinRM.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createParallelsISO("parallels-iso")
+	settings, err = builderFactories["parallels-iso"].Create(&testAllBuildersWinRM, "parallels-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3649,7 +3649,7 @@ func TestCreateParallelsPVM(t *testing.T) {
 		"vm_name":             "test-iso",
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err := testAllBuilders.createParallelsPVM("parallels-pvm")
+	settings, err := builderFactories["parallels-pvm"].Create(&testAllBuilders, "parallels-pvm")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3690,7 +3690,7 @@ func TestCreateParallelsPVM(t *testing.T) {
 		"vm_name":                      "test-iso",
 	}
 	testAllBuildersSSH.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createParallelsPVM("parallels-pvm")
+	settings, err = builderFactories["parallels-pvm"].Create(&testAllBuildersSSH, "parallels-pvm")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3724,7 +3724,7 @@ func TestCreateParallelsPVM(t *testing.T) {
 		"winrm_insecure":             true,
 	}
 	testAllBuildersWinRM.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createParallelsPVM("parallels-pvm")
+	settings, err = builderFactories["parallels-pvm"].Create(&testAllBuildersWinRM, "parallels-pvm")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3783,7 +3783,7 @@ func TestCreateQEMU(t *testing.T) {
 		"type":            "qemu",
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err := testAllBuilders.createQEMU("qemu")
+	settings, err := builderFactories["qemu"].Create(&testAllBuilders, "qemu")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3833,7 +3833,7 @@ func TestCreateQEMU(t *testing.T) {
 		"type":                         "qemu",
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createQEMU("qemu")
+	settings, err = builderFactories["qemu"].Create(&testAllBuildersSSH, "qemu")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3875,7 +3875,7 @@ func TestCreateQEMU(t *testing.T) {
 		"winrm_insecure":    true,
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createQEMU("qemu")
+	settings, err = builderFactories["qemu"].Create(&testAllBuildersWinRM, "qemu")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -3953,7 +3953,7 @@ func TestCreateVirtualboxISO(t *testing.T) {
 		"vm_name":                 "test-vb-iso",
 	}
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err := testAllBuilders.createVirtualBoxISO("virtualbox-iso")
+	settings, err := builderFactories["virtualbox-iso"].Create(&testAllBuilders, "virtualbox-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -4005,7 +4005,7 @@ func TestCreateVirtualboxISO(t *testing.T) {
 		"vm_name":                 "test-vb-iso",
 	}
 	testAllBuildersSSH.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createVirtualBoxISO("virtualbox-iso")
+	settings, err = builderFactories["virtualbox-iso"].Create(&testAllBuildersSSH, "virtualbox-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -4049,7 +4049,7 @@ func TestCreateVirtualboxISO(t *testing.T) {
 		"vm_name":                 "test-vb-iso",
 	}
 	testAllBuildersWinRM.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createVirtualBoxISO("virtualbox-iso")
+	settings, err = builderFactories["virtualbox-iso"].Create(&testAllBuildersWinRM, "virtualbox-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -4122,7 +4122,7 @@ func TestCreateVirtualboxOVF(t *testing.T) {
 		"vm_name":                 "test-vb-ovf",
 	}
 	testAllBuilders.Files = make(map[string]string)
-	settings, err := testAllBuilders.createVirtualBoxOVF("virtualbox-ovf")
+	settings, err := builderFactories["virtualbox-ovf"].Create(&testAllBuilders, "virtualbox-ovf")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4170,7 +4170,7 @@ func TestCreateVirtualboxOVF(t *testing.T) {
 		"vm_name":                 "test-vb-ovf",
 	}
 	testAllBuildersSSH.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createVirtualBoxOVF("virtualbox-ovf")
+	settings, err = builderFactories["virtualbox-ovf"].Create(&testAllBuildersSSH, "virtualbox-ovf")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -4209,7 +4209,7 @@ func TestCreateVirtualboxOVF(t *testing.T) {
 		"vm_name":                 "test-vb-ovf",
 	}
 	testAllBuildersWinRM.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createVirtualBoxOVF("virtualbox-ovf")
+	settings, err = builderFactories["virtualbox-ovf"].Create(&testAllBuildersWinRM, "virtualbox-ovf")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err.Error())
 	} else {
@@ -4282,7 +4282,7 @@ func TestCreateVMWareISO(t *testing.T) {
 	}
 
 	testAllBuilders.BaseURL = "http://releases.ubuntu.com/"
-	settings, err := testAllBuilders.createVMWareISO("vmware-iso")
+	settings, err := builderFactories["vmware-iso"].Create(&testAllBuilders, "vmware-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4343,7 +4343,7 @@ func TestCreateVMWareISO(t *testing.T) {
 	}
 
 	testAllBuildersSSH.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersSSH.createVMWareISO("vmware-iso")
+	settings, err = builderFactories["vmware-iso"].Create(&testAllBuildersSSH, "vmware-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4397,7 +4397,7 @@ func TestCreateVMWareISO(t *testing.T) {
 	}
 
 	testAllBuildersWinRM.BaseURL = "http://releases.ubuntu.com/"
-	settings, err = testAllBuildersWinRM.createVMWareISO("vmware-iso")
+	settings, err = builderFactories["vmware-iso"].Create(&testAllBuildersWinRM, "vmware-iso")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4444,7 +4444,7 @@ func TestCreateVMWareVMX(t *testing.T) {
 		"vnc_port_min": 5900,
 	}
 
-	settings, err := testAllBuilders.createVMWareVMX("vmware-vmx")
+	settings, err := builderFactories["vmware-vmx"].Create(&testAllBuilders, "vmware-vmx")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4486,7 +4486,7 @@ func TestCreateVMWareVMX(t *testing.T) {
 		"vnc_port_min":                 5900,
 	}
 
-	settings, err = testAllBuildersSSH.createVMWareVMX("vmware-vmx")
+	settings, err = builderFactories["vmware-vmx"].Create(&testAllBuildersSSH, "vmware-vmx")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -4521,7 +4521,7 @@ func TestCreateVMWareVMX(t *testing.T) {
 		"winrm_insecure":   true,
 	}
 
-	settings, err = testAllBuildersWinRM.createVMWareVMX("vmware-vmx")
+	settings, err = builderFactories["vmware-vmx"].Create(&testAllBuildersWinRM, "vmware-vmx")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
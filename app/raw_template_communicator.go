@@ -112,7 +112,7 @@ func (s SSH) isCommunicator() bool {
 func (s SSH) processSettings(vals []string, r *RawTemplate, settings map[string]interface{}) error {
 	for _, val := range vals {
 		k, v := parseVar(val)
-		v = r.replaceVariables(v)
+		v = r.replaceSettingVars(k, v)
 		switch k {
 		case "ssh_host":
 			settings[k] = v
@@ -129,7 +129,11 @@ func (s SSH) processSettings(vals []string, r *RawTemplate, settings map[string]
 		case "ssh_private_key_file":
 			settings[k] = v
 		case "ssh_pty":
-			settings[k], _ = strconv.ParseBool(v)
+			b, err := parseBool(v)
+			if err != nil {
+				return SettingErr{Key: k, Value: v, err: err}
+			}
+			settings[k] = b
 		case "ssh_timeout":
 			settings[k] = v
 		case "ssh_handshake_attempts":
@@ -139,7 +143,11 @@ func (s SSH) processSettings(vals []string, r *RawTemplate, settings map[string]
 			}
 			settings[k] = i
 		case "ssh_disable_agent":
-			settings[k], _ = strconv.ParseBool(v)
+			b, err := parseBool(v)
+			if err != nil {
+				return SettingErr{Key: k, Value: v, err: err}
+			}
+			settings[k] = b
 		case "ssh_bastion_host":
 			settings[k] = v
 		case "ssh_bastion_port":
@@ -190,7 +198,7 @@ func (w WinRM) isCommunicator() bool {
 func (w WinRM) processSettings(vals []string, r *RawTemplate, settings map[string]interface{}) error {
 	for _, val := range vals {
 		k, v := parseVar(val)
-		v = r.replaceVariables(v)
+		v = r.replaceSettingVars(k, v)
 		switch k {
 		case "winrm_host":
 			settings[k] = v
@@ -207,9 +215,17 @@ func (w WinRM) processSettings(vals []string, r *RawTemplate, settings map[strin
 		case "winrm_timeout":
 			settings[k] = v
 		case "winrm_use_ssl":
-			settings[k], _ = strconv.ParseBool(v)
+			b, err := parseBool(v)
+			if err != nil {
+				return SettingErr{Key: k, Value: v, err: err}
+			}
+			settings[k] = b
 		case "winrm_insecure":
-			settings[k], _ = strconv.ParseBool(v)
+			b, err := parseBool(v)
+			if err != nil {
+				return SettingErr{Key: k, Value: v, err: err}
+			}
+			settings[k] = b
 		}
 	}
 	return nil
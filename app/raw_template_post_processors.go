@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/mohae/deepcopy"
 	"github.com/mohae/feedlot/log"
 )
 
@@ -40,6 +39,11 @@ func (e PostProcessorErr) Error() string {
 // not found in the definition.
 var ErrPostProcessorNotFound = errors.New("post-processor not found")
 
+// PostProcessorSeqSep separates the IDs of the post-processors in a sequence,
+// e.g. "docker-import -> docker-tag -> docker-push".  Each post-processor in a
+// sequence runs on the artifact of the one before it.
+const PostProcessorSeqSep = "->"
+
 // PostProcessor constants
 const (
 	UnsupportedPostProcessor PostProcessor = iota
@@ -102,17 +106,21 @@ func PostProcessorFromString(s string) PostProcessor {
 // Merges the new config with the old. The updates occur as follows:
 //   * The existing configuration is used when no `new` postProcessors are
 //     specified.
-//   * When 1 or more `new` postProcessors are specified, they will replace all
-//     existing postProcessors.  In this situation, if a postProcessor exists
-//     in the `old` map but it does not exist in the `new` map, that
-//     postProcessor will be orphaned.
-//   * If there isn't a new config, the existing one is used
+//   * Each `new` postProcessor is merged with the `old` postProcessor that has
+//     the same ID; a postProcessor that only exists in one of them is used as
+//     is.
+//   * Which postProcessors are used, their order, and the sequences they are
+//     in, are set by post_processor_ids, which replaces the existing IDs when
+//     it is specified; this function doesn't change them.
 func (r *RawTemplate) updatePostProcessors(newP map[string]PostProcessorC) error {
 	// If there is nothing new, old equals merged.
 	if len(newP) == 0 || newP == nil {
 		log.Debugf("%s: update post-processors: nothing to update", r.Name)
 		return nil
 	}
+	for ID, p := range newP {
+		r.recordSectionOrigins("post_processors", ID, p.TemplateSection)
+	}
 	// Convert the existing postProcessors to Componenter.
 	oldC := DeepCopyMapStringPostProcessorC(r.PostProcessors)
 	// Convert the new postProcessors to Componenter
@@ -133,7 +141,12 @@ func (r *RawTemplate) updatePostProcessors(newP map[string]PostProcessorC) error
 			if !ok { // if the key exists in neither then something is wrong
 				return fmt.Errorf("post-processor merge failed: %s key not found in either template", v)
 			}
-			r.PostProcessors[v] = pp.Copy()
+			c := pp.Copy()
+			err := c.resolveArrays()
+			if err != nil {
+				return fmt.Errorf("post-processor %s: %s", v, err)
+			}
+			r.PostProcessors[v] = c
 			continue
 		}
 		// If the element for this key doesn't exist, skip it.
@@ -145,7 +158,11 @@ func (r *RawTemplate) updatePostProcessors(newP map[string]PostProcessorC) error
 		if err != nil {
 			return err
 		}
-		p.mergeArrays(pp.Arrays)
+		err = p.mergeArrays(pp.Arrays, pp.Merge)
+		if err != nil {
+			return fmt.Errorf("post-processor %s: %s", v, err)
+		}
+		p.mergePassthrough(pp.TemplateSection)
 		r.PostProcessors[v] = p
 		log.Debugf("%s: merge post-processors: %s", r.Name, v)
 	}
@@ -153,72 +170,23 @@ func (r *RawTemplate) updatePostProcessors(newP map[string]PostProcessorC) error
 	return nil
 }
 
-// r.createPostProcessors creates the PostProcessors for a build.
+// r.createPostProcessors creates the PostProcessors for a build.  A
+// post-processor ID that is a sequence of IDs results in a nested array of
+// post-processors, which Packer runs as a chain.
 func (r *RawTemplate) createPostProcessors() (pp []interface{}, err error) {
 	if r.PostProcessorIDs == nil || len(r.PostProcessorIDs) <= 0 {
 		log.Infof("%s: no post-processors to create", r.Name)
 		return nil, nil
 	}
-	var tmpS map[string]interface{}
+	var tmpS interface{}
 	var ndx int
 	pp = make([]interface{}, len(r.PostProcessorIDs))
 	log.Infof("%s: create %d post-processors", r.Name, len(r.PostProcessorIDs))
 	// Generate the postProcessor for each postProcessor type.
 	for _, ID := range r.PostProcessorIDs {
-		tmpPP, ok := r.PostProcessors[ID]
-		if !ok {
-			return nil, PostProcessorErr{id: ID, Err: ErrPostProcessorNotFound}
-		}
-		typ := PostProcessorFromString(tmpPP.Type)
-		switch typ {
-		case Atlas:
-			tmpS, err = r.createAtlas(ID)
-			if err != nil {
-				return nil, err
-			}
-		case Compress:
-			tmpS, err = r.createCompress(ID)
-			if err != nil {
-				return nil, err
-			}
-		case DockerImport:
-			tmpS, err = r.createDockerImport(ID)
-			if err != nil {
-				return nil, err
-			}
-		case DockerPush:
-			tmpS, err = r.createDockerPush(ID)
-			if err != nil {
-				return nil, err
-			}
-		case DockerSave:
-			tmpS, err = r.createDockerSave(ID)
-			if err != nil {
-				return nil, err
-			}
-		case DockerTag:
-			tmpS, err = r.createDockerTag(ID)
-			if err != nil {
-				return nil, err
-			}
-		case Vagrant:
-			tmpS, err = r.createVagrant(ID)
-			if err != nil {
-				return nil, err
-			}
-		case VagrantCloud:
-			// Create the settings
-			tmpS, err = r.createVagrantCloud(ID)
-			if err != nil {
-				return nil, err
-			}
-		case VSphere:
-			tmpS, err = r.createVSphere(ID)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return nil, InvalidComponentErr{cTyp: "post-processor", s: tmpPP.Type}
+		tmpS, err = r.createPostProcessorSeq(ID)
+		if err != nil {
+			return nil, err
 		}
 		pp[ndx] = tmpS
 		ndx++
@@ -227,619 +195,110 @@ func (r *RawTemplate) createPostProcessors() (pp []interface{}, err error) {
 	return pp, nil
 }
 
-// createAtlas() creates a map of settings for Packer's atlas post-processor.
-// Any values that aren't supported by the atlas post-processor are ignored.
-// For more information refer to
-// https://packer.io/docs/post-processors/compress.html
-//
-// Required configuration options:
-//   artifact      string
-//   artifact_type string
-//   token         string
-// Optional configuration options:
-//   atlas_url     string
-//   metadata      object of key/value strings
-func (r *RawTemplate) createAtlas(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Atlas, Err: ErrPostProcessorNotFound}
-
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, Atlas)
-	settings = make(map[string]interface{})
-	settings["type"] = Atlas.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasArtifact, hasArtifactType, hasToken bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		log.Debugf("%s: %s settings: %s: %s", r.Name, ID, k, v)
-		switch k {
-		case "artifact":
-			settings[k] = v
-			hasArtifact = true
-		case "artifact_type":
-			settings[k] = v
-			hasArtifactType = true
-		case "token":
-			settings[k] = v
-			hasToken = true
-		case "atlas_url":
-			settings[k] = v
-		}
-	}
-	if !hasArtifact {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Atlas, Err: RequiredSettingErr{"artifact"}}
-	}
-	if !hasArtifactType {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Atlas, Err: RequiredSettingErr{"artifact_type"}}
-	}
-	if !hasToken {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Atlas, Err: RequiredSettingErr{"token"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "metadata":
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
-	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, Atlas)
-	return settings, nil
-}
-
-// createCompress() creates a map of settings for Packer's compress
-// post-processor.  Any values that aren't supported by the compress
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/compress.html
-//
-// Required configuration options:
-//   none
-// Optional configuration options:
-//   compression_level    int
-//   keep_input_artifact  bool
-//   output               string
-func (r *RawTemplate) createCompress(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Compress, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, Compress)
-	settings = make(map[string]interface{})
-	settings["type"] = Compress.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "compression_level":
-			i, err := strconv.Atoi(v)
-			if err != nil {
-				return nil, PostProcessorErr{id: ID, PostProcessor: Compress, Err: SettingErr{k, v, err}}
-			}
-			settings[k] = i
-		case "keep_input_artifact":
-			// Invalid values are treated as false so the error is
-			// ignored.
-			settings[k], _ = strconv.ParseBool(v)
-		case "output":
-			settings[k] = v
-		}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
-	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, Compress)
-	return settings, nil
-}
-
-// createDockerImport() creates a map of settings for Packer's docker-import
-// post-processor.  Any values that aren't supported by the docker-import
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/docker-import.html.
-//
-// Required configuration options:
-//   repository  string
-//   tag         string
-// Optional configuration options:
-//   none
-func (r *RawTemplate) createDockerImport(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerImport, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, DockerImport)
-	settings = make(map[string]interface{})
-	settings["type"] = DockerImport.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasRepository, hasTag bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "repository":
-			settings[k] = v
-			hasRepository = true
-		case "tag":
-			settings[k] = v
-			hasTag = true
-		}
-	}
-	if !hasRepository {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerImport, Err: RequiredSettingErr{"repository"}}
-	}
-	if !hasTag {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerImport, Err: RequiredSettingErr{"tag"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
-	}
-	log.Infof("%s: created post-processor id: %s: %s", r.Name, ID, DockerImport)
-	return settings, nil
+// postProcessorSeq returns the IDs of the post-processors in a
+// post_processor_ids entry.  Unless the entry is a sequence, its ID is the
+// only one.
+func postProcessorSeq(s string) []string {
+	IDs := strings.Split(s, PostProcessorSeqSep)
+	for i := range IDs {
+		IDs[i] = strings.TrimSpace(IDs[i])
+	}
+	return IDs
 }
 
-// createDockerPush() creates a map of settings for Packer's docker-push
-// post-processor.  Any values that aren't supported by the docker-push
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/docker-push.html.
-//
-// Required configuration options:
-//   none
-// Optional configuration options:
-//   login           bool
-//   login_email     string
-//   login_username  string
-//   login_password  string
-//   login_server    string
-func (r *RawTemplate) createDockerPush(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerPush, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, DockerPush)
-	settings = make(map[string]interface{})
-	settings["type"] = DockerPush.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "login_email", "login_username", "login_password", "login_server":
-			settings[k] = v
-		case "login":
-			settings[k], _ = strconv.ParseBool(v)
-		}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
+// createPostProcessorSeq creates the post-processor for a post_processor_ids
+// entry.  If the entry is a sequence, the post-processors are returned as
+// a []interface{}, in order.  Each step of a sequence uses its own settings,
+// including keep_input_artifact.
+func (r *RawTemplate) createPostProcessorSeq(s string) (interface{}, error) {
+	IDs := postProcessorSeq(s)
+	if len(IDs) == 1 {
+		return r.createPostProcessor(IDs[0])
+	}
+	seq := make([]interface{}, 0, len(IDs))
+	for _, ID := range IDs {
+		if ID == "" {
+			return nil, PostProcessorErr{id: s, Err: errors.New("sequence has an empty post-processor ID")}
+		}
+		pp, err := r.createPostProcessor(ID)
+		if err != nil {
+			return nil, err
 		}
+		seq = append(seq, pp)
 	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, DockerPush)
-	return settings, nil
+	return seq, nil
 }
 
-// createDockerSave() creates a map of settings for Packer's docker-save
-// post-processor.  Any values that aren't supported by the docker-save
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/docker-save.html.
-//
-// Required configuration options:
-//   path  // string
-// Optional configuration options:
-//   none
-func (r *RawTemplate) createDockerSave(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
+// createPostProcessor creates the settings for the post-processor with the
+// passed ID.  The post-processor's only and except settings are validated
+// against the build's builders.
+func (r *RawTemplate) createPostProcessor(ID string) (map[string]interface{}, error) {
+	tmpPP, ok := r.PostProcessors[ID]
 	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerSave, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, DockerSave)
-	settings = make(map[string]interface{})
-	settings["type"] = DockerSave.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasPath bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "path":
-			settings[k] = v
-			hasPath = true
-		}
-	}
-	if !hasPath {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerPush, Err: RequiredSettingErr{"path"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
+		return nil, PostProcessorErr{id: ID, Err: ErrPostProcessorNotFound}
+	}
+	var settings map[string]interface{}
+	var err error
+	// every problem with the settings is collected, not just the first.
+	var errs []error
+	r.varErrs = nil
+	typ := PostProcessorFromString(tmpPP.Type)
+	f, ok := postProcessorFactories[strings.ToLower(tmpPP.Type)]
+	if tmpPP.Passthrough {
+		settings, err = r.createPassthrough(tmpPP.TemplateSection)
+		if err != nil {
+			return nil, PostProcessorErr{id: ID, PostProcessor: typ, Err: err}
 		}
+		goto created
 	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, DockerSave)
-	return settings, nil
-}
-
-// createDockerTag() creates a map of settings for Packer's docker-tag
-// post-processor.  Any values that aren't supported by the docker-tag
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/docker-tag.html.
-//
-// Required configuration options:
-//   repository  string
-// Optional configuration options:
-//   force       bool
-//   tag         string
-func (r *RawTemplate) createDockerTag(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
 	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerTag, Err: ErrPostProcessorNotFound}
+		return nil, InvalidComponentErr{cTyp: "post-processor", s: tmpPP.Type}
 	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, DockerTag)
-	settings = make(map[string]interface{})
-	settings["type"] = DockerTag.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasRepository bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "repository":
-			settings[k] = v
-			hasRepository = true
-		case "force":
-			// Invalid values are treated as false so the error is
-			// ignored.
-			settings[k], _ = strconv.ParseBool(v)
-		case "tag":
-			settings[k] = v
+	settings, err = f.Create(r, ID)
+	if err != nil {
+		if _, ok := err.(PostProcessorErr); ok {
+			return nil, err
 		}
+		errs = errList(err)
 	}
-	if !hasRepository {
-		return nil, PostProcessorErr{id: ID, PostProcessor: DockerTag, Err: RequiredSettingErr{"repository"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
-	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, DockerTag)
-	return settings, nil
-}
-
-// createVagrant() creates a map of settings for Packer's Vagrant
-// post-processor.  Any values that aren't supported by the Vagrant
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/vagrant.html.
-//
-// Required configuration options:
-//   none
-// Optional configuration options:
-//   compression_level     int
-//   include               array of strings
-//   keep_input_artifact   bool
-//   output                string
-//   vagrantfile_template  string
-// Provider-Specific Overrides:
-//   override	           array of strings
-func (r *RawTemplate) createVagrant(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: Vagrant, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, Vagrant)
-	settings = make(map[string]interface{})
-	settings["type"] = Vagrant.String()
-	// For each value, extract its key value pair and then process. Only process the supported keys.
-	// Key validation isn't done here, leaving that for Packer.
-	var k, v string
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "output":
-			settings[k] = v
-		case "keep_input_artifact":
-			settings[k], _ = strconv.ParseBool(v)
-		case "compression_level":
-			// only add if its an int
-			i, err := strconv.Atoi(v)
-			if err != nil {
-				return nil, PostProcessorErr{id: ID, PostProcessor: Vagrant, Err: SettingErr{k, v, err}}
+	// only the settings of the components whose factory lists them are
+	// checked.
+	if l, ok := f.(SettingsLister); ok {
+		for _, e := range unknownSettings(tmpPP.keys(), l.Settings(), postProcessorCommonSettings) {
+			if r.unknownSetting(PostProcessorErr{id: ID, PostProcessor: typ, Err: e}) != nil {
+				errs = append(errs, e)
 			}
-			settings[k] = i
-		case "vagrantfile_template":
-			// locate the file
-			src, err := r.findSource(v, Vagrant.String(), false)
-			if err != nil {
-				return nil, PostProcessorErr{id: ID, PostProcessor: Vagrant, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(Vagrant.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(Vagrant.String(), v, false)
 		}
 	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		case "override":
-		case "include":
-		default:
-			continue
-		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
+created:
+	filters, err := r.builderFilters(tmpPP.Arrays, false)
+	if err != nil {
+		errs = append(errs, err)
 	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, Vagrant)
-	return settings, nil
-}
-
-// createVagrantCloud() creates a map of settings for Packer's Vagrant-Cloud
-// post-processor.  Any values that aren't supported by the Vagrant-Cloud
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/vagrant-cloud.html
-//
-// Required configuration options:
-//   access_token         string
-//   box_tag              string
-//   version              string
-// Optional configuration options
-//   no_release           string
-//   vagrant_cloud_url    string
-//   version_description  string
-//   box_download_url     string
-func (r *RawTemplate) createVagrantCloud(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VagrantCloud, Err: ErrPostProcessorNotFound}
+	errs = append(errs, r.varErrors()...)
+	if len(errs) > 0 {
+		return nil, PostProcessorErr{id: ID, PostProcessor: typ, Err: settingErrs(errs)}
 	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, VagrantCloud)
-	settings = make(map[string]interface{})
-	settings["type"] = VagrantCloud.String()
-	var hasAccessToken, hasBoxTag, hasVersion bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "access_token":
-			settings[k] = v
-			hasAccessToken = true
-		case "box_tag":
-			settings[k] = v
-			hasBoxTag = true
-		case "version":
-			settings[k] = v
-			hasVersion = true
-		case "box_download_url", "no_release", "vagrant_cloud_url", "version_description":
-			settings[k] = v
-		}
-	}
-	if !hasAccessToken {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VagrantCloud, Err: RequiredSettingErr{"access_token"}}
-	}
-	if !hasBoxTag {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VagrantCloud, Err: RequiredSettingErr{"box_tag"}}
+	for k, v := range filters {
+		settings[k] = v
 	}
-	if !hasVersion {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VagrantCloud, Err: RequiredSettingErr{"version"}}
+	// keep_input_artifact applies to every post-processor; set it for the
+	// ones whose factory doesn't.
+	if _, ok := settings["keep_input_artifact"]; ok {
+		return settings, nil
 	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
+	for _, s := range tmpPP.Settings {
+		k, v := parseVar(s)
+		if k != "keep_input_artifact" {
 			continue
 		}
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
-		}
-	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, VagrantCloud)
-	return settings, nil
-}
-
-// createVSphere() creates a map of settings for Packer's vSphere
-// post-processor.  Any values that aren't supported by the vSphere
-// post-processor are ignored. For more information refer to
-// https://packer.io/docs/post-processors/vsphere.html.
-//
-// Required configuration options:
-//   cluster         string
-//   datacenter      string
-//   datastore*      string
-//   host            string
-//   password        string
-//   username        string
-//   vm_name         string
-// Optional configuration options:
-//   disk_mode       string
-//   insecure        bool
-//   resource_pool   string
-//   vm_folder       string
-//   vm_network      string
-//
-// Notes:
-//   * datastore is not required if resource_pool is specified
-func (r *RawTemplate) createVSphere(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.PostProcessors[ID]
-	if !ok {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: ErrPostProcessorNotFound}
-	}
-	log.Infof("%s: create post-processor: %s: %s", r.Name, ID, VSphere)
-	settings = make(map[string]interface{})
-	settings["type"] = VSphere.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasCluster, hasDatacenter, hasDatastore, hasHost, hasPassword, hasResourcePool, hasUsername, hasVMName bool
-	for _, s := range r.PostProcessors[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
 		v = r.replaceVariables(v)
-		switch k {
-		case "cluster":
-			settings[k] = v
-			hasCluster = true
-		case "datacenter":
-			settings[k] = v
-			hasDatacenter = true
-		case "datastore":
-			settings[k] = v
-			hasDatastore = true
-		case "host":
-			settings[k] = v
-			hasHost = true
-		case "password":
-			settings[k] = v
-			hasPassword = true
-		case "resource_pool":
-			settings[k] = v
-			hasResourcePool = true
-		case "username":
-			settings[k] = v
-			hasUsername = true
-		case "vm_name":
-			settings[k] = v
-			hasVMName = true
-		case "disk_mode", "vm_folder", "vm_network":
-			settings[k] = v
-		case "insecure":
-			settings[k], _ = strconv.ParseBool(v)
-		}
-	}
-	if !hasCluster {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"cluster"}}
-	}
-	if !hasDatacenter {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"datacenter"}}
-	}
-	if !hasDatastore {
-		if !hasResourcePool {
-			return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"datastore/resource_pool"}}
-		}
-	}
-	if !hasHost {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"host"}}
-	}
-	if !hasPassword {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"password"}}
-	}
-	if !hasUsername {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"username"}}
-	}
-	if !hasVMName {
-		return nil, PostProcessorErr{id: ID, PostProcessor: VSphere, Err: RequiredSettingErr{"vm_name"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.PostProcessors[ID].Arrays {
-		switch name {
-		case "except":
-		case "only":
-		default:
-			continue
-		}
-		array := deepcopy.Copy(val)
-		if array != nil {
-			settings[name] = array
+		b, err := parseBool(v)
+		if err != nil {
+			return nil, PostProcessorErr{id: ID, PostProcessor: typ, Err: SettingErr{k, v, err}}
 		}
+		settings[k] = b
 	}
-	log.Infof("%s: created post-processor: %s: %s", r.Name, ID, VSphere)
 	return settings, nil
 }
 
@@ -857,7 +316,7 @@ func (p *PostProcessorC) settingsToMap(Type string, r *RawTemplate) map[string]i
 		case "keep_input_artifact":
 			v, _ = strconv.ParseBool(v.(string))
 		default:
-			v = r.replaceVariables(v.(string))
+			v = r.replaceSettingVars(k, v.(string))
 		}
 		m[k] = v
 	}
@@ -342,7 +342,7 @@ func TestAtlasPostProcessor(t *testing.T) {
 		"token": "{{user `atlas_token`}}",
 		"type":  "atlas",
 	}
-	pp, err := testPostProcessorsAllTemplate.createAtlas("atlas")
+	pp, err := postProcessorFactories["atlas"].Create(testPostProcessorsAllTemplate, "atlas")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -358,7 +358,7 @@ func TestCompressPostProcessor(t *testing.T) {
 		"type":   "compress",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createCompress("compress")
+	pp, err := postProcessorFactories["compress"].Create(testPostProcessorsAllTemplate, "compress")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -378,7 +378,7 @@ func TestDockerPushPostProcessor(t *testing.T) {
 		"type":           "docker-push",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createDockerPush("docker-push")
+	pp, err := postProcessorFactories["docker-push"].Create(testPostProcessorsAllTemplate, "docker-push")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -394,7 +394,7 @@ func TestDockerSavePostProcessor(t *testing.T) {
 		"type": "docker-save",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createDockerSave("docker-save")
+	pp, err := postProcessorFactories["docker-save"].Create(testPostProcessorsAllTemplate, "docker-save")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -411,7 +411,7 @@ func TestDockerTagPostProcessor(t *testing.T) {
 		"type":       "docker-tag",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createDockerTag("docker-tag")
+	pp, err := postProcessorFactories["docker-tag"].Create(testPostProcessorsAllTemplate, "docker-tag")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -437,7 +437,7 @@ func TestVagrantPostProcessor(t *testing.T) {
 		"vagrantfile_template": "template/VagrantFile.template",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createVagrant("vagrant")
+	pp, err := postProcessorFactories["vagrant"].Create(testPostProcessorsAllTemplate, "vagrant")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -459,7 +459,7 @@ func TestVagrantCloudPostProcessor(t *testing.T) {
 		"version_description": "initial",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createVagrantCloud("vagrant-cloud")
+	pp, err := postProcessorFactories["vagrant-cloud"].Create(testPostProcessorsAllTemplate, "vagrant-cloud")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -486,7 +486,7 @@ func TestVagrantVSphereProcessor(t *testing.T) {
 		"vm_network":    "vm-network",
 	}
 
-	pp, err := testPostProcessorsAllTemplate.createVSphere("vsphere")
+	pp, err := postProcessorFactories["vsphere"].Create(testPostProcessorsAllTemplate, "vsphere")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -507,3 +507,81 @@ func TestDeepCopyMapStringPostProcessor(t *testing.T) {
 		t.Error(msg)
 	}
 }
+
+func TestPostProcessorSeq(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{"docker-tag", []string{"docker-tag"}},
+		{"docker-import -> docker-tag -> docker-push", []string{"docker-import", "docker-tag", "docker-push"}},
+		{"docker-import->docker-push", []string{"docker-import", "docker-push"}},
+		{"docker-import -> ", []string{"docker-import", ""}},
+	}
+	for i, test := range tests {
+		IDs := postProcessorSeq(test.s)
+		if !reflect.DeepEqual(IDs, test.expected) {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, IDs)
+		}
+	}
+}
+
+func TestCreatePostProcessorsSequence(t *testing.T) {
+	r := newRawTemplate()
+	r.Name = "test"
+	r.PostProcessorIDs = []string{"docker-import -> tag -> docker-push", "compress"}
+	r.PostProcessors = map[string]PostProcessorC{
+		"compress": {TemplateSection{Type: "compress", Settings: []string{"output = foo.tar.gz"}}},
+		"docker-import": {TemplateSection{
+			Type:     "docker-import",
+			Settings: []string{"repository = mitchellh/packer", "tag = 0.7"},
+		}},
+		"docker-push": {TemplateSection{Type: "docker-push"}},
+		"tag": {TemplateSection{
+			Type:     "docker-tag",
+			Settings: []string{"repository = mitchellh/packer", "tag = 0.7", "keep_input_artifact = true"},
+		}},
+	}
+	expected := []interface{}{
+		[]interface{}{
+			map[string]interface{}{"type": "docker-import", "repository": "mitchellh/packer", "tag": "0.7"},
+			map[string]interface{}{"type": "docker-tag", "repository": "mitchellh/packer", "tag": "0.7", "keep_input_artifact": true},
+			map[string]interface{}{"type": "docker-push"},
+		},
+		map[string]interface{}{"type": "compress", "output": "foo.tar.gz"},
+	}
+	pp, err := r.createPostProcessors()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if !reflect.DeepEqual(pp, expected) {
+		t.Errorf("expected %#v, got %#v", expected, pp)
+	}
+	tests := []struct {
+		IDs []string
+		err string
+	}{
+		{[]string{"docker-import -> -> docker-push"}, "docker-import -> -> docker-push: sequence has an empty post-processor ID"},
+		{[]string{"docker-import -> docker-save"}, "docker-save: post-processor not found"},
+	}
+	for i, test := range tests {
+		r.PostProcessorIDs = test.IDs
+		_, err = r.createPostProcessors()
+		if err == nil {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("%d: expected %q, got %q", i, test.err, err)
+		}
+	}
+	r.PostProcessorIDs = []string{"tag"}
+	r.PostProcessors["tag"] = PostProcessorC{TemplateSection{
+		Type:     "docker-tag",
+		Settings: []string{"tag = 0.7"},
+	}}
+	_, err = r.createPostProcessors()
+	if err == nil {
+		t.Error("expected an error, got none")
+	}
+}
@@ -3,12 +3,10 @@ package app
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
-	"github.com/mohae/deepcopy"
 	"github.com/mohae/feedlot/log"
 )
 
@@ -118,64 +116,9 @@ func (r *RawTemplate) createProvisioners() (p []interface{}, err error) {
 	p = make([]interface{}, len(r.ProvisionerIDs))
 	// Generate the provisioners for each provisioners ID.
 	for _, ID := range r.ProvisionerIDs {
-		tmpP, ok := r.Provisioners[ID]
-		if !ok {
-			return nil, ProvisionerErr{id: ID, Err: ErrProvisionerNotFound}
-		}
-		typ := ParseProvisioner(tmpP.Type)
-		switch typ {
-		case Ansible:
-			tmpS, err = r.createAnsible(ID)
-			if err != nil {
-				return nil, err
-			}
-		case AnsibleLocal:
-			tmpS, err = r.createAnsibleLocal(ID)
-			if err != nil {
-				return nil, err
-			}
-		case ChefClient:
-			tmpS, err = r.createChefClient(ID)
-			if err != nil {
-				return nil, err
-			}
-		case ChefSolo:
-			tmpS, err = r.createChefSolo(ID)
-			if err != nil {
-				return nil, err
-			}
-		case File:
-			tmpS, err = r.createFile(ID)
-			if err != nil {
-				return nil, err
-			}
-		case PuppetMasterless:
-			tmpS, err = r.createPuppetMasterless(ID)
-			if err != nil {
-				return nil, err
-			}
-		case PuppetServer:
-			tmpS, err = r.createPuppetServer(ID)
-			if err != nil {
-				return nil, err
-			}
-		case Salt:
-			tmpS, err = r.createSalt(ID)
-			if err != nil {
-				return nil, err
-			}
-		case Shell:
-			tmpS, err = r.createShell(ID)
-			if err != nil {
-				return nil, err
-			}
-		case ShellLocal:
-			tmpS, err = r.createShellLocal(ID)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return nil, InvalidComponentErr{cTyp: "provisioner", s: tmpP.Type}
+		tmpS, err = r.createProvisioner(ID)
+		if err != nil {
+			return nil, err
 		}
 		p[ndx] = tmpS
 		ndx++
@@ -184,1035 +127,78 @@ func (r *RawTemplate) createProvisioners() (p []interface{}, err error) {
 	return p, nil
 }
 
-// createAnsible creates a map of settings for Packer's ansible provisioner.
-//  Any values that aren't supported by the file provisioner are ignored. For
-// more information, refer to
-// https://packer.io/docs/provisioners/ansible-local.html
-//
-// Required configuration options:
-//   playbook_file            string
-// Optional configuration options:
-//   ansible_env_vars         array of strings
-//   empty_groups             array of strings
-//   extra_arguments          array of strings
-//   groups                   array of strings
-//   host_alias               string
-//   local_port               string
-//   sftp_command             string
-//   ssh_authorized_key_file  string
-//   ssh_host_key_file        string
-//   user                     string
-func (r *RawTemplate) createAnsible(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: Ansible, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, Ansible)
-	settings = make(map[string]interface{})
-	settings["type"] = Ansible.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasPlaybook bool
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "playbook_file":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, Ansible.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Ansible, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[filepath.Join(r.TemplateOutputDir, Ansible.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(Ansible.String(), v, false)
-			hasPlaybook = true
-		case "sftp_command":
-			if !stringIsCommandFilename(v) {
-				// The value is the command.
-				settings[k] = v
-				continue
-			}
-			// The value is a command file, load the contents of the file.
-			cmds, err := r.commandsFromFile(v, Ansible.String())
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Ansible, Err: SettingErr{k, v, err}}
-			}
-			// Make the cmds slice a single string, if it was split into multiple lines.
-			cmd := commandFromSlice(cmds)
-			if cmd == "" {
-				return nil, ProvisionerErr{id: ID, Provisioner: Ansible, Err: SettingErr{k, v, ErrNoCommands}}
-			}
-			settings[k] = cmd
-		case "host_alias", "local_port", "ssh_authorized_key_file", "ssh_host_key_file", "user":
-			settings[k] = v
-		}
-	}
-	if !hasPlaybook {
-		return nil, ProvisionerErr{id: ID, Provisioner: Ansible, Err: RequiredSettingErr{"playbook_file"}}
-	}
-	// Process the Arrays.
-	for name, v := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, v)
-		if name == "ansible_env_vars" || name == "empty_groups" || name == "extra_arguments" || name == "groups" {
-			array := deepcopy.Copy(v).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-			continue
-		}
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(v).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, Ansible)
-	return settings, nil
-}
-
-// createAnsibleLocal creates a map of settings for Packer's ansible
-// provisioner.  Any values that aren't supported by the file provisioner
-// are ignored. For more information, refer to
-// https://packer.io/docs/provisioners/ansible-local.html
-//
-// Required configuration options:
-//   playbook_file      string
-// Optional configuration options:
-//   command            string
-//   extra_arguments    array of strings
-//   group_vars         string
-//   host_vars          string
-//   inventory_groups   string
-//   inventory_file     string
-//   playbook_dir	    string
-//   playbook_paths     array of strings
-//   role_paths         array of strings
-//   staging_directory  string
-func (r *RawTemplate) createAnsibleLocal(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, AnsibleLocal)
-	settings = make(map[string]interface{})
-	settings["type"] = AnsibleLocal.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	var k, v string
-	var hasPlaybook bool
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "playbook_file":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, AnsibleLocal.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[filepath.Join(r.TemplateOutputDir, AnsibleLocal.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(AnsibleLocal.String(), v, false)
-			hasPlaybook = true
-		case "inventory_file":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, AnsibleLocal.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(AnsibleLocal.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(AnsibleLocal.String(), v, false)
-		case "playbook_dir", "host_vars", "group_vars":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, AnsibleLocal.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Dirs[r.buildOutPath(AnsibleLocal.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(AnsibleLocal.String(), v, false)
-		case "command", "staging_directory", "inventory_groups":
-			settings[k] = v
-		}
-	}
-	if !hasPlaybook {
-		return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: RequiredSettingErr{"playbook_file"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "playbook_paths" || name == "role_paths" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			for i, v := range array {
-				v = r.replaceVariables(v)
-				src, err := r.findSource(v, AnsibleLocal.String(), true)
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: AnsibleLocal, Err: SettingErr{k, v, err}}
-				}
-				// if the source couldn't be found and an error wasn't generated, replace
-				// s with the original value; this occurs when it is an example.
-				// Nothing should be copied in this instancel it should not be added
-				// to the copy info
-				if src != "" {
-					r.Files[r.buildOutPath(AnsibleLocal.String(), v)] = src
-				}
-				array[i] = r.buildTemplateResourcePath(AnsibleLocal.String(), v, false)
-			}
-			settings[name] = array
-			continue
-		}
-		// extra_arguments
-		if name == "extra_arguments" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-			continue
-		}
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, AnsibleLocal)
-	return settings, nil
-}
-
-// createChefClient creates a map of settings for Packer's chef-client
-// provisioner.  Any values that aren't supported by the chef-client
-// provisioner are ignored. For more information, refer to:
-// https://www.packer.io/docs/provisioners/chef-client.html
-//
-// Required configuration options:
-//   none
-// Optional configuraiton options:
-//   chef_environment               string
-//   client_key                     string
-//   config_template                string
-//   encrypted_data_bag_secret_path string
-//   execute_command                string
-//   guest_os_type                  string
-//   install_command                string
-//   node_name                      string
-//   prevent_sudo                   bool
-//   run_list                       array of strings
-//   server_url                     string
-//   skip_clean_client              bool
-//   skip_clean_node                bool
-//   skip_install                   bool
-//   ssl_verify_mode                string
-//   staging_directory              string
-//   validation_client_name         string
-//   validation_key_path            string
-// Unsopported configuration options:
-//   json                           object
-func (r *RawTemplate) createChefClient(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ChefClient.String()]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: ChefClient, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, ChefClient)
-	settings = make(map[string]interface{})
-	settings["type"] = ChefClient.String()
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "chef_environment", "client_key", "encrypted_data_bag_secret_path", "guest_os_type",
-			"node_name", "server_url", "ssl_verify_mode", "staging_directory",
-			"validation_client_name", "validation_key_path":
-			settings[k] = v
-		case "prevent_sudo", "skip_clean_client", "skip_clean_node", "skip_install":
-			settings[k], _ = strconv.ParseBool(v)
-		case "config_template":
-			// find the actual location of the source file and add it to the files map for copying
-			src, err := r.findSource(v, ChefClient.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: ChefClient, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(ChefClient.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(ChefClient.String(), v, false)
-		case "execute_command", "install_command":
-			// if the value ends with .command, find the referenced command file and use its
-			// contents as the command, otherwise just use the value
-			if strings.HasSuffix(v, ".command") {
-				commands, err := r.commandsFromFile(v, ChefClient.String())
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: ChefClient, Err: SettingErr{k, v, err}}
-				}
-				if len(commands) == 0 {
-					return nil, ProvisionerErr{id: ID, Provisioner: ChefClient, Err: SettingErr{k, v, ErrNoCommands}}
-				}
-				settings[k] = commands[0]
-				continue
-			}
-			settings[k] = v
-		}
-	}
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "run_list" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			settings[name] = array
-			continue
-		}
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, ChefClient)
-	return settings, nil
-}
-
-// createChefSolo creates a map of settings for Packer's chef-solo provisioner.
-// Any values that aren't supported by the chef-solo provisioner are ignored.
-//  For more information, refer to
-// https://www.packer.io/docs/provisioners/chef-solo.html
-//
-// Required configuration options:
-//   none
-// Optional configuraiton options:
-//   chef_environment                string
-//   config_template                 string
-//   cookbook_paths                  array of strings
-//   data_bags_path                  string
-//   encrypted_data_bag_secret_path  string
-//   environments_path               string
-//   execute_command                 string
-//   guest_os_type                   string
-//   install_command                 string
-//   prevent_sudo                    bool
-//   remote_cookbook_paths           array of strings
-//   roles_path                      string
-//   run_list                        array of strings
-//   skip_install                    bool
-//   staging_directory               string
-// Unsopported configuration options:
-//   json                            object
-func (r *RawTemplate) createChefSolo(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
+// createProvisioner creates the settings for the provisioner with the passed
+// ID.  The provisioner's only, except, and override settings are validated
+// against the build's builders.
+func (r *RawTemplate) createProvisioner(ID string) (map[string]interface{}, error) {
+	tmpP, ok := r.Provisioners[ID]
 	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, ChefSolo)
-	settings = make(map[string]interface{})
-	settings["type"] = ChefSolo.String()
-	// For each value, extract its key value pair and then process. Only
-	// process the supported keys. Key validation isn't done here, leaving
-	// that for Packer.
-	for _, s := range r.Provisioners[ChefSolo.String()].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "chef_environment", "encrypted_data_bag_secret_path", "guest_os_type",
-			"staging_directory":
-			settings[k] = v
-		case "prevent_sudo", "skip_install":
-			settings[k], _ = strconv.ParseBool(v)
-		case "config_template":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, ChefSolo.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(ChefSolo.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(ChefSolo.String(), v, false)
-		case "data_bags_path", "environments_path", "roles_path":
-			src, err := r.findSource(v, ChefSolo.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Dirs[r.buildOutPath(ChefSolo.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(ChefSolo.String(), v, false)
-		case "execute_command", "install_command":
-			// if the value ends with .command, find the referenced command file and use its
-			// contents as the command, otherwise just use the value
-			if strings.HasSuffix(v, ".command") {
-				commands, err := r.commandsFromFile(v, ChefSolo.String())
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: SettingErr{k, v, err}}
-				}
-				if len(commands) == 0 {
-					return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: SettingErr{k, v, ErrNoCommands}}
-				}
-				settings[k] = commands[0]
-				continue
-			}
-			settings[k] = v
-		}
-	}
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "cookbook_paths" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			for i, v := range array {
-				v = r.replaceVariables(v)
-				// find the actual location and add it to the files map for copying
-				src, err := r.findSource(v, ChefSolo.String(), true)
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: ChefSolo, Err: SettingErr{name, v, err}}
-				}
-				// if the source couldn't be found and an error wasn't generated, replace
-				// s with the original value; this occurs when it is an example.
-				// Nothing should be copied in this instancel it should not be added
-				// to the copy info
-				if src != "" {
-					r.Dirs[r.buildOutPath(ChefSolo.String(), v)] = src
-				}
-				array[i] = r.buildTemplateResourcePath(ChefSolo.String(), v, false)
-			}
-			settings[name] = array
-			continue
-		}
-		if name == "run_list" || name == "remote_cookbook_paths" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			settings[name] = array
-			continue
-		}
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, ChefSolo)
-	return settings, nil
-}
-
-// createFile creates a map of settings for Packer's file provisioner. Any
-// values that aren't supported by the file provisioner are ignored. For
-// more information, refer to https://packer.io/docs/provisioners/file.html
-//
-// Required configuration options:
-//   destination  string
-//   source       string
-// Optional configuraiton options:
-//   direction    string
-func (r *RawTemplate) createFile(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: File, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, File)
-	settings = make(map[string]interface{})
-	settings["type"] = File.String()
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	var k, v string
-	var hasSource, hasDestination bool
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "source":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, File.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: File, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instance it should not be added
-			// to the copy info
-			var isDir bool // used to track if the path is a dir
-			if src != "" {
-				// see if this is a dir
-				inf, err := os.Stat(src)
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: File, Err: SettingErr{k, v, err}}
-				}
-				if inf.IsDir() {
-					isDir = true
-					r.Dirs[r.buildOutPath(File.String(), v)] = src
-				} else {
-					r.Files[r.buildOutPath(File.String(), v)] = src
-				}
-			}
-			settings[k] = r.buildTemplateResourcePath(File.String(), v, isDir)
-			hasSource = true
-		case "destination":
-			settings[k] = v
-			hasDestination = true
-		}
-	}
-	if !hasSource {
-		return nil, ProvisionerErr{id: ID, Provisioner: File, Err: RequiredSettingErr{"source"}}
-	}
-	if !hasDestination {
-		return nil, ProvisionerErr{id: ID, Provisioner: File, Err: RequiredSettingErr{"destination"}}
-	}
-	// Process the Arrays.
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
+		return nil, ProvisionerErr{id: ID, Err: ErrProvisionerNotFound}
+	}
+	var settings map[string]interface{}
+	var err error
+	// every problem with the settings is collected, not just the first.
+	var errs []error
+	r.varErrs = nil
+	typ := ParseProvisioner(tmpP.Type)
+	f, ok := provisionerFactories[strings.ToLower(tmpP.Type)]
+	if tmpP.Passthrough {
+		settings, err = r.createPassthrough(tmpP.TemplateSection)
+		if err != nil {
+			return nil, ProvisionerErr{id: ID, Provisioner: typ, Err: err}
 		}
+		goto created
 	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, File)
-	return settings, nil
-}
-
-// createPuppetMasterless creates a map of settings for Packer's puppet-client
-// provisioner.  Any values that aren't supported by the puppet-client
-// provisioner are ignored. For more information, refer to:
-// https://www.packer.io/docs/provisioners/chef-client.html
-//
-// Required configuration options:
-//   manifest_file
-// Optional configuraiton options:
-//   execute_command    string
-//   extra_arguments    array of strings
-//   facter             object, string key and values
-//   hiera_config_path  string
-//   ignore_exit_codes  bool
-//   manifest_dir       string
-//   module_paths       array of strings
-//   prevent_sudo       bool
-//   staging_directroy  string
-//   working_directory  string
-func (r *RawTemplate) createPuppetMasterless(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
 	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: ErrProvisionerNotFound}
+		return nil, InvalidComponentErr{cTyp: "provisioner", s: tmpP.Type}
 	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, PuppetMasterless)
-	settings = make(map[string]interface{})
-	settings["type"] = PuppetMasterless.String()
-	var hasManifestFile bool
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "manifest_file":
-			src, err := r.findSource(v, PuppetMasterless.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(PuppetMasterless.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(PuppetMasterless.String(), v, false)
-			hasManifestFile = true
-		case "staging_directory", "working_directory":
-			settings[k] = v
-		case "ignore_exit_codes", "prevent_sudo":
-			settings[k], _ = strconv.ParseBool(v)
-		case "hiera_config_path":
-			// find the actual location of the source file and add it to the files map for copying
-			src, err := r.findSource(v, PuppetMasterless.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(PuppetMasterless.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(PuppetMasterless.String(), v, false)
-		case "manifest_dir":
-			// find the actual location of the directory and add it to the dir map for copying contents
-			src, err := r.findSource(v, PuppetMasterless.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Dirs[r.buildOutPath(PuppetMasterless.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(PuppetMasterless.String(), v, false)
-		case "execute_command":
-			// if the value ends with .command, find the referenced command file and use its
-			// contents as the command, otherwise just use the value
-			if strings.HasSuffix(v, ".command") {
-				commands, err := r.commandsFromFile(v, PuppetMasterless.String())
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: SettingErr{k, v, err}}
-				}
-				if len(commands) == 0 {
-					return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: SettingErr{k, v, ErrNoCommands}}
-				}
-				settings[k] = commands[0]
-				continue
-			}
-			settings[k] = v
+	settings, err = f.Create(r, ID)
+	if err != nil {
+		if _, ok := err.(ProvisionerErr); ok {
+			return nil, err
 		}
+		errs = errList(err)
 	}
-	if !hasManifestFile {
-		return nil, ProvisionerErr{id: ID, Provisioner: PuppetMasterless, Err: RequiredSettingErr{"manifest_file"}}
-	}
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		switch name {
-		case "extra_arguments", "module_paths", "only", "except":
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
+	// only the settings of the components whose factory lists them are
+	// checked.
+	if l, ok := f.(SettingsLister); ok {
+		for _, e := range unknownSettings(tmpP.keys(), l.Settings(), provisionerCommonSettings) {
+			if r.unknownSetting(ProvisionerErr{id: ID, Provisioner: typ, Err: e}) != nil {
+				errs = append(errs, e)
 			}
-		case "facter":
-			settings[name] = deepcopy.Copy(val)
 		}
 	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, PuppetMasterless)
-	return settings, nil
-}
-
-// createPuppetServer creates a map of settings for Packer's puppet-client
-// provisioner.  Any values that aren't supported by the puppet-client
-// provisioner are ignored. For more information, refer to:
-// https://www.packer.io/docs/provisioners/chef-client.html
-//
-// Required configuration options:
-//  none
-// Optional configuraiton options:
-//   client_cert_path         string
-//   client_private_key_path  string
-//   facter                   object, string key and values
-//   ignore_exit_codes        bool
-//   options                  string
-//   prevent_sudo             bool
-//   puppet_node              string
-//   puppet_server            string
-//   staging_directroy        string
-func (r *RawTemplate) createPuppetServer(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: PuppetServer, Err: ErrProvisionerNotFound}
+created:
+	filters, err := r.builderFilters(tmpP.Arrays, true)
+	if err != nil {
+		errs = append(errs, err)
 	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, PuppetServer)
-	settings = make(map[string]interface{})
-	settings["type"] = PuppetServer.String()
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	for _, s := range r.Provisioners[PuppetServer.String()].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "client_cert_path", "client_private_key_path", "options", "puppet_node", "puppet_server", "staging_directory":
-			settings[k] = v
-		case "ignore_exit_codes", "prevent_sudo":
-			settings[k], _ = strconv.ParseBool(v)
-		}
+	errs = append(errs, r.varErrors()...)
+	if len(errs) > 0 {
+		return nil, ProvisionerErr{id: ID, Provisioner: typ, Err: settingErrs(errs)}
 	}
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "facter" {
-			settings[name] = deepcopy.Copy(val)
-			continue
-		}
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
+	for k, v := range filters {
+		settings[k] = v
 	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, PuppetServer)
 	return settings, nil
 }
 
-// createSalt creates a map of settings for Packer's salt provisioner. Any
-// values that aren't supported by the salt provisioner are ignored. For more
-// information, refer to
-// https://packer.io/docs/provisioners/salt-masterless.html
-//
-// Required configuration options:
-//   local_state_tree     string
-// Optional configuration options
-//   bootstrap_args       string
-//   disable_sudo         bool
-//   local_pillar_roots   string
-//   local_state_tree     string
-//   log_level            string
-//   minion_config        string
-//   no_exit_on_failure   bool
-//   remote_pillar_roots  string
-//   remote_state_tree    string
-//   skip_bootstrap       bool
-//   temp_config_dir      string
-func (r *RawTemplate) createSalt(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, Salt)
-	settings = make(map[string]interface{})
-	settings["type"] = Salt.String()
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	var (
-		k, v, remotePillarRoots, remoteStateTree string
-		hasLocalStateTree, hasMinion             bool
-	)
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "local_state_tree":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, Salt.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info)
-			if src != "" {
-				r.Dirs[r.buildOutPath(Salt.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(Salt.String(), v, false)
-			hasLocalStateTree = true
-		case "local_pillar_roots":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(v, Salt.String(), true)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Dirs[r.buildOutPath(Salt.String(), v)] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(Salt.String(), v, false)
-		case "minion_config":
-			// find the actual location and add it to the files map for copying
-			src, err := r.findSource(filepath.Join(v, "minion"), Salt.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(Salt.String(), filepath.Join(v, "minion"))] = src
-			}
-			settings[k] = r.buildTemplateResourcePath(Salt.String(), v, false)
-			hasMinion = true
-		case "bootstrap_args", "log_level", "temp_config_dir":
-			settings[k] = v
-		case "remote_pillar_roots":
-			settings[k] = v
-			remotePillarRoots = v
-		case "remote_state_tree":
-			settings[k] = v
-			remoteStateTree = v
-		case "disable_sudo", "no_exit_on_failure", "skip_bootstrap":
-			settings[k], _ = strconv.ParseBool(v)
-		}
-	}
-	if !hasLocalStateTree {
-		return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: RequiredSettingErr{"local_state_tree"}}
-	}
-	// If minion is set, remote_pilar_roots and remote_state_tree cannot be used.
-	if hasMinion {
-		if remotePillarRoots != "" {
-			return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: SettingErr{Key: "remote_pillar_roots", Value: remotePillarRoots, err: errors.New("cannot be used with the 'minion_config' setting")}}
-		}
-		if remoteStateTree != "" {
-			return nil, ProvisionerErr{id: ID, Provisioner: Salt, Err: SettingErr{Key: "remote_state_tree", Value: remoteStateTree, err: errors.New("cannot be used with the 'minion_config' setting")}}
-		}
-	}
-	// Process the Arrays.
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, Salt)
-	return settings, nil
-}
-
-// createShell creates a map of settings for Packer's shell provisioner.  Any
-// values that aren't supported by the shell provisioner are ignored. For
-// more information, refer to https://packer.io/docs/provisioners/shell.html
-//
-// Of the "inline", "script", and "scripts" options, only one can be used:
-// they are mutually exclusive.  If multiple are specified, the one with the
-// highest precedence will be used.  They are listed in order of precedence,
-// from high to low:
-//
-// Required configuration options:
-//   inline               array of strings
-//   script               string
-//   scripts              array of strings
-// Optional confinguration parameters:
-//   binary               bool
-//   environment_vars     array of strings
-//   execute_command      string
-//   inline_shebang       string
-//   remote_file          string
-//   remote_folder        string
-//   remote_path          string
-//   skip_clean           bool
-//   start_retry_timeout  string
-func (r *RawTemplate) createShell(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, Shell)
-	settings = make(map[string]interface{})
-	settings["type"] = Shell.String()
-	var script string
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	var k, v string
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v = parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "execute_command":
-			// If the execute_command references a file, parse that for the command
-			// Otherwise assume that it contains the command
-			if strings.HasSuffix(v, ".command") {
-				var commands []string
-				commands, err = r.commandsFromFile(v, Shell.String())
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: SettingErr{k, v, err}}
-				}
-				if len(commands) == 0 {
-					return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: SettingErr{k, v, ErrNoCommands}}
-				}
-				settings[k] = commands[0] // for execute_command, only the first element is used
-				continue
-			}
-			settings[k] = v
-		case "inline_shebang", "remote_file", "remote_folder", "remote_path", "start_retry_timeout":
-			settings[k] = v
-		case "script":
-			// defer resolution of
-			script = v
-		case "binary", "skip_clean":
-			settings[k], _ = strconv.ParseBool(v)
-		}
-	}
-	// Check for inline and/or scripts in the Arrays.  This is so that the
-	// setting with the highest precedence is used in situations where
-	// inline, script, and/or scripts are not exclusive (at least two of them
-	// exist in the settings).
-	var (
-		vals interface{}
-		key  string
-	)
-	vals, ok = r.Provisioners[ID].Arrays["inline"]
-	if ok {
-		key = "inline"
-	} else {
-		vals, ok = r.Provisioners[ID].Arrays["scripts"]
-		if ok {
-			key = "scripts"
-		}
-	}
-	if key == "inline" {
-		settings[key] = deepcopy.Copy(vals).([]string) // this will panic if it isn't a slice of strings
-		goto arrays
-	}
-
-	if script != "" {
-		script = r.replaceVariables(script)
-		// find the source
-		src, err := r.findSource(script, Shell.String(), false)
-		if err != nil {
-			return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: SettingErr{"script", script, err}}
-		}
-		// if the source couldn't be found and an error wasn't generated, replace
-		// s with the original value; this occurs when it is an example.
-		// Nothing should be copied in this instancel it should not be added
-		// to the copy info
-		if src != "" {
-			r.Files[r.buildOutPath(Shell.String(), script)] = src
-		}
-		settings["script"] = r.buildTemplateResourcePath(Shell.String(), script, false)
-		goto arrays
-	}
-	if key == "scripts" {
-		scripts := deepcopy.Copy(vals).([]string) // this will panic if it isn't a slice of strings
-		for i, v := range scripts {
-			v = r.replaceVariables(v)
-			// find the source
-			src, err := r.findSource(v, Shell.String(), false)
-			if err != nil {
-				return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: SettingErr{k, v, err}}
-			}
-			// if the source couldn't be found and an error wasn't generated, replace
-			// s with the original value; this occurs when it is an example.
-			// Nothing should be copied in this instancel it should not be added
-			// to the copy info
-			if src != "" {
-				r.Files[r.buildOutPath(Shell.String(), v)] = src
-			}
-			scripts[i] = r.buildTemplateResourcePath(Shell.String(), v, false)
-		}
-		settings[key] = scripts
-		goto arrays
-	}
-	// This means the a required setting was not found.
-	return nil, ProvisionerErr{id: ID, Provisioner: Shell, Err: RequiredSettingErr{"inline, script, scripts"}}
-
-arrays:
-	// Process the Arrays.
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "environment_vars" || name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, Shell)
-	return settings, nil
-}
-
-// createShellLocal creates a map of settings for Packer's shell local
-// provisioner.  Any values that aren't supported by the shell local
-// provisioner are ignored. For/ more information, refer to
-// https://packer.io/docs/provisioners/shell.html
-//
-// Of the "inline", "script", and "scripts" options, only one can be used:
-// they are mutually exclusive.  If multiple are specified, the one with the
-// highest precedence will be used.  They are listed in order of precedence,
-// from high to low:
-//
-// Required configuration options:
-//   command              string
-// Optional confinguration parameters:
-//   execute_command      string
-func (r *RawTemplate) createShellLocal(ID string) (settings map[string]interface{}, err error) {
-	_, ok := r.Provisioners[ID]
-	if !ok {
-		return nil, ProvisionerErr{id: ID, Provisioner: ShellLocal, Err: ErrProvisionerNotFound}
-	}
-	log.Infof("%s: create provisioner: %s: %s", r.Name, ID, ShellLocal)
-	settings = make(map[string]interface{})
-	settings["type"] = ShellLocal.String()
-
-	// For each value, extract its key value pair and then process. Only process the supported
-	// keys. Key validation isn't done here, leaving that for Packer.
-	var hasCommand bool
-	for _, s := range r.Provisioners[ID].Settings {
-		log.Debugf("%s: %s: %s", r.Name, ID, s)
-		k, v := parseVar(s)
-		v = r.replaceVariables(v)
-		switch k {
-		case "command", "execute_command":
-			if k == "command" && v != "" {
-				hasCommand = true
-			}
-			// If the execute_command references a file, parse that for the command
-			// Otherwise assume that it contains the command
-			if strings.HasSuffix(v, ".command") {
-				var commands []string
-				commands, err = r.commandsFromFile(v, Shell.String())
-				if err != nil {
-					return nil, ProvisionerErr{id: ID, Provisioner: ShellLocal, Err: SettingErr{k, v, err}}
-				}
-				if len(commands) == 0 {
-					return nil, ProvisionerErr{id: ID, Provisioner: ShellLocal, Err: SettingErr{k, v, ErrNoCommands}}
-				}
-				settings[k] = commands[0] // for execute_command, only the first element is used
-				continue
-			}
-			settings[k] = v
-		}
-	}
-	if !hasCommand {
-		return nil, ProvisionerErr{id: ID, Provisioner: ShellLocal, Err: RequiredSettingErr{"command"}}
-	}
-
-	for name, val := range r.Provisioners[ID].Arrays {
-		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
-		if name == "environment_vars" || name == "only" || name == "except" {
-			array := deepcopy.Copy(val).([]string) // this will panic if it isn't a slice of strings
-			if array != nil {
-				settings[name] = array
-			}
-		}
-	}
-	log.Infof("%s: created provisioner: %s: %s", r.Name, ID, ShellLocal)
-	return settings, nil
+// minionConfig finds the minion file in the salt-masterless provisioner's
+// minion_config directory, v, and adds it to the files to copy.  The
+// directory's template path is returned.
+func minionConfig(r *RawTemplate, name string, v interface{}) (interface{}, error) {
+	dir, _ := v.(string)
+	minion := filepath.Join(dir, "minion")
+	src, err := r.findSource(minion, Salt.String(), false)
+	if err != nil {
+		return nil, err
+	}
+	// if the source couldn't be found and an error wasn't generated, this
+	// is an example; nothing is copied.
+	if src != "" {
+		r.Files[r.buildOutPath(Salt.String(), minion)] = src
+	}
+	return r.buildTemplateResourcePath(Salt.String(), dir, false), nil
 }
 
 // Merges the new config with the old. The updates occur as follows:
@@ -1230,6 +216,9 @@ func (r *RawTemplate) updateProvisioners(newP map[string]ProvisionerC) error {
 		log.Debugf("%s: update provisioners: nothing to update", r.Name)
 		return nil
 	}
+	for ID, p := range newP {
+		r.recordSectionOrigins("provisioners", ID, p.TemplateSection)
+	}
 	// Convert the existing provisioners to Componenter.
 	oldC := DeepCopyMapStringProvisionerC(r.Provisioners)
 	// Convert the new provisioners to Componenter.
@@ -1250,7 +239,12 @@ func (r *RawTemplate) updateProvisioners(newP map[string]ProvisionerC) error {
 			if !ok { // if the key exists in neither then something is wrong
 				return fmt.Errorf("provisioner merge failed: %s key not found in either template", v)
 			}
-			r.Provisioners[v] = pp.Copy()
+			c := pp.Copy()
+			err := c.resolveArrays()
+			if err != nil {
+				return fmt.Errorf("provisioner %s: %s", v, err)
+			}
+			r.Provisioners[v] = c
 			continue
 		}
 		// If the element for this key doesn't exist, skip it.
@@ -1262,7 +256,11 @@ func (r *RawTemplate) updateProvisioners(newP map[string]ProvisionerC) error {
 		if err != nil {
 			return err
 		}
-		p.mergeArrays(pp.Arrays)
+		err = p.mergeArrays(pp.Arrays, pp.Merge)
+		if err != nil {
+			return fmt.Errorf("provisioner %s: %s", v, err)
+		}
+		p.mergePassthrough(pp.TemplateSection)
 		r.Provisioners[v] = p
 		log.Debugf("%s: merge provisioners: %s", r.Name, v)
 	}
@@ -1284,7 +282,7 @@ func (p *ProvisionerC) settingsToMap(Type string, r *RawTemplate) map[string]int
 		case "binary":
 			v, _ = strconv.ParseBool(v.(string))
 		default:
-			v = r.replaceVariables(v.(string))
+			v = r.replaceSettingVars(k, v.(string))
 		}
 		m[k] = v
 	}
@@ -850,7 +850,7 @@ func TestAnsibleProvisioner(t *testing.T) {
 	}
 	eg := testRawTemplateProvisionersAll.IsExample
 	testRawTemplateProvisionersAll.IsExample = true
-	settings, err := testRawTemplateProvisionersAll.createAnsible("ansible")
+	settings, err := provisionerFactories["ansible"].Create(testRawTemplateProvisionersAll, "ansible")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -885,7 +885,7 @@ func TestAnsibleLocalProvisioner(t *testing.T) {
 		"staging_directory": "staging/directory",
 		"type":              "ansible-local",
 	}
-	settings, err := testRawTemplateProvisionersAll.createAnsibleLocal("ansible-local")
+	settings, err := provisionerFactories["ansible-local"].Create(testRawTemplateProvisionersAll, "ansible-local")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -920,7 +920,7 @@ func TestChefClientProvisioner(t *testing.T) {
 		"validation_client_name": "some_value",
 		"validation_key_path":    "/home/user/chef/chef-key",
 	}
-	settings, err := testRawTemplateProvisionersAll.createChefClient("chef-client")
+	settings, err := provisionerFactories["chef-client"].Create(testRawTemplateProvisionersAll, "chef-client")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -958,7 +958,7 @@ func TestChefSoloProvisioner(t *testing.T) {
 		"staging_directory": "/tmp/chef/",
 		"type":              "chef-solo",
 	}
-	settings, err := testRawTemplateProvisionersAll.createChefSolo("chef-solo")
+	settings, err := provisionerFactories["chef-solo"].Create(testRawTemplateProvisionersAll, "chef-solo")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -974,7 +974,7 @@ func TestFileProvisioner(t *testing.T) {
 		"source":      "file/app.tar.gz",
 		"type":        "file",
 	}
-	settings, err := testRawTemplateProvisionersAll.createFile("file")
+	settings, err := provisionerFactories["file"].Create(testRawTemplateProvisionersAll, "file")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -987,7 +987,7 @@ func TestFileProvisioner(t *testing.T) {
 		"source":      "file/source/",
 		"type":        "file",
 	}
-	settings, err = testRawTemplateProvisionersAll.createFile("filedir")
+	settings, err = provisionerFactories["file"].Create(testRawTemplateProvisionersAll, "filedir")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1019,7 +1019,7 @@ func TestPuppetMasterlessProvisioner(t *testing.T) {
 		"type":              "puppet-masterless",
 		"working_directory": "work",
 	}
-	settings, err := testRawTemplateProvisionersAll.createPuppetMasterless("puppet-masterless")
+	settings, err := provisionerFactories["puppet-masterless"].Create(testRawTemplateProvisionersAll, "puppet-masterless")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1044,7 +1044,7 @@ func TestPuppetServerProvisioner(t *testing.T) {
 		"staging_directory": "/tmp/puppet-server",
 		"type":              "puppet-server",
 	}
-	settings, err := testRawTemplateProvisionersAll.createPuppetServer("puppet-server")
+	settings, err := provisionerFactories["puppet-server"].Create(testRawTemplateProvisionersAll, "puppet-server")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1105,7 +1105,7 @@ func TestSaltProvisioner(t *testing.T) {
 				"temp_config_dir":     "/tmp",
 				"type":                "salt-masterless",
 			},
-			expectedErr: "salt-masterless: salt-masterless-remote-pillar-minion-err: remote_pillar_roots: /srv/pillar: cannot be used with the 'minion_config' setting",
+			expectedErr: "remote_pillar_roots: /srv/pillar: cannot be used with the 'minion_config' setting",
 		},
 		{
 			key: "salt-masterless-remote-state-minion-err",
@@ -1121,11 +1121,11 @@ func TestSaltProvisioner(t *testing.T) {
 				"temp_config_dir":    "/tmp",
 				"type":               "salt-masterless",
 			},
-			expectedErr: "salt-masterless: salt-masterless-remote-state-minion-err: remote_state_tree: /srv/salt: cannot be used with the 'minion_config' setting",
+			expectedErr: "remote_state_tree: /srv/salt: cannot be used with the 'minion_config' setting",
 		},
 	}
 	for i, test := range tests {
-		settings, err := testRawTemplateProvisionersAll.createSalt(test.key)
+		settings, err := provisionerFactories["salt-masterless"].Create(testRawTemplateProvisionersAll, test.key)
 		if err != nil {
 			if err.Error() != test.expectedErr {
 				t.Errorf("%d: got %q, want %q", i, err, test.expectedErr)
@@ -1143,8 +1143,8 @@ func TestSaltProvisioner(t *testing.T) {
 }
 
 func TestShellProvisionerRequiredMissing(t *testing.T) {
-	expected := "shell: shell-required-missing: inline, script, scripts: required setting not found"
-	_, err := testRawTemplateProvisionersAll.createShell("shell-required-missing")
+	expected := "inline/script/scripts: required setting not found"
+	_, err := provisionerFactories["shell"].Create(testRawTemplateProvisionersAll, "shell-required-missing")
 	if err == nil {
 		t.Error("Expected error, got none")
 	} else {
@@ -1176,7 +1176,7 @@ func TestShellProvisionerInline(t *testing.T) {
 		"start_retry_timeout": "5m",
 		"type":                "shell",
 	}
-	settings, err := testRawTemplateProvisionersAll.createShell("shell-inline")
+	settings, err := provisionerFactories["shell"].Create(testRawTemplateProvisionersAll, "shell-inline")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1205,7 +1205,7 @@ func TestShellProvisionerScript(t *testing.T) {
 		"start_retry_timeout": "5m",
 		"type":                "shell",
 	}
-	settings, err := testRawTemplateProvisionersAll.createShell("shell-script")
+	settings, err := provisionerFactories["shell"].Create(testRawTemplateProvisionersAll, "shell-script")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1239,7 +1239,7 @@ func TestShellProvisionerScripts(t *testing.T) {
 		"start_retry_timeout": "5m",
 		"type":                "shell",
 	}
-	settings, err := testRawTemplateProvisionersAll.createShell("shell-scripts")
+	settings, err := provisionerFactories["shell"].Create(testRawTemplateProvisionersAll, "shell-scripts")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -1255,7 +1255,7 @@ func TestShellLocalProvisioner(t *testing.T) {
 		"execute_command": "echo 'vagrant'|sudo -S sh '{{.Path}}'",
 		"type":            "shell-local",
 	}
-	settings, err := testRawTemplateProvisionersAll.createShellLocal("shell-local")
+	settings, err := provisionerFactories["shell-local"].Create(testRawTemplateProvisionersAll, "shell-local")
 	if err != nil {
 		t.Errorf("Expected error to be nil, got %q", err)
 	} else {
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// userVarRef matches references to Packer user variables in a setting
+// value, e.g. {{user `aws_access_key`}}.
+var userVarRef = regexp.MustCompile("{{\\s*user\\s+`([^`]+)`\\s*}}")
+
+// VariableErr is an error processing a Packer user variable.
+type VariableErr struct {
+	name string
+	Err  error
+}
+
+func (e VariableErr) Error() string {
+	return fmt.Sprintf("variable %s: %s", e.name, e.Err)
+}
+
+// updateVariables merges the passed Packer user variables and sensitive
+// variables with the template's.  For variables, a variable's default value
+// is replaced by the new one.  Sensitive variables are added to the existing
+// ones.
+func (r *RawTemplate) updateVariables(vars, sensitive []string) error {
+	for _, v := range vars {
+		k, vv := parseVar(v)
+		r.recordOrigin("variables", k, vv)
+	}
+	if len(sensitive) > 0 {
+		r.recordIDsOrigin("sensitive_variables", sensitive)
+	}
+	var err error
+	r.Variables, err = mergeSettingsSlices(r.Variables, vars)
+	if err != nil {
+		return Error{slug: "merge variables", err: err}
+	}
+	r.SensitiveVariables = MergeSlices(r.SensitiveVariables, sensitive)
+	return nil
+}
+
+// createVariables returns the Packer template's variables and
+// sensitive-variables.  The Feedlot variables in each default value are
+// replaced.  Every sensitive variable must be a declared variable.
+func (r *RawTemplate) createVariables() (map[string]interface{}, []string, error) {
+	if len(r.Variables) == 0 && len(r.SensitiveVariables) == 0 {
+		return nil, nil, nil
+	}
+	vars := make(map[string]interface{}, len(r.Variables))
+	for _, v := range r.Variables {
+		k, vv := parseVar(v)
+		val, undefined := expandVars(vv, r.Delim, r.lookupVar)
+		if len(undefined) > 0 {
+			return nil, nil, VariableErr{name: k, Err: fmt.Errorf("%s%s: %s", r.Delim, undefined[0], ErrUndefinedVar)}
+		}
+		vars[k] = val
+	}
+	var sensitive []string
+	for _, k := range r.SensitiveVariables {
+		if _, ok := vars[k]; !ok {
+			return nil, nil, VariableErr{name: k, Err: fmt.Errorf("sensitive variable is not declared")}
+		}
+		sensitive = append(sensitive, k)
+	}
+	return vars, sensitive, nil
+}
+
+// undeclaredUserVariables returns an error for each Packer user variable that
+// the template's components reference but the template doesn't declare, in
+// name order.  Packer can still get their values, e.g. from a var-file, so
+// they are only errors in strict mode; see RawTemplate.strictErr.
+func (p *PackerTemplate) undeclaredUserVariables() []error {
+	var refs []string
+	for _, c := range p.components() {
+		refs = userVariableRefs(c, refs)
+	}
+	sort.Strings(refs)
+	var errs []error
+	for i, k := range refs {
+		if i > 0 && refs[i-1] == k {
+			continue
+		}
+		if _, ok := p.Variables[k]; !ok {
+			errs = append(errs, VariableErr{name: k, Err: fmt.Errorf("referenced but not declared")})
+		}
+	}
+	return errs
+}
+
+// userVariableRefs appends the names of the user variables referenced by
+// the strings within v to refs.
+func userVariableRefs(v interface{}, refs []string) []string {
+	if s, ok := v.(string); ok {
+		for _, m := range userVarRef.FindAllStringSubmatch(s, -1) {
+			refs = append(refs, m[1])
+		}
+		return refs
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			refs = userVariableRefs(rv.Index(i).Interface(), refs)
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			refs = userVariableRefs(rv.MapIndex(k).Interface(), refs)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+)
+
+func TestUpdateVariables(t *testing.T) {
+	r := newRawTemplate()
+	r.Variables = []string{"region = us-east-1", "aws_access_key ="}
+	r.SensitiveVariables = []string{"aws_access_key"}
+	err := r.updateVariables([]string{"region = us-west-2", "aws_secret_key="}, []string{"aws_secret_key"})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := []string{"region = us-west-2", "aws_access_key =", "aws_secret_key="}
+	if !reflect.DeepEqual(r.Variables, expected) {
+		t.Errorf("expected %v, got %v", expected, r.Variables)
+	}
+	expected = []string{"aws_access_key", "aws_secret_key"}
+	if !reflect.DeepEqual(r.SensitiveVariables, expected) {
+		t.Errorf("expected %v, got %v", expected, r.SensitiveVariables)
+	}
+}
+
+func TestCreateVariables(t *testing.T) {
+	tests := []struct {
+		vars      []string
+		sensitive []string
+		expected  map[string]interface{}
+		err       string
+	}{
+		{nil, nil, nil, ""},
+		{
+			[]string{"region = us-east-1", "name = :build_name", "aws_secret_key ="},
+			[]string{"aws_secret_key"},
+			map[string]interface{}{"region": "us-east-1", "name": "test-build", "aws_secret_key": ""},
+			"",
+		},
+		{[]string{"region = us-east-1"}, []string{"aws_secret_key"}, nil, "variable aws_secret_key: sensitive variable is not declared"},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.BuildName = "test-build"
+		r.setBaseVarVals()
+		r.Variables = test.vars
+		r.SensitiveVariables = test.sensitive
+		vars, sensitive, err := r.createVariables()
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected error %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(vars, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, vars)
+		}
+		if !reflect.DeepEqual(sensitive, test.sensitive) {
+			t.Errorf("%d: expected %v, got %v", i, test.sensitive, sensitive)
+		}
+	}
+}
+
+func TestUndeclaredUserVariables(t *testing.T) {
+	p := PackerTemplate{
+		Builders: []interface{}{
+			map[string]interface{}{
+				"type":       "amazon-ebs",
+				"access_key": "{{user `aws_access_key`}}",
+				"tags":       map[string]string{"Name": "{{ user `name` }}", "Region": "{{user `region`}}"},
+			},
+		},
+		Provisioners: []interface{}{
+			map[string]interface{}{
+				"type":             "shell",
+				"environment_vars": []string{"REGION={{user `region`}}", "ZONE={{user `zone`}}"},
+			},
+		},
+		Variables: map[string]interface{}{"aws_access_key": "", "name": "test"},
+	}
+	errs := p.undeclaredUserVariables()
+	expected := []string{"variable region: referenced but not declared", "variable zone: referenced but not declared"}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], err)
+		}
+	}
+	p.Variables["region"] = "us-east-1"
+	p.Variables["zone"] = "a"
+	errs = p.undeclaredUserVariables()
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+// An undeclared user variable is a warning, unless in strict mode.
+func TestValidateUndeclaredUserVariables(t *testing.T) {
+	strict := contour.GetBool(conf.Strict)
+	defer contour.UpdateBool(conf.Strict, strict)
+	tests := []struct {
+		strict   bool
+		warnings []string
+		errs     []string
+	}{
+		{false, []string{"variable version: referenced but not declared"}, nil},
+		{true, nil, []string{"variable version: referenced but not declared"}},
+	}
+	for i, test := range tests {
+		contour.UpdateBool(conf.Strict, test.strict)
+		r := newRawTemplate()
+		r.Delim = ":"
+		r.Variables = []string{"cloud_token="}
+		r.PostProcessorIDs = []string{"vagrant-cloud"}
+		r.PostProcessors = map[string]PostProcessorC{
+			"vagrant-cloud": {
+				TemplateSection{
+					Type:     "vagrant-cloud",
+					Settings: []string{"access_token={{user `cloud_token`}}", "box_tag=hashicorp/precise64", "version={{user `version`}}"},
+				},
+			},
+		}
+		var errs []string
+		for _, err := range r.validate() {
+			// there aren't any builders
+			if _, ok := err.(BuilderErr); ok {
+				continue
+			}
+			errs = append(errs, err.Error())
+		}
+		if !reflect.DeepEqual(errs, test.errs) {
+			t.Errorf("%d: expected errors %v, got %v", i, test.errs, errs)
+		}
+		if !reflect.DeepEqual(r.warnings, test.warnings) {
+			t.Errorf("%d: expected warnings %v, got %v", i, test.warnings, r.warnings)
+		}
+	}
+}
+
+func TestMarshalVarFile(t *testing.T) {
+	p := PackerTemplate{Variables: map[string]interface{}{"region": "us-east-1", "aws_access_key": ""}}
+	b, err := p.marshalVarFile()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected := "{\n\t\"aws_access_key\": \"\",\n\t\"region\": \"us-east-1\"\n}"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, string(b))
+	}
+	p.format = HCL2Template
+	b, err = p.marshalVarFile()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	expected = "aws_access_key = \"\"\nregion         = \"us-east-1\"\n"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, string(b))
+	}
+}
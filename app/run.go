@@ -3,7 +3,8 @@ package app
 import "github.com/mohae/feedlot/log"
 
 // Run takes a list of build list names and generates all of the Packer
-// templates associated with them.
+// templates associated with them.  The lists' globs, selectors, and
+// references to other lists are resolved to the builds they refer to.
 func Run(listNames ...string) ([]string, []error) {
 	log.Infof("run: build %d lists", len(listNames))
 	// load the build lists
@@ -12,19 +13,24 @@ func Run(listNames ...string) ([]string, []error) {
 	if err != nil {
 		return nil, []error{err}
 	}
+	// the builds are needed to resolve the lists' globs and selectors.
+	err = loadDistroDefaultsAndBuilds()
+	if err != nil {
+		return nil, []error{err}
+	}
 	// make sure the lists all exist
 	var errs []error
-	var lists []List
+	var lists [][]string
 	for _, name := range listNames {
-		log.Debugf("%s: get list", name)
-		l, err := bl.Get(name)
+		log.Debugf("%s: resolve list", name)
+		builds, err := bl.Resolve(name)
 		if err != nil {
 			errs = append(errs, err)
-			log.Errorf("%s: get list: %s", err)
+			log.Errorf("%s: resolve list: %s", name, err)
 			continue
 		}
-		lists = append(lists, l)
-		log.Debugf("%s: got list", name)
+		lists = append(lists, builds)
+		log.Debugf("%s: resolved list", name)
 	}
 	// if there were any errors on finding the build lists, don't do any processing.
 	if errs != nil {
@@ -35,8 +41,8 @@ func Run(listNames ...string) ([]string, []error) {
 	// TODO: make this concurrent once concurrent generation of builds is stable
 	messages := make([]string, len(listNames))
 	for i, v := range lists {
-		log.Debugf("run: build lists: %v", v.Builds)
-		messages[i], err = BuildBuilds(v.Builds...)
+		log.Debugf("run: build lists: %v", v)
+		messages[i], err = BuildBuilds(v...)
 		if err != nil {
 			log.Infof("run: %s", err)
 			errs = append(errs, err)
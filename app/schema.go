@@ -0,0 +1,674 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mohae/deepcopy"
+	"github.com/mohae/feedlot/log"
+)
+
+// The kinds of Packer components.
+const (
+	ComponentBuilder       = "builder"
+	ComponentPostProcessor = "post-processor"
+	ComponentProvisioner   = "provisioner"
+)
+
+// componentKinds are the kinds of components, in the order that their
+// schemas are listed.
+var componentKinds = []string{ComponentBuilder, ComponentPostProcessor, ComponentProvisioner}
+
+// SettingKind is the kind of value that a component setting has.
+type SettingKind int
+
+// SettingKind constants
+const (
+	SettingString SettingKind = iota
+	SettingInt
+	SettingBool
+	SettingArray
+	SettingObject
+	SettingStrings
+)
+
+var settingKinds = [...]string{
+	"string",
+	"int",
+	"bool",
+	"array",
+	"object",
+	"strings",
+}
+
+func (k SettingKind) String() string { return settingKinds[k] }
+
+// MarshalText implements encoding.TextMarshaler so that schemas are encoded
+// with the kind's name.
+func (k SettingKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *SettingKind) UnmarshalText(b []byte) error {
+	s := strings.ToLower(string(b))
+	for i, v := range settingKinds {
+		if v == s {
+			*k = SettingKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: unknown setting kind", b)
+}
+
+// isArray returns whether or not the setting's value is in the section's
+// arrays.  A strings setting is an array whose values must be strings.
+func (k SettingKind) isArray() bool {
+	return k == SettingArray || k == SettingObject || k == SettingStrings
+}
+
+// SettingSchema describes one of a component's settings or arrays.
+type SettingSchema struct {
+	Name string      `toml:"name" json:"name"`
+	Kind SettingKind `toml:"kind" json:"kind"`
+	// Required settings must be set.
+	Required bool `toml:"required,omitempty" json:"required,omitempty"`
+	// Default is the value that is used when the setting isn't set; it's
+	// in the same form as a build template's setting value.  Arrays don't
+	// have defaults.
+	Default string `toml:"default,omitempty" json:"default,omitempty"`
+	// Command is whether or not the setting's value can be a command file.
+	// A string setting gets the file's command; an array gets all of the
+	// file's commands, unless the array is set.
+	Command bool `toml:"command,omitempty" json:"command,omitempty"`
+	// Resource is whether or not the setting's value is the path of a
+	// resource, or, for an array, a list of them; resources are found and
+	// copied to the template output directory.
+	Resource bool `toml:"resource,omitempty" json:"resource,omitempty"`
+	// Aliases are deprecated names for the setting.  An alias is written to
+	// the Packer template with the setting's name.
+	Aliases []string `toml:"aliases,omitempty" json:"aliases,omitempty"`
+	// Options are the setting's valid values; any value is valid if there
+	// aren't any.
+	Options []string `toml:"options,omitempty" json:"options,omitempty"`
+	// RequiredUnless is the setting=value that makes a required setting
+	// optional, e.g. parallels_tools_flavor isn't required when
+	// parallels_tools_mode=disable.
+	RequiredUnless string `toml:"required_unless,omitempty" json:"required_unless,omitempty"`
+	// Overrides are the settings that aren't used when the setting is set,
+	// e.g. a shell provisioner's inline overrides its script and scripts.
+	Overrides []string `toml:"overrides,omitempty" json:"overrides,omitempty"`
+	// Conflicts are the settings that can't be used with the setting.
+	Conflicts []string `toml:"conflicts,omitempty" json:"conflicts,omitempty"`
+	// TrailingSlash is whether or not a resource that is a directory has a
+	// trailing slash in the Packer template.
+	TrailingSlash bool `toml:"trailing_slash,omitempty" json:"trailing_slash,omitempty"`
+	// Communicator is the communicator, ssh or winrm, that a builder's
+	// setting is for.  The setting is ignored if the builder uses the other
+	// communicator.
+	Communicator string `toml:"communicator,omitempty" json:"communicator,omitempty"`
+	// CommunicatorSetting is the communicator setting, without its prefix,
+	// that replaces a builder's setting when the builder has a
+	// communicator, e.g. username for ssh_username: the setting is ignored
+	// and, if it's required, the communicator's setting, e.g. winrm_username,
+	// is required instead.
+	CommunicatorSetting string `toml:"communicator_setting,omitempty" json:"communicator_setting,omitempty"`
+	// convert, if set, converts the setting's value, or the array, to the
+	// form that Packer expects, e.g. vboxmanage's settings to modifyvm
+	// commands.
+	convert func(r *RawTemplate, name string, v interface{}) (interface{}, error)
+}
+
+// ComponentSchema describes the settings and arrays of a type of component.
+// Settings that aren't in a component's schema are ignored; the builders'
+// communicator settings, and the settings that every post-processor or
+// provisioner supports, don't need to be in it.
+type ComponentSchema struct {
+	// Kind is builder, post-processor, or provisioner.
+	Kind     string          `toml:"kind" json:"kind"`
+	Type     string          `toml:"type" json:"type"`
+	Settings []SettingSchema `toml:"settings" json:"settings"`
+	// OneOf lists groups of settings; at least one setting of each group
+	// must be set.
+	OneOf [][]string `toml:"one_of,omitempty" json:"one_of,omitempty"`
+	// CommunicatorRequired is whether or not a builder must have a
+	// communicator other than none.
+	CommunicatorRequired bool `toml:"communicator_required,omitempty" json:"communicator_required,omitempty"`
+	// finish, if set, finishes the component's settings once they've been
+	// processed, e.g. it sets an ISO builder's ISO from its distro's
+	// release.
+	finish func(r *RawTemplate, c *schemaComponent) error
+}
+
+// schemaComponent is a component that is being created from its schema.
+type schemaComponent struct {
+	id  string
+	typ string
+	// the component's section; a builder's settings are merged with the
+	// common builder's.
+	section TemplateSection
+	// the builder's communicator: ssh, winrm, or, if it doesn't have one,
+	// empty.
+	communicator string
+	settings     map[string]interface{}
+}
+
+// copy returns a deep copy of the schema.
+func (s ComponentSchema) copy() ComponentSchema {
+	c := s
+	c.Settings = make([]SettingSchema, len(s.Settings))
+	for i, ss := range s.Settings {
+		c.Settings[i] = ss
+		c.Settings[i].Aliases = append([]string(nil), ss.Aliases...)
+		c.Settings[i].Options = append([]string(nil), ss.Options...)
+		c.Settings[i].Overrides = append([]string(nil), ss.Overrides...)
+		c.Settings[i].Conflicts = append([]string(nil), ss.Conflicts...)
+	}
+	c.OneOf = make([][]string, len(s.OneOf))
+	for i, g := range s.OneOf {
+		c.OneOf[i] = append([]string(nil), g...)
+	}
+	if len(c.OneOf) == 0 {
+		c.OneOf = nil
+	}
+	return c
+}
+
+// setting returns the schema of the setting whose name, or alias, is k.
+func (s *ComponentSchema) setting(k string) (SettingSchema, bool) {
+	for _, ss := range s.Settings {
+		if ss.Name == k || contains(ss.Aliases, k) {
+			return ss, true
+		}
+	}
+	return SettingSchema{}, false
+}
+
+// names returns the names and aliases of the schema's settings.
+func (s *ComponentSchema) names() []string {
+	var names []string
+	for _, ss := range s.Settings {
+		names = append(names, ss.Name)
+		names = append(names, ss.Aliases...)
+	}
+	return names
+}
+
+// SchemaProvider is implemented by the component factories whose components
+// are described by a schema.
+type SchemaProvider interface {
+	Schema() ComponentSchema
+}
+
+// schemaFactory is the factory of a type of component that is created from
+// its schema.
+type schemaFactory struct {
+	schema ComponentSchema
+}
+
+// NewSchemaFactory returns a factory that creates the components described
+// by the schema, s; see RegisterBuilder, RegisterPostProcessor, and
+// RegisterProvisioner.
+func NewSchemaFactory(s ComponentSchema) ComponentFactory {
+	return schemaFactory{schema: s.copy()}
+}
+
+func (f schemaFactory) Type() string { return f.schema.Type }
+
+func (f schemaFactory) Create(r *RawTemplate, id string) (map[string]interface{}, error) {
+	return r.createFromSchema(&f.schema, id)
+}
+
+func (f schemaFactory) Settings() []string { return f.schema.names() }
+
+func (f schemaFactory) Schema() ComponentSchema { return f.schema.copy() }
+
+// Schemas returns the schemas of the registered components that have one,
+// builders first, then post-processors, then provisioners; each sorted by
+// type.  The registered components whose factory doesn't provide a schema
+// aren't included.
+func Schemas() []ComponentSchema {
+	var schemas []ComponentSchema
+	for _, kind := range componentKinds {
+		factories := kindFactories(kind)
+		types := make([]string, 0, len(factories))
+		for typ := range factories {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		for _, typ := range types {
+			p, ok := factories[typ].(SchemaProvider)
+			if ok {
+				schemas = append(schemas, p.Schema())
+			}
+		}
+	}
+	return schemas
+}
+
+// Schema returns the schema of the registered component of the kind,
+// builder, post-processor, or provisioner, and type, typ.  False is returned
+// if there isn't one.
+func Schema(kind, typ string) (ComponentSchema, bool) {
+	p, ok := kindFactories(kind)[strings.ToLower(typ)].(SchemaProvider)
+	if !ok {
+		return ComponentSchema{}, false
+	}
+	return p.Schema(), true
+}
+
+// kindFactories returns the factories of the kind of component.
+func kindFactories(kind string) map[string]ComponentFactory {
+	switch kind {
+	case ComponentBuilder:
+		return builderFactories
+	case ComponentPostProcessor:
+		return postProcessorFactories
+	case ComponentProvisioner:
+		return provisionerFactories
+	}
+	return nil
+}
+
+// ErrNotCommandFile occurs when an array's setting isn't a command file; the
+// array's values must be in the section's arrays.
+var ErrNotCommandFile = errors.New("not a command file: an array's values must be in arrays")
+
+// InvalidOptionErr occurs when a component's setting isn't one of the
+// setting's options.
+type InvalidOptionErr struct {
+	Options []string
+}
+
+func (e InvalidOptionErr) Error() string {
+	return "invalid option: must be one of " + strings.Join(e.Options, ", ")
+}
+
+// SettingErrs are all of the problems found with a component's settings.
+type SettingErrs []error
+
+func (e SettingErrs) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// settingErrs returns the problems found with a component's settings, errs,
+// as an error: nil if there aren't any, the problem if there is only one,
+// or SettingErrs.
+func settingErrs(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return SettingErrs(errs)
+}
+
+// errList returns the problems in err: a SettingErrs' errors, or err.
+func errList(err error) []error {
+	if errs, ok := err.(SettingErrs); ok {
+		return errs
+	}
+	return []error{err}
+}
+
+// DeprecatedSettingErr occurs when a component's setting, or array, uses a
+// deprecated name.
+type DeprecatedSettingErr struct {
+	Key  string
+	Name string
+}
+
+func (e DeprecatedSettingErr) Error() string {
+	return fmt.Sprintf("%s: deprecated; use %s", e.Key, e.Name)
+}
+
+// createFromSchema creates the settings of the component, ID, whose settings
+// and arrays are described by the schema, s.  A builder's settings are merged
+// with the common builder's settings and its communicator is processed.  All
+// of the problems found with the settings are returned, see SettingErrs.
+func (r *RawTemplate) createFromSchema(s *ComponentSchema, ID string) (map[string]interface{}, error) {
+	c := schemaComponent{id: ID, typ: s.Type}
+	switch s.Kind {
+	case ComponentBuilder:
+		bldr, ok := r.Builders[ID]
+		if !ok {
+			return nil, ErrBuilderNotFound
+		}
+		c.section = bldr.TemplateSection
+		common, ok := r.Builders[Common.String()]
+		if ok {
+			var err error
+			c.section.Settings, err = mergeSettingsSlices(common.Settings, c.section.Settings)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case ComponentPostProcessor:
+		pp, ok := r.PostProcessors[ID]
+		if !ok {
+			return nil, ErrPostProcessorNotFound
+		}
+		c.section = pp.TemplateSection
+	case ComponentProvisioner:
+		p, ok := r.Provisioners[ID]
+		if !ok {
+			return nil, ErrProvisionerNotFound
+		}
+		c.section = p.TemplateSection
+	default:
+		return nil, fmt.Errorf("%s: unknown component kind", s.Kind)
+	}
+	log.Infof("%s: create %s: %s: %s", r.Name, s.Kind, ID, s.Type)
+	c.settings = map[string]interface{}{"type": s.Type}
+	// every problem with the settings is collected, not just the first.
+	var errs []error
+	if s.Kind == ComponentBuilder {
+		var err error
+		c.communicator, err = r.processCommunicator(ID, c.section.Settings, c.settings)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if s.CommunicatorRequired && c.communicator == "" && err == nil {
+			errs = append(errs, RequiredSettingErr{"communicator"})
+		}
+	}
+	unused := s.unused(&c)
+	set := map[string]bool{}
+	for _, kv := range c.section.Settings {
+		log.Debugf("%s: %s: %s", r.Name, ID, kv)
+		k, v := parseVar(kv)
+		ss, ok := s.setting(k)
+		if !ok || unused[ss.Name] {
+			continue
+		}
+		// an array takes precedence over a command file setting of the
+		// same name.
+		if _, ok := c.section.Arrays[ss.Name]; ok && ss.Kind.isArray() {
+			continue
+		}
+		if k != ss.Name {
+			r.warn(Error{slug: s.Type + ": " + ID, err: DeprecatedSettingErr{k, ss.Name}})
+		}
+		v = r.replaceSettingVars(k, v)
+		// a setting that has a problem is still set so that it isn't
+		// reported as missing too.
+		set[ss.Name] = true
+		if len(ss.Options) > 0 && !contains(ss.Options, v) {
+			errs = append(errs, SettingErr{k, v, InvalidOptionErr{ss.Options}})
+			continue
+		}
+		val, err := r.schemaSettingValue(s.Type, ss, v)
+		if err != nil {
+			errs = append(errs, SettingErr{k, v, err})
+			continue
+		}
+		c.settings[ss.Name] = val
+	}
+	// the arrays are a map; they are processed in order so that their
+	// errors are too.
+	names := make([]string, 0, len(c.section.Arrays))
+	for name := range c.section.Arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val := c.section.Arrays[name]
+		ss, ok := s.setting(name)
+		if !ok {
+			// every post-processor and provisioner can be filtered by
+			// builder.
+			if s.Kind == ComponentBuilder || (name != "except" && name != "only") {
+				continue
+			}
+			ss = SettingSchema{Name: name, Kind: SettingArray}
+		}
+		if !ss.Kind.isArray() || unused[ss.Name] {
+			continue
+		}
+		if name != ss.Name {
+			r.warn(Error{slug: s.Type + ": " + ID, err: DeprecatedSettingErr{name, ss.Name}})
+		}
+		log.Debugf("%s: %s: %s: %v", r.Name, ID, name, val)
+		array := deepcopy.Copy(val)
+		if array == nil {
+			continue
+		}
+		set[ss.Name] = true
+		var err error
+		switch {
+		case ss.convert != nil:
+			array, err = ss.convert(r, name, array)
+		case ss.Resource:
+			array, err = r.componentResources(name, s.Type, array, ss.TrailingSlash)
+		case ss.Kind == SettingStrings:
+			array, err = stringSlice(name, array)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.settings[ss.Name] = array
+	}
+	for _, ss := range s.Settings {
+		if set[ss.Name] {
+			continue
+		}
+		// the builder's communicator settings replace the setting.
+		if ss.CommunicatorSetting != "" && c.communicator != "" {
+			k := c.communicator + "_" + ss.CommunicatorSetting
+			if _, ok := c.settings[k]; ss.Required && !ok {
+				errs = append(errs, RequiredSettingErr{k})
+			}
+			continue
+		}
+		if unused[ss.Name] {
+			continue
+		}
+		if ss.required(c.settings) {
+			errs = append(errs, RequiredSettingErr{ss.Name})
+			continue
+		}
+		if ss.Default == "" || ss.Kind.isArray() {
+			continue
+		}
+		v := r.replaceSettingVars(ss.Name, ss.Default)
+		val, err := r.schemaSettingValue(s.Type, ss, v)
+		if err != nil {
+			errs = append(errs, SettingErr{ss.Name, v, err})
+			continue
+		}
+		c.settings[ss.Name] = val
+	}
+	for _, ss := range s.Settings {
+		if !set[ss.Name] {
+			continue
+		}
+		for _, name := range ss.Conflicts {
+			if set[name] {
+				errs = append(errs, SettingErr{name, fmt.Sprint(c.settings[name]), fmt.Errorf("cannot be used with the '%s' setting", ss.Name)})
+			}
+		}
+	}
+	for _, group := range s.OneOf {
+		var ok bool
+		for _, name := range group {
+			if set[name] {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			errs = append(errs, RequiredSettingErr{strings.Join(group, "/")})
+		}
+	}
+	if s.finish != nil {
+		err := s.finish(r, &c)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, settingErrs(errs)
+	}
+	log.Infof("%s: created %s: %s: %s", r.Name, s.Kind, ID, s.Type)
+	return c.settings, nil
+}
+
+// unused returns the settings of the component, c, that aren't used: the
+// settings for the communicator that the builder doesn't use, the settings
+// that the builder's communicator settings replace, and the settings that
+// are overridden by a setting that is set.
+func (s *ComponentSchema) unused(c *schemaComponent) map[string]bool {
+	unused := map[string]bool{}
+	for _, ss := range s.Settings {
+		if c.communicator == "" {
+			continue
+		}
+		if ss.CommunicatorSetting != "" || (ss.Communicator != "" && ss.Communicator != c.communicator) {
+			unused[ss.Name] = true
+		}
+	}
+	for _, k := range c.section.keys() {
+		ss, ok := s.setting(k)
+		if !ok || unused[ss.Name] {
+			continue
+		}
+		for _, name := range ss.Overrides {
+			unused[name] = true
+		}
+	}
+	return unused
+}
+
+// required returns whether or not the setting must be set; a setting that
+// is required unless another setting has a value isn't required if the
+// other setting, in the component's settings, has that value.
+func (ss SettingSchema) required(settings map[string]interface{}) bool {
+	if !ss.Required {
+		return false
+	}
+	if ss.RequiredUnless == "" {
+		return true
+	}
+	k, v := parseVar(ss.RequiredUnless)
+	val, ok := settings[k]
+	return !ok || fmt.Sprint(val) != v
+}
+
+// schemaSettingValue returns the value of the setting, ss, of a component,
+// converted to the setting's kind.  A command file is replaced by its
+// command, or commands, and a resource by its template path.
+func (r *RawTemplate) schemaSettingValue(component string, ss SettingSchema, v string) (interface{}, error) {
+	if ss.convert != nil && !ss.Kind.isArray() {
+		return ss.convert(r, ss.Name, v)
+	}
+	switch ss.Kind {
+	case SettingInt:
+		return strconv.Atoi(v)
+	case SettingBool:
+		return parseBool(v)
+	case SettingArray, SettingObject, SettingStrings:
+		if !ss.Command || !stringIsCommandFilename(v) {
+			return nil, ErrNotCommandFile
+		}
+		cmds, err := r.commandsFromFile(v, component)
+		if err != nil {
+			return nil, err
+		}
+		if len(cmds) == 0 {
+			return nil, ErrNoCommands
+		}
+		return cmds, nil
+	}
+	if ss.Command && stringIsCommandFilename(v) {
+		cmds, err := r.commandsFromFile(v, component)
+		if err != nil {
+			return nil, err
+		}
+		cmd := commandFromSlice(cmds)
+		if cmd == "" {
+			return nil, ErrNoCommands
+		}
+		return cmd, nil
+	}
+	if ss.Resource {
+		return r.componentResource(component, v, ss.TrailingSlash)
+	}
+	return v, nil
+}
+
+// componentResources returns the template paths of the resources in the
+// array, name, of a component; see componentResource.  The array must be a
+// path or a list of paths.
+func (r *RawTemplate) componentResources(name, component string, v interface{}, slash bool) (interface{}, error) {
+	switch vv := v.(type) {
+	case string:
+		p, err := r.componentResource(component, vv, slash)
+		if err != nil {
+			return nil, SettingErr{name, vv, err}
+		}
+		return p, nil
+	case []string:
+		paths := make([]string, len(vv))
+		for i, s := range vv {
+			p, err := r.componentResource(component, s, slash)
+			if err != nil {
+				return nil, SettingErr{fmt.Sprintf("%s[%d]", name, i), s, err}
+			}
+			paths[i] = p
+		}
+		return paths, nil
+	case []interface{}:
+		paths := make([]string, len(vv))
+		for i, x := range vv {
+			s, ok := x.(string)
+			if !ok {
+				return nil, SettingTypeErr{Path: fmt.Sprintf("%s[%d]", name, i), Expected: "a resource path", Got: settingTypeName(x)}
+			}
+			p, err := r.componentResource(component, s, slash)
+			if err != nil {
+				return nil, SettingErr{fmt.Sprintf("%s[%d]", name, i), s, err}
+			}
+			paths[i] = p
+		}
+		return paths, nil
+	}
+	return nil, SettingTypeErr{Path: name, Expected: "a resource path or a list of them", Got: settingTypeName(v)}
+}
+
+// componentResource finds the source of the resource, p, of a component and
+// adds it to the files, or the dirs, to copy.  The resource's path in the
+// Packer template is returned; if slash is true and the resource is a
+// directory, it has a trailing slash.
+func (r *RawTemplate) componentResource(component, p string, slash bool) (string, error) {
+	src, err := r.findSource(p, component, false)
+	if err != nil {
+		return "", err
+	}
+	// if the source couldn't be found and an error wasn't generated, this
+	// is an example; nothing is copied.  An example's source may not exist.
+	var isDir bool
+	if src != "" {
+		inf, err := os.Stat(src)
+		if err != nil {
+			if !r.IsExample {
+				return "", err
+			}
+		} else if inf.IsDir() {
+			isDir = slash
+			r.Dirs[r.buildOutPath(component, p)] = src
+		} else {
+			r.Files[r.buildOutPath(component, p)] = src
+		}
+	}
+	return r.buildTemplateResourcePath(component, p, isDir), nil
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSchema = ComponentSchema{
+	Kind: ComponentProvisioner,
+	Type: "shell-test",
+	Settings: []SettingSchema{
+		{Name: "boot_command", Kind: SettingArray, Command: true},
+		{Name: "debug", Kind: SettingBool},
+		{Name: "execute_command", Command: true},
+		{Name: "name", Required: true},
+		{Name: "retries", Kind: SettingInt, Default: "2"},
+		{Name: "scripts", Kind: SettingArray, Resource: true},
+		{Name: "setup_script", Resource: true, Aliases: []string{"setup"}},
+		{Name: "user"},
+		{Name: "vars", Kind: SettingObject},
+		{Name: "workdir"},
+	},
+	OneOf: [][]string{{"user", "workdir"}},
+}
+
+func TestCreateFromSchema(t *testing.T) {
+	tests := []struct {
+		section  TemplateSection
+		expected map[string]interface{}
+		files    map[string]string
+		warnings []string
+		err      string
+	}{
+		{
+			TemplateSection{
+				Settings: []string{"name = :name", "debug = true", "execute_command = execute_test.command", "setup_script = setup_test.sh", "user = vagrant", "unknown = x"},
+				Arrays: map[string]interface{}{
+					"scripts": []string{"vagrant_test.sh"},
+					"vars":    map[string]string{"a": "b"},
+					"only":    []string{"virtualbox-iso"},
+					"extra":   []string{"x"},
+				},
+			},
+			map[string]interface{}{
+				"type":            "shell-test",
+				"name":            "test",
+				"debug":           true,
+				"execute_command": "echo 'vagrant'|sudo -S sh '{{.Path}}'",
+				"retries":         2,
+				"setup_script":    "setup_test.sh",
+				"scripts":         []string{"vagrant_test.sh"},
+				"user":            "vagrant",
+				"vars":            map[string]string{"a": "b"},
+				"only":            []string{"virtualbox-iso"},
+			},
+			map[string]string{
+				"out/setup_test.sh":   "../test_files/src/shell/setup_test.sh",
+				"out/vagrant_test.sh": "../test_files/src/shell/vagrant_test.sh",
+			},
+			nil,
+			"",
+		},
+		{
+			TemplateSection{Settings: []string{"name = x", "setup = setup_test.sh", "workdir = /tmp", "retries = 3", "boot_command = boot.command"}},
+			map[string]interface{}{
+				"type":         "shell-test",
+				"name":         "x",
+				"retries":      3,
+				"setup_script": "setup_test.sh",
+				"workdir":      "/tmp",
+				"boot_command": []string{"<esc><wait>", "<esc><wait>", "<enter><wait>"},
+			},
+			map[string]string{"out/setup_test.sh": "../test_files/src/shell/setup_test.sh"},
+			[]string{"shell-test: test: setup: deprecated; use setup_script"},
+			"",
+		},
+		{
+			TemplateSection{
+				Settings: []string{"name = x", "user = u", "boot_command = boot.command"},
+				Arrays:   map[string]interface{}{"boot_command": []string{"<enter>"}},
+			},
+			map[string]interface{}{
+				"type":         "shell-test",
+				"name":         "x",
+				"retries":      2,
+				"user":         "u",
+				"boot_command": []string{"<enter>"},
+			},
+			map[string]string{},
+			nil,
+			"",
+		},
+		{
+			TemplateSection{Settings: []string{"user = u"}},
+			nil, nil, nil,
+			"name: required setting not found",
+		},
+		{
+			TemplateSection{Settings: []string{"name = x"}},
+			nil, nil, nil,
+			"user/workdir: required setting not found",
+		},
+		{
+			TemplateSection{Settings: []string{"name = x", "user = u", "retries = many"}},
+			nil, nil, nil,
+			"retries: many: strconv.Atoi: parsing \"many\": invalid syntax",
+		},
+		{
+			TemplateSection{Settings: []string{"name = x", "user = u", "boot_command = <enter>"}},
+			nil, nil, nil,
+			"boot_command: <enter>: not a command file: an array's values must be in arrays",
+		},
+		{
+			TemplateSection{Settings: []string{"name = x", "user = u"}, Arrays: map[string]interface{}{"scripts": []interface{}{float64(1)}}},
+			nil, nil, nil,
+			"scripts[0]: expected a resource path, got number",
+		},
+		// every problem is found.
+		{
+			TemplateSection{Settings: []string{"retries = many", "setup_script = missing.sh"}, Arrays: map[string]interface{}{"scripts": []interface{}{float64(1)}}},
+			nil, nil, nil,
+			"retries: many: strconv.Atoi: parsing \"many\": invalid syntax; setup_script: missing.sh: missing.sh: file does not exist; scripts[0]: expected a resource path, got number; name: required setting not found; user/workdir: required setting not found",
+		},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.Delim = ":"
+		r.SourceDir = "../test_files/src"
+		r.TemplateOutputDir = "out"
+		r.VarVals = map[string]string{":name": "test"}
+		r.Provisioners = map[string]ProvisionerC{"test": {test.section}}
+		settings, err := r.createFromSchema(&testSchema, "test")
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(settings, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, settings)
+		}
+		if !reflect.DeepEqual(r.Files, test.files) {
+			t.Errorf("%d: expected %v, got %v", i, test.files, r.Files)
+		}
+		if !reflect.DeepEqual(r.warnings, test.warnings) {
+			t.Errorf("%d: expected %v, got %v", i, test.warnings, r.warnings)
+		}
+	}
+}
+
+func TestSettingKindText(t *testing.T) {
+	for i, kind := range []SettingKind{SettingString, SettingInt, SettingBool, SettingArray, SettingObject, SettingStrings} {
+		b, err := kind.MarshalText()
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		var k SettingKind
+		err = k.UnmarshalText(b)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if k != kind {
+			t.Errorf("%d: expected %s, got %s", i, kind, k)
+		}
+	}
+	var k SettingKind
+	err := k.UnmarshalText([]byte("float"))
+	if err == nil || err.Error() != "float: unknown setting kind" {
+		t.Errorf("expected %q, got %v", "float: unknown setting kind", err)
+	}
+}
+
+func TestSchemas(t *testing.T) {
+	defer delete(provisionerFactories, "shell-test")
+	err := RegisterBuilder(NewSchemaFactory(testSchema))
+	if err == nil || err.Error() != "builder shell-test: schema is a provisioner schema" {
+		t.Errorf("expected %q, got %v", "builder shell-test: schema is a provisioner schema", err)
+	}
+	err = RegisterProvisioner(NewSchemaFactory(testSchema))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	s, ok := Schema(ComponentProvisioner, "Shell-Test")
+	if !ok {
+		t.Fatal("expected the shell-test schema, got none")
+	}
+	if !reflect.DeepEqual(s, testSchema) {
+		t.Errorf("expected %v, got %v", testSchema, s)
+	}
+	// the returned schema is a copy.
+	s.Settings[0].Aliases = append(s.Settings[0].Aliases, "boot")
+	s, _ = Schema(ComponentProvisioner, "shell-test")
+	if len(s.Settings[0].Aliases) != 0 {
+		t.Errorf("expected the registered schema to be unchanged, got aliases %v", s.Settings[0].Aliases)
+	}
+	_, ok = Schema(ComponentProvisioner, "shell-unregistered")
+	if ok {
+		t.Error("expected shell-unregistered to not have a schema")
+	}
+	// the schemas are grouped by kind and sorted by type.
+	var prev ComponentSchema
+	var found bool
+	for i, s := range Schemas() {
+		if s.Type == "shell-test" {
+			found = true
+		}
+		if i > 0 && prev.Kind == s.Kind && prev.Type >= s.Type {
+			t.Errorf("%d: expected %s %s to be after %s", i, s.Kind, s.Type, prev.Type)
+		}
+		prev = s
+	}
+	if !found {
+		t.Error("expected the shell-test schema to be in the schemas")
+	}
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// ListRefPrefix is the prefix of a build list entry that refers to another
+// build list, e.g. @ubuntu.
+const ListRefPrefix = "@"
+
+// selectorKeys are the build attributes that a selector can match.
+var selectorKeys = []string{"name", "distro", "release", "arch", "image", "tag"}
+
+// SelectorErr occurs when a selector can't be parsed.
+type SelectorErr struct {
+	term string
+	Err  error
+}
+
+func (e SelectorErr) Error() string {
+	return fmt.Sprintf("selector: %q: %s", e.term, e.Err)
+}
+
+// selectorTerm matches builds whose attribute, key, matches the glob
+// pattern, val, or, if not is true, builds whose attribute doesn't.
+type selectorTerm struct {
+	key string
+	val string
+	not bool
+}
+
+// Selector selects builds by their attributes.  A build is selected if it
+// matches all of the selector's terms.
+type Selector []selectorTerm
+
+// ParseSelector parses a selector expression: a comma separated list of
+// key=pattern terms, e.g. distro=ubuntu,tag=ci,!tag=experimental.  A term
+// that starts with a ! matches the builds that the rest of the term doesn't.
+// The keys are name, distro, release, arch, image, and tag; the patterns are
+// glob patterns, see path.Match.  A build matches a tag term if any of its
+// tags match.  An empty expression selects every build.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		var t selectorTerm
+		kv := term
+		if strings.HasPrefix(kv, "!") {
+			t.not = true
+			kv = kv[1:]
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, SelectorErr{term: term, Err: errors.New("expected key=pattern")}
+		}
+		t.key = strings.TrimSpace(kv[:i])
+		t.val = strings.TrimSpace(kv[i+1:])
+		if !contains(selectorKeys, t.key) {
+			return nil, SelectorErr{term: term, Err: fmt.Errorf("%s: unknown key: must be one of %s", t.key, strings.Join(selectorKeys, ", "))}
+		}
+		_, err := path.Match(t.val, "")
+		if err != nil {
+			return nil, SelectorErr{term: term, Err: err}
+		}
+		sel = append(sel, t)
+	}
+	return sel, nil
+}
+
+// isSelector returns whether or not the build list entry, s, is a selector
+// expression.
+func isSelector(s string) bool {
+	return strings.Contains(s, "=")
+}
+
+// isGlob returns whether or not the build list entry, s, is a glob pattern.
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// buildAttrs are the attributes of a build that a selector matches.
+type buildAttrs struct {
+	name    string
+	distro  string
+	release string
+	arch    string
+	image   string
+	tags    []string
+}
+
+// matches returns whether or not the build's attributes match all of the
+// selector's terms.
+func (s Selector) matches(a buildAttrs) bool {
+	for _, t := range s {
+		var ok bool
+		switch t.key {
+		case "name":
+			ok, _ = path.Match(t.val, a.name)
+		case "distro":
+			ok, _ = path.Match(t.val, a.distro)
+		case "release":
+			ok, _ = path.Match(t.val, a.release)
+		case "arch":
+			ok, _ = path.Match(t.val, a.arch)
+		case "image":
+			ok, _ = path.Match(t.val, a.image)
+		case "tag":
+			for _, tag := range a.tags {
+				ok, _ = path.Match(t.val, tag)
+				if ok {
+					break
+				}
+			}
+		}
+		if ok == t.not {
+			return false
+		}
+	}
+	return true
+}
+
+// buildAttributes returns the attributes of the named build.  Like the
+// distro, a build's release, arch, and image are inherited from its closest
+// parent that has one and, if none do, are its distro's defaults.  A build's
+// tags are its own and all of its parents' tags.
+func buildAttributes(name string) (buildAttrs, error) {
+	a := buildAttrs{name: name}
+	chain, err := getBuildChain(name)
+	if err != nil {
+		return a, err
+	}
+	a.distro = chainDistro(chain)
+	rTpl, err := DistroDefaults.getEnvTemplate(chain[len(chain)-1].env, a.distro)
+	if err == nil {
+		a.release, a.arch, a.image = rTpl.Release, rTpl.Arch, rTpl.Image
+	}
+	for _, bTpl := range chain {
+		if bTpl.Release != "" {
+			a.release = bTpl.Release
+		}
+		if bTpl.Arch != "" {
+			a.arch = bTpl.Arch
+		}
+		if bTpl.Image != "" {
+			a.image = bTpl.Image
+		}
+		for _, tag := range bTpl.Tags {
+			if !contains(a.tags, tag) {
+				a.tags = append(a.tags, tag)
+			}
+		}
+	}
+	return a, nil
+}
+
+// selectBuilds returns the names, sorted, of the builds in BuildDefs that
+// the selector selects.  Matrix builds are selected by the builds they were
+// expanded into.
+func selectBuilds(sel Selector) ([]string, error) {
+	var names []string
+	for _, name := range allBuildNames() {
+		a, err := buildAttributes(name)
+		if err != nil {
+			return nil, Error{slug: name, err: err}
+		}
+		if sel.matches(a) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// globBuilds returns the names, sorted, of the builds in BuildDefs that
+// match the glob pattern.
+func globBuilds(pattern string) ([]string, error) {
+	var names []string
+	for _, name := range allBuildNames() {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, Error{slug: pattern, err: err}
+		}
+		if ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// SelectBuilds returns the names of the builds that the selector
+// expression selects; see ParseSelector.  An empty expression selects every
+// build.
+func SelectBuilds(selector string) ([]string, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	err = loadDistroDefaultsAndBuilds()
+	if err != nil {
+		err = Error{slug: "select builds", err: err}
+		log.Error(err)
+		return nil, err
+	}
+	names, err := selectBuilds(sel)
+	if err != nil {
+		err = Error{slug: "select builds", err: err}
+		log.Error(err)
+		return nil, err
+	}
+	log.Infof("select builds: %q: %v", selector, names)
+	return names, nil
+}
+
+// loadDistroDefaultsAndBuilds sets the distro defaults, if they haven't been
+// set, and loads the builds.
+func loadDistroDefaultsAndBuilds() error {
+	if !DistroDefaults.IsSet {
+		log.Debug("loading distro defaults")
+		err := DistroDefaults.Set()
+		if err != nil {
+			return err
+		}
+	}
+	return loadBuilds()
+}
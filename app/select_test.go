@@ -0,0 +1,132 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Selector
+		err      string
+	}{
+		{"", nil, ""},
+		{"distro=ubuntu", Selector{{key: "distro", val: "ubuntu"}}, ""},
+		{
+			"distro=ubuntu, tag=ci,!tag=experimental",
+			Selector{{key: "distro", val: "ubuntu"}, {key: "tag", val: "ci"}, {key: "tag", val: "experimental", not: true}},
+			"",
+		},
+		{"release=16.*", Selector{{key: "release", val: "16.*"}}, ""},
+		{"distro", nil, `selector: "distro": expected key=pattern`},
+		{"os=ubuntu", nil, `selector: "os=ubuntu": os: unknown key: must be one of name, distro, release, arch, image, tag`},
+		{"name=[ubuntu", nil, `selector: "name=[ubuntu": syntax error in pattern`},
+	}
+	for i, test := range tests {
+		sel, err := ParseSelector(test.s)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(sel, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, sel)
+		}
+	}
+}
+
+var testSelectBuildDefs = map[string]Builds{
+	"conf/build.toml": {
+		Templates: map[string]*RawTemplate{
+			"base":         {Distro: "ubuntu", Tags: []string{"vagrant"}},
+			"1404-64":      {Extends: []string{"base"}, Release: "14.04", Tags: []string{"ci", "ubuntu-lts"}},
+			"1604-64":      {Extends: []string{"base"}, Release: "16.04", Tags: []string{"ci", "experimental"}},
+			"1604-32":      {Extends: []string{"base"}, Release: "16.04", Arch: "i386"},
+			"centos7-64":   {Distro: "centos", Tags: []string{"ci"}},
+			"centos7-test": {Distro: "centos"},
+		},
+	},
+}
+
+func TestSelectBuilds(t *testing.T) {
+	BuildDefs = testSelectBuildDefs
+	defer func() { BuildDefs = map[string]Builds{} }()
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{"", []string{"1404-64", "1604-32", "1604-64", "base", "centos7-64", "centos7-test"}},
+		{"distro=ubuntu,tag=ci,!tag=experimental", []string{"1404-64"}},
+		{"tag=vagrant,release=16.*", []string{"1604-32", "1604-64"}},
+		{"arch=i386", []string{"1604-32"}},
+		{"tag=ubuntu-*", []string{"1404-64"}},
+		{"!tag=*", []string{"centos7-test"}},
+		{"name=centos*,!name=*-test", []string{"centos7-64"}},
+		{"distro=debian", nil},
+	}
+	for i, test := range tests {
+		sel, err := ParseSelector(test.s)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		names, err := selectBuilds(sel)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, names)
+		}
+	}
+}
+
+func TestBuildListsResolve(t *testing.T) {
+	BuildDefs = testSelectBuildDefs
+	defer func() { BuildDefs = map[string]Builds{} }()
+	bl := BuildLists{
+		Lists: map[string]List{
+			"ubuntu":  {Builds: []string{"1[46]04-*"}},
+			"ci":      {Builds: []string{"tag=ci"}},
+			"all":     {Builds: []string{"@ci", "@ubuntu", "centos7-test"}},
+			"cycle-a": {Builds: []string{"1404-64", "@cycle-b"}},
+			"cycle-b": {Builds: []string{"@all", "@cycle-a"}},
+			"missing": {Builds: []string{"@nope"}},
+			"bad":     {Builds: []string{"release=[16"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		expected []string
+		err      string
+	}{
+		{"ubuntu", []string{"1404-64", "1604-32", "1604-64"}, ""},
+		{"ci", []string{"1404-64", "1604-64", "centos7-64"}, ""},
+		{"all", []string{"1404-64", "1604-64", "centos7-64", "1604-32", "centos7-test"}, ""},
+		{"cycle-a", nil, "build list cycle: cycle-a -> cycle-b -> cycle-a"},
+		{"missing", nil, "nope: unknown build list"},
+		{"bad", nil, `bad: selector: "release=[16": syntax error in pattern`},
+	}
+	for i, test := range tests {
+		names, err := bl.Resolve(test.name)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, names)
+		}
+	}
+}
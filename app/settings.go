@@ -0,0 +1,263 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	cjsn "github.com/mohae/cjson"
+)
+
+// SettingTypeErr occurs when a setting's value isn't of a type that is
+// accepted at its location.  The path is the setting's location within its
+// conf file, e.g. 1604-64.builders.virtualbox-iso.settings.headless.
+type SettingTypeErr struct {
+	Path     string
+	Expected string
+	Got      string
+}
+
+func (e SettingTypeErr) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Path, e.Expected, e.Got)
+}
+
+// UnmarshalJSON decodes a JSON component section.  The settings can either
+// be a list of key=value strings or an object whose values are native JSON
+// types; the latter is resolved by setSettings once the conf file has been
+// decoded.
+func (t *TemplateSection) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	err := cjsn.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	return t.decode(v)
+}
+
+// UnmarshalTOML decodes a TOML component section.  The settings can either
+// be an array of key=value strings or a table whose values are native TOML
+// types; the latter is resolved by setSettings once the conf file has been
+// decoded.
+func (t *TemplateSection) UnmarshalTOML(v interface{}) error {
+	return t.decode(v)
+}
+
+// decode sets the section's fields from its decoded, untyped, value.  The
+// settings are kept as is until setSettings is called.  A null section is
+// left as is.
+func (t *TemplateSection) decode(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return SettingTypeErr{Path: "section", Expected: "an object", Got: settingTypeName(v)}
+	}
+	if typ, ok := m["type"]; ok && typ != nil {
+		s, ok := typ.(string)
+		if !ok {
+			return SettingTypeErr{Path: "type", Expected: "a string", Got: settingTypeName(typ)}
+		}
+		t.Type = s
+	}
+	if arrays, ok := m["arrays"]; ok && arrays != nil {
+		a, ok := arrays.(map[string]interface{})
+		if !ok {
+			return SettingTypeErr{Path: "arrays", Expected: "an object", Got: settingTypeName(arrays)}
+		}
+		t.Arrays = a
+	}
+	if merge, ok := m["merge"]; ok && merge != nil {
+		mm, ok := merge.(map[string]interface{})
+		if !ok {
+			return SettingTypeErr{Path: "merge", Expected: "an object", Got: settingTypeName(merge)}
+		}
+		t.Merge = make(map[string]string, len(mm))
+		for k, v := range mm {
+			s, ok := v.(string)
+			if !ok {
+				return SettingTypeErr{Path: "merge." + k, Expected: "a string", Got: settingTypeName(v)}
+			}
+			t.Merge[k] = s
+		}
+	}
+	if pt, ok := m["passthrough"]; ok && pt != nil {
+		b, ok := pt.(bool)
+		if !ok {
+			return SettingTypeErr{Path: "passthrough", Expected: "a bool", Got: settingTypeName(pt)}
+		}
+		t.Passthrough = b
+	}
+	if res, ok := m["resources"]; ok && res != nil {
+		l, ok := res.([]interface{})
+		if !ok {
+			return SettingTypeErr{Path: "resources", Expected: "a list of setting names", Got: settingTypeName(res)}
+		}
+		t.Resources = make([]string, 0, len(l))
+		for i, v := range l {
+			s, ok := v.(string)
+			if !ok {
+				return SettingTypeErr{Path: fmt.Sprintf("resources[%d]", i), Expected: "a setting name", Got: settingTypeName(v)}
+			}
+			t.Resources = append(t.Resources, s)
+		}
+	}
+	t.rawSettings = m["settings"]
+	return nil
+}
+
+// setSettings resolves the section's decoded settings, which are at path.
+// Settings that are a list of key=value strings are used as is.  For
+// settings that are an object, each string, bool, and number becomes a
+// key=value setting and each list and object is added to the arrays;
+// defining a setting in both is an error.  The settings are processed in key
+// order.
+func (t *TemplateSection) setSettings(path string) error {
+	v := t.rawSettings
+	t.rawSettings = nil
+	path += ".settings"
+	switch vv := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		t.Settings = make([]string, 0, len(vv))
+		for i, s := range vv {
+			str, ok := s.(string)
+			if !ok {
+				return SettingTypeErr{Path: fmt.Sprintf("%s[%d]", path, i), Expected: "a key=value string", Got: settingTypeName(s)}
+			}
+			t.Settings = append(t.Settings, str)
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		t.Settings = make([]string, 0, len(vv))
+		for _, k := range keys {
+			switch val := vv[k].(type) {
+			case []interface{}, []map[string]interface{}, map[string]interface{}:
+				if _, ok := t.Arrays[k]; ok {
+					return Error{slug: path + "." + k, err: fmt.Errorf("also defined in arrays")}
+				}
+				if t.Arrays == nil {
+					t.Arrays = map[string]interface{}{}
+				}
+				t.Arrays[k] = val
+			default:
+				s, err := settingString(path+"."+k, val)
+				if err != nil {
+					return err
+				}
+				t.Settings = append(t.Settings, k+"="+s)
+			}
+		}
+		return nil
+	}
+	return SettingTypeErr{Path: path, Expected: "a list of key=value strings or an object", Got: settingTypeName(v)}
+}
+
+// settingString returns the value of the setting at path in its key=value
+// string form.
+func settingString(path string, v interface{}) (string, error) {
+	switch vv := v.(type) {
+	case string:
+		return vv, nil
+	case bool:
+		return strconv.FormatBool(vv), nil
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64), nil
+	case int64:
+		return strconv.FormatInt(vv, 10), nil
+	}
+	return "", SettingTypeErr{Path: path, Expected: "a string, bool, number, list, or object", Got: settingTypeName(v)}
+}
+
+// parseBool returns the bool value of a setting.  A setting without a value,
+// e.g. `rackconnect_wait`, is false; any other value that isn't a bool is an
+// error instead of silently being false.
+func parseBool(v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// stringSlice returns the list of strings of the array at path.  Arrays that
+// were decoded from a conf file are a []interface{}; each of their values must
+// be a string.  The returned slice is a copy.
+func stringSlice(path string, v interface{}) ([]string, error) {
+	switch vv := v.(type) {
+	case []string:
+		s := make([]string, len(vv))
+		copy(s, vv)
+		return s, nil
+	case []interface{}:
+		s := make([]string, len(vv))
+		for i, x := range vv {
+			str, ok := x.(string)
+			if !ok {
+				return nil, SettingTypeErr{Path: fmt.Sprintf("%s[%d]", path, i), Expected: "a string", Got: settingTypeName(x)}
+			}
+			s[i] = str
+		}
+		return s, nil
+	}
+	return nil, SettingTypeErr{Path: path, Expected: "a list of strings", Got: settingTypeName(v)}
+}
+
+// settingTypeName returns the name of a decoded value's type, as it is
+// referred to in conf files.
+func settingTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64, int64:
+		return "number"
+	case []interface{}, []map[string]interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	case time.Time:
+		return "datetime"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// setSettings resolves the settings of each of the build's components.  The
+// prefix is the path of the build within its conf file; it is empty if the
+// build is at the top level, e.g. in the defaults file.
+func (b *Build) setSettings(prefix string) error {
+	if prefix != "" {
+		prefix += "."
+	}
+	for k, v := range b.Builders {
+		err := v.setSettings(prefix + "builders." + k)
+		if err != nil {
+			return err
+		}
+		b.Builders[k] = v
+	}
+	for k, v := range b.PostProcessors {
+		err := v.setSettings(prefix + "post_processors." + k)
+		if err != nil {
+			return err
+		}
+		b.PostProcessors[k] = v
+	}
+	for k, v := range b.Provisioners {
+		err := v.setSettings(prefix + "provisioners." + k)
+		if err != nil {
+			return err
+		}
+		b.Provisioners[k] = v
+	}
+	return nil
+}
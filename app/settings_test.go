@@ -0,0 +1,225 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	cjsn "github.com/mohae/cjson"
+)
+
+func TestSetSettings(t *testing.T) {
+	tests := []struct {
+		json     string
+		toml     string
+		expected map[string]BuilderC
+		err      string
+	}{
+		{
+			`{"builders": {"vbox": {"type": "virtualbox-iso", "settings": ["headless=true", "disk_size = 20000"]}}}`,
+			"[builders.vbox]\ntype = \"virtualbox-iso\"\nsettings = [\"headless=true\", \"disk_size = 20000\"]\n",
+			map[string]BuilderC{
+				"vbox": {TemplateSection{Type: "virtualbox-iso", Settings: []string{"headless=true", "disk_size = 20000"}}},
+			},
+			"",
+		},
+		{
+			`{"builders": {"vbox": {"settings": {"headless": true, "disk_size": 20000, "ratio": 1.5, "boot_wait": "5s", "iso_urls": ["a", "b"]}, "arrays": {"vboxmanage": ["x"]}}}}`,
+			"[builders.vbox.settings]\nheadless = true\ndisk_size = 20000\nratio = 1.5\nboot_wait = \"5s\"\niso_urls = [\"a\", \"b\"]\n[builders.vbox.arrays]\nvboxmanage = [\"x\"]\n",
+			map[string]BuilderC{
+				"vbox": {TemplateSection{
+					Settings: []string{"boot_wait=5s", "disk_size=20000", "headless=true", "ratio=1.5"},
+					Arrays: map[string]interface{}{
+						"iso_urls":   []interface{}{"a", "b"},
+						"vboxmanage": []interface{}{"x"},
+					},
+				}},
+			},
+			"",
+		},
+		{
+			`{"builders": {"hv": {"type": "hyperv-iso", "passthrough": true, "resources": ["floppy_files"], "settings": ["generation = 2"]}}}`,
+			"[builders.hv]\ntype = \"hyperv-iso\"\npassthrough = true\nresources = [\"floppy_files\"]\nsettings = [\"generation = 2\"]\n",
+			map[string]BuilderC{
+				"hv": {TemplateSection{Type: "hyperv-iso", Settings: []string{"generation = 2"}, Passthrough: true, Resources: []string{"floppy_files"}}},
+			},
+			"",
+		},
+		{
+			`{"builders": {"vbox": {"settings": [20000]}}}`,
+			"[builders.vbox]\nsettings = [20000]\n",
+			nil,
+			"test.builders.vbox.settings[0]: expected a key=value string, got number",
+		},
+		{
+			`{"builders": {"vbox": {"settings": "headless=true"}}}`,
+			"[builders.vbox]\nsettings = \"headless=true\"\n",
+			nil,
+			"test.builders.vbox.settings: expected a list of key=value strings or an object, got string",
+		},
+		{
+			`{"builders": {"vbox": {"settings": {"iso_urls": ["a"]}, "arrays": {"iso_urls": ["b"]}}}}`,
+			"[builders.vbox.settings]\niso_urls = [\"a\"]\n[builders.vbox.arrays]\niso_urls = [\"b\"]\n",
+			nil,
+			"test.builders.vbox.settings.iso_urls: also defined in arrays",
+		},
+		{
+			`{"builders": {"vbox": {"settings": {"boot_wait": null}}}}`,
+			"[builders.vbox.settings]\nboot_wait = 1979-05-27T07:32:00Z\n",
+			nil,
+			"test.builders.vbox.settings.boot_wait: expected a string, bool, number, list, or object, got ",
+		},
+	}
+	for i, test := range tests {
+		for _, format := range []string{"json", "toml"} {
+			var b Build
+			var err error
+			if format == "json" {
+				err = cjsn.Unmarshal([]byte(test.json), &b)
+			} else {
+				_, err = toml.Decode(test.toml, &b)
+			}
+			if err != nil {
+				t.Errorf("%d: %s: expected no error, got %q", i, format, err)
+				continue
+			}
+			err = b.setSettings("test")
+			if err != nil {
+				if test.err == "" {
+					t.Errorf("%d: %s: expected no error, got %q", i, format, err)
+					continue
+				}
+				// the type names differ between the formats for values that
+				// only one of them has.
+				if !strings.HasPrefix(err.Error(), test.err) {
+					t.Errorf("%d: %s: expected %q, got %q", i, format, test.err, err)
+				}
+				continue
+			}
+			if test.err != "" {
+				t.Errorf("%d: %s: expected %q, got none", i, format, test.err)
+				continue
+			}
+			if !reflect.DeepEqual(b.Builders, test.expected) {
+				t.Errorf("%d: %s: expected %#v, got %#v", i, format, test.expected, b.Builders)
+			}
+		}
+	}
+}
+
+func TestStringSlice(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected []string
+		err      string
+	}{
+		{[]string{"A=b", "C=d"}, []string{"A=b", "C=d"}, ""},
+		{[]interface{}{"A=b", "C=d"}, []string{"A=b", "C=d"}, ""},
+		{[]interface{}{}, []string{}, ""},
+		{[]interface{}{"A=b", float64(1)}, nil, "environment_vars[1]: expected a string, got number"},
+		{"A=b", nil, "environment_vars: expected a list of strings, got string"},
+		{map[string]interface{}{"A": "b"}, nil, "environment_vars: expected a list of strings, got object"},
+	}
+	for i, test := range tests {
+		s, err := stringSlice("environment_vars", test.v)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(s, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, s)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		v        string
+		expected bool
+		err      string
+	}{
+		{"", false, ""},
+		{"true", true, ""},
+		{"1", true, ""},
+		{"false", false, ""},
+		{"maybe", false, "strconv.ParseBool: parsing \"maybe\": invalid syntax"},
+	}
+	for i, test := range tests {
+		b, err := parseBool(test.v)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if b != test.expected {
+			t.Errorf("%d: expected %t, got %t", i, test.expected, b)
+		}
+	}
+}
+
+// The lists in a settings object are decoded as []interface{}; the
+// components that expect a list of strings must accept them.
+func TestSettingsObjectLists(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected map[string]interface{}
+		err      string
+	}{
+		{
+			`{"provisioners": {"shell": {"type": "shell", "settings": {"inline": ["echo hi"], "environment_vars": ["A=b"]}}}}`,
+			map[string]interface{}{"type": "shell", "inline": []string{"echo hi"}, "environment_vars": []string{"A=b"}},
+			"",
+		},
+		{
+			`{"provisioners": {"shell": {"type": "shell", "settings": {"inline": ["echo hi"], "environment_vars": ["A=b", 1]}}}}`,
+			nil,
+			"shell: shell: environment_vars[1]: expected a string, got number",
+		},
+		{
+			`{"provisioners": {"shell": {"type": "shell", "settings": {"inline": [["echo hi"]]}}}}`,
+			nil,
+			"shell: shell: inline[0]: expected a string, got list",
+		},
+	}
+	for i, test := range tests {
+		var b Build
+		err := cjsn.Unmarshal([]byte(test.json), &b)
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		err = b.setSettings("")
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		r := newRawTemplate()
+		r.Provisioners = b.Provisioners
+		settings, err := r.createProvisioner("shell")
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(settings, test.expected) {
+			t.Errorf("%d: expected %#v, got %#v", i, test.expected, settings)
+		}
+	}
+}
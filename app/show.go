@@ -0,0 +1,170 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// MergedTemplate is a build's configuration after the defaults, the supported
+// distro's settings, and the build's settings have been merged and all of the
+// Feedlot variables have been replaced.  The common builder's settings are
+// merged into each builder's settings, as they are when the Packer template
+// is created.  Chain is the build's resolved inheritance chain: the builds
+// whose settings were merged, in order, ending with the build itself.  Files
+// and Dirs map each resource's destination to its resolved source.
+type MergedTemplate struct {
+	Chain []string `toml:"chain" json:"chain"`
+	PackerInf
+	IODirInf
+	BuildInf
+	Distro  string            `toml:"distro" json:"distro"`
+	Arch    string            `toml:"arch" json:"arch"`
+	Image   string            `toml:"image" json:"image"`
+	Release string            `toml:"release" json:"release"`
+	VarVals map[string]string `toml:"var_vals" json:"var_vals"`
+	Build
+	Files map[string]string `toml:"files" json:"files"`
+	Dirs  map[string]string `toml:"dirs" json:"dirs"`
+}
+
+// ShowBuild returns the fully merged configuration of the named build.  The
+// build goes through the same process as BuildBuilds, so that all of the
+// variables and resources are resolved, but nothing is written.
+func ShowBuild(name string) (MergedTemplate, error) {
+	if !DistroDefaults.IsSet {
+		log.Debug("loading distro defaults")
+		err := DistroDefaults.Set()
+		if err != nil {
+			err = fmt.Errorf("show build failed: %s", err)
+			log.Error(err)
+			return MergedTemplate{}, err
+		}
+	}
+	err := loadBuilds()
+	if err != nil {
+		err = fmt.Errorf("show build failed: %s", err)
+		log.Error(err)
+		return MergedTemplate{}, err
+	}
+	rTpl, err := buildRawTemplate(name)
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return MergedTemplate{}, err
+	}
+	// Creating the Packer template resolves the variables and the sources of
+	// all the resources.
+	_, err = rTpl.createPackerTemplate()
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return MergedTemplate{}, err
+	}
+	m, err := rTpl.merged()
+	if err != nil {
+		err = Error{name, err}
+		log.Error(err)
+		return MergedTemplate{}, err
+	}
+	return m, nil
+}
+
+// merged returns the template's configuration with all of the variables in the
+// component settings and arrays replaced.  The template's variables must
+// already be merged.
+func (r *RawTemplate) merged() (MergedTemplate, error) {
+	m := MergedTemplate{
+		Chain:     r.chain,
+		PackerInf: r.PackerInf,
+		IODirInf:  r.IODirInf,
+		BuildInf:  r.BuildInf,
+		Distro:    r.Distro,
+		Arch:      r.Arch,
+		Image:     r.Image,
+		Release:   r.Release,
+		VarVals:   r.VarVals,
+		Build: Build{
+			BuilderIDs:       r.BuilderIDs,
+			Builders:         make(map[string]BuilderC, len(r.Builders)),
+			PostProcessorIDs: r.PostProcessorIDs,
+			PostProcessors:   make(map[string]PostProcessorC, len(r.PostProcessors)),
+			ProvisionerIDs:   r.ProvisionerIDs,
+			Provisioners:     make(map[string]ProvisionerC, len(r.Provisioners)),
+		},
+		Files: r.Files,
+		Dirs:  r.Dirs,
+	}
+	common, hasCommon := r.Builders[Common.String()]
+	for ID, b := range r.Builders {
+		if ID == Common.String() {
+			continue
+		}
+		settings := b.Settings
+		if hasCommon {
+			var err error
+			settings, err = mergeSettingsSlices(common.Settings, b.Settings)
+			if err != nil {
+				return m, BuilderErr{id: ID, Err: err}
+			}
+		}
+		m.Builders[ID] = BuilderC{r.replaceSectionVariables(b.TemplateSection, settings)}
+	}
+	for ID, p := range r.PostProcessors {
+		m.PostProcessors[ID] = PostProcessorC{r.replaceSectionVariables(p.TemplateSection, p.Settings)}
+	}
+	for ID, p := range r.Provisioners {
+		m.Provisioners[ID] = ProvisionerC{r.replaceSectionVariables(p.TemplateSection, p.Settings)}
+	}
+	return m, nil
+}
+
+// replaceSectionVariables returns a TemplateSection with the passed section's
+// type, passthrough settings, and arrays and the passed settings after the
+// variables in the setting values and the array values have been replaced.
+func (r *RawTemplate) replaceSectionVariables(sec TemplateSection, settings []string) TemplateSection {
+	t := TemplateSection{Type: sec.Type, Passthrough: sec.Passthrough, Resources: sec.Resources}
+	arrays := sec.Arrays
+	if settings != nil {
+		t.Settings = make([]string, len(settings))
+		for i, s := range settings {
+			k, v := parseVar(s)
+			t.Settings[i] = fmt.Sprintf("%s = %s", k, r.replaceVariables(v))
+		}
+	}
+	if arrays != nil {
+		t.Arrays = make(map[string]interface{}, len(arrays))
+		for k, v := range arrays {
+			t.Arrays[k] = r.replaceValueVariables(v)
+		}
+	}
+	return t
+}
+
+// replaceValueVariables replaces the variables in all of the strings within
+// the passed value and returns the result.
+func (r *RawTemplate) replaceValueVariables(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case string:
+		return r.replaceVariables(vv)
+	case []string:
+		s := make([]string, len(vv))
+		for i, x := range vv {
+			s[i] = r.replaceVariables(x)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = r.replaceValueVariables(x)
+		}
+		return s
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, x := range vv {
+			m[k] = r.replaceValueVariables(x)
+		}
+		return m
+	}
+	return v
+}
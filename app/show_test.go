@@ -0,0 +1,101 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawTemplateMerged(t *testing.T) {
+	r := newRawTemplate()
+	r.Delim = ":"
+	r.Distro = "ubuntu"
+	r.Release = "14.04"
+	r.VarVals = map[string]string{
+		":distro":  "ubuntu",
+		":release": "14.04",
+	}
+	r.BuilderIDs = []string{"virtualbox-iso"}
+	r.Builders = map[string]BuilderC{
+		"common": {
+			TemplateSection{
+				Type: "common",
+				Settings: []string{
+					"boot_wait = 5s",
+					"ssh_username = vagrant",
+				},
+			},
+		},
+		"virtualbox-iso": {
+			TemplateSection{
+				Type: "virtualbox-iso",
+				Settings: []string{
+					"boot_wait = 10s",
+					"vm_name = :distro-:release",
+				},
+				Arrays: map[string]interface{}{
+					"vboxmanage": []interface{}{
+						[]interface{}{"modifyvm", "{{.Name}}", "--description", ":distro"},
+					},
+				},
+			},
+		},
+	}
+	r.ProvisionerIDs = []string{"shell"}
+	r.Provisioners = map[string]ProvisionerC{
+		"shell": {
+			TemplateSection{
+				Type: "shell",
+				Arrays: map[string]interface{}{
+					"scripts": []string{":distro/setup.sh"},
+				},
+			},
+		},
+	}
+	r.Files = map[string]string{"out/shell/setup.sh": "src/ubuntu/setup.sh"}
+	expected := MergedTemplate{
+		Distro:  "ubuntu",
+		Release: "14.04",
+		VarVals: r.VarVals,
+		Build: Build{
+			BuilderIDs: []string{"virtualbox-iso"},
+			Builders: map[string]BuilderC{
+				"virtualbox-iso": {
+					TemplateSection{
+						Type: "virtualbox-iso",
+						Settings: []string{
+							"boot_wait = 10s",
+							"ssh_username = vagrant",
+							"vm_name = ubuntu-14.04",
+						},
+						Arrays: map[string]interface{}{
+							"vboxmanage": []interface{}{
+								[]interface{}{"modifyvm", "{{.Name}}", "--description", "ubuntu"},
+							},
+						},
+					},
+				},
+			},
+			PostProcessors: map[string]PostProcessorC{},
+			ProvisionerIDs: []string{"shell"},
+			Provisioners: map[string]ProvisionerC{
+				"shell": {
+					TemplateSection{
+						Type: "shell",
+						Arrays: map[string]interface{}{
+							"scripts": []string{"ubuntu/setup.sh"},
+						},
+					},
+				},
+			},
+		},
+		Files: r.Files,
+		Dirs:  r.Dirs,
+	}
+	m, err := r.merged()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %#v, got %#v", expected, m)
+	}
+}
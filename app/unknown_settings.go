@@ -0,0 +1,233 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+	"github.com/mohae/feedlot/log"
+)
+
+// UnknownSettingErr occurs when a component has a setting, or an array, that
+// it doesn't support.  Suggestions are the supported settings whose names are
+// close to the unknown setting's, if any.
+type UnknownSettingErr struct {
+	Key         string
+	Suggestions []string
+}
+
+func (e UnknownSettingErr) Error() string {
+	s := e.Key + ": unknown setting"
+	switch len(e.Suggestions) {
+	case 0:
+		return s
+	case 1:
+		return s + "; did you mean " + e.Suggestions[0] + "?"
+	}
+	return s + "; did you mean " + strings.Join(e.Suggestions[:len(e.Suggestions)-1], ", ") + " or " + e.Suggestions[len(e.Suggestions)-1] + "?"
+}
+
+// communicatorSettings are the settings that the builders use to configure
+// their communicator; see processCommunicator.
+var communicatorSettings = []string{
+	"communicator",
+	"ssh_bastion_host", "ssh_bastion_password", "ssh_bastion_port",
+	"ssh_bastion_private_key_file", "ssh_bastion_username", "ssh_disable_agent",
+	"ssh_handshake_attempts", "ssh_host", "ssh_password", "ssh_port",
+	"ssh_private_key_file", "ssh_pty", "ssh_timeout", "ssh_username",
+	"winrm_host", "winrm_insecure", "winrm_password", "winrm_port",
+	"winrm_timeout", "winrm_use_ssl", "winrm_username",
+}
+
+// postProcessorCommonSettings are the settings that every post-processor
+// supports.
+var postProcessorCommonSettings = []string{"except", "keep_input_artifact", "only"}
+
+// provisionerCommonSettings are the settings that every provisioner
+// supports; see builderFilters.
+var provisionerCommonSettings = []string{"except", "only", "override"}
+
+// keys returns the names, sorted, of the section's settings and arrays.
+func (t TemplateSection) keys() []string {
+	var keys []string
+	for _, s := range t.Settings {
+		k, _ := parseVar(s)
+		if k != "" && !contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range t.Arrays {
+		if !contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unknownSettings returns an error for each of the keys that isn't in any of
+// the known lists.  Each error's suggestions are the known keys that are
+// close to it.
+func unknownSettings(keys []string, known ...[]string) []UnknownSettingErr {
+	var errs []UnknownSettingErr
+	for _, k := range keys {
+		var ok bool
+		for _, l := range known {
+			if contains(l, k) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			errs = append(errs, UnknownSettingErr{Key: k, Suggestions: suggestSettings(k, known...)})
+		}
+	}
+	return errs
+}
+
+// maxSuggestions is the maximum number of suggestions for an unknown setting.
+const maxSuggestions = 3
+
+// suggestSettings returns the known keys, closest first, that are within a
+// third of the key's length, but at least 1, edits of the key.
+func suggestSettings(key string, known ...[]string) []string {
+	max := len(key) / 3
+	if max < 1 {
+		max = 1
+	}
+	type suggestion struct {
+		key  string
+		dist int
+	}
+	var sugs []suggestion
+	for _, l := range known {
+		for _, k := range l {
+			d := editDistance(key, k)
+			if d > max {
+				continue
+			}
+			var dup bool
+			for _, s := range sugs {
+				if s.key == k {
+					dup = true
+					break
+				}
+			}
+			if !dup {
+				sugs = append(sugs, suggestion{key: k, dist: d})
+			}
+		}
+	}
+	sort.Slice(sugs, func(i, j int) bool {
+		if sugs[i].dist != sugs[j].dist {
+			return sugs[i].dist < sugs[j].dist
+		}
+		return sugs[i].key < sugs[j].key
+	})
+	if len(sugs) > maxSuggestions {
+		sugs = sugs[:maxSuggestions]
+	}
+	var keys []string
+	for _, s := range sugs {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// editDistance returns the number of single character insertions, deletions,
+// substitutions, and transpositions of adjacent characters that it takes to
+// turn a into b.
+func editDistance(a, b string) int {
+	// d[i][j] is the distance between a[:i] and b[:j].
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// minInt returns the smallest of the passed ints.
+func minInt(v int, vals ...int) int {
+	for _, x := range vals {
+		if x < v {
+			v = x
+		}
+	}
+	return v
+}
+
+// unknownSetting handles an unknown setting error, err.  In strict mode, it is
+// returned.  Otherwise, it is a warning, see warn, and nil is returned; the
+// setting is left out of the Packer template.
+func (r *RawTemplate) unknownSetting(err error) error {
+	return r.strictErr(err)
+}
+
+// strictErr handles a problem, err, that Packer may still be able to deal
+// with.  In strict mode, it is returned.  Otherwise, it is a warning, see
+// warn, and nil is returned.
+func (r *RawTemplate) strictErr(err error) error {
+	if contour.GetBool(conf.Strict) {
+		return err
+	}
+	r.warn(err)
+	return nil
+}
+
+// warn logs the warning, err, and adds it to the template's warnings.
+func (r *RawTemplate) warn(err error) {
+	log.Warnf("%s: %s", r.Name, err)
+	r.warnings = append(r.warnings, err.Error())
+}
+
+// checkCommonBuilder checks the common builder's settings.  They are merged
+// into the settings of each builder, so a setting is only unknown if none of
+// the build's builders support it.  A passthrough builder, and a builder
+// whose factory doesn't list its settings, supports every setting.
+func (r *RawTemplate) checkCommonBuilder() error {
+	common, ok := r.Builders[Common.String()]
+	if !ok || len(r.BuilderIDs) == 0 {
+		return nil
+	}
+	known := [][]string{communicatorSettings}
+	for _, ID := range r.BuilderIDs {
+		bldr, ok := r.Builders[ID]
+		if !ok {
+			continue
+		}
+		if bldr.Passthrough {
+			return nil
+		}
+		// if any of the builders' settings aren't known, neither are the
+		// common settings that it supports.
+		l, ok := builderFactories[strings.ToLower(bldr.Type)].(SettingsLister)
+		if !ok {
+			return nil
+		}
+		known = append(known, l.Settings())
+	}
+	for _, e := range unknownSettings(common.keys(), known...) {
+		err := r.unknownSetting(BuilderErr{Builder: Common, Err: e})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
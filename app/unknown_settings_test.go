@@ -0,0 +1,120 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/conf"
+)
+
+func TestSuggestSettings(t *testing.T) {
+	known := []string{"disk_size", "disk_type_id", "format", "headless", "http_port_max", "http_port_min"}
+	tests := []struct {
+		key      string
+		expected []string
+	}{
+		{"disk_szie", []string{"disk_size"}},
+		{"disksize", []string{"disk_size"}},
+		{"fromat", []string{"format"}},
+		{"http_port_mix", []string{"http_port_max", "http_port_min"}},
+		{"headles", []string{"headless"}},
+		{"memory", nil},
+		{"vm", nil},
+	}
+	for i, test := range tests {
+		sugs := suggestSettings(test.key, known)
+		if !reflect.DeepEqual(sugs, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, sugs)
+		}
+	}
+}
+
+func TestUnknownSettingErr(t *testing.T) {
+	tests := []struct {
+		err      UnknownSettingErr
+		expected string
+	}{
+		{UnknownSettingErr{Key: "memory"}, "memory: unknown setting"},
+		{UnknownSettingErr{Key: "disk_szie", Suggestions: []string{"disk_size"}}, "disk_szie: unknown setting; did you mean disk_size?"},
+		{UnknownSettingErr{Key: "http_port_mix", Suggestions: []string{"http_port_max", "http_port_min"}}, "http_port_mix: unknown setting; did you mean http_port_max or http_port_min?"},
+		{UnknownSettingErr{Key: "a", Suggestions: []string{"b", "c", "d"}}, "a: unknown setting; did you mean b, c or d?"},
+	}
+	for i, test := range tests {
+		if test.err.Error() != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, test.err.Error())
+		}
+	}
+}
+
+func TestUnknownSettings(t *testing.T) {
+	strict := contour.GetBool(conf.Strict)
+	defer contour.UpdateBool(conf.Strict, strict)
+	tests := []struct {
+		strict   bool
+		settings []string
+		arrays   map[string]interface{}
+		warnings []string
+		err      string
+	}{
+		{false, []string{"output = out.tar.gz", "compression_level = 6"}, nil, nil, ""},
+		{false, []string{"output = out.tar.gz", "compresion_level = 6"}, nil, []string{"compress: out: compresion_level: unknown setting; did you mean compression_level?"}, ""},
+		{false, []string{"output = out.tar.gz", "keep_input_artifact = true"}, map[string]interface{}{"onyl": []string{"virtualbox-iso"}}, []string{"compress: out: onyl: unknown setting; did you mean only?"}, ""},
+		{true, []string{"output = out.tar.gz", "compression_level = 6"}, nil, nil, ""},
+		{true, []string{"output = out.tar.gz", "compresion_level = 6", "levels = 2"}, nil, nil, "compress: out: compresion_level: unknown setting; did you mean compression_level?; levels: unknown setting"},
+	}
+	for i, test := range tests {
+		contour.UpdateBool(conf.Strict, test.strict)
+		r := newRawTemplate()
+		r.BuilderIDs = []string{"virtualbox-iso"}
+		r.PostProcessors = map[string]PostProcessorC{
+			"out": {TemplateSection{Type: "compress", Settings: test.settings, Arrays: test.arrays}},
+		}
+		_, err := r.createPostProcessor("out")
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(r.warnings, test.warnings) {
+			t.Errorf("%d: expected %v, got %v", i, test.warnings, r.warnings)
+		}
+	}
+}
+
+func TestCheckCommonBuilder(t *testing.T) {
+	strict := contour.GetBool(conf.Strict)
+	defer contour.UpdateBool(conf.Strict, strict)
+	contour.UpdateBool(conf.Strict, false)
+	tests := []struct {
+		builderIDs []string
+		warnings   []string
+	}{
+		{[]string{"virtualbox-iso"}, []string{"common: disk_szie: unknown setting; did you mean disk_size?"}},
+		{[]string{"virtualbox-iso", "docker"}, []string{"common: disk_szie: unknown setting; did you mean disk_size?"}},
+		{[]string{"docker"}, []string{"common: disk_szie: unknown setting", "common: iso_url: unknown setting"}},
+		{nil, nil},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.BuilderIDs = test.builderIDs
+		r.Builders = map[string]BuilderC{
+			"common":         {TemplateSection{Settings: []string{"disk_szie = 20000", "iso_url = http://example.com", "ssh_username = vagrant"}}},
+			"docker":         {TemplateSection{Type: "docker"}},
+			"virtualbox-iso": {TemplateSection{Type: "virtualbox-iso"}},
+		}
+		err := r.checkCommonBuilder()
+		if err != nil {
+			t.Errorf("%d: expected no error, got %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(r.warnings, test.warnings) {
+			t.Errorf("%d: expected %v, got %v", i, test.warnings, r.warnings)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mohae/feedlot/log"
+)
+
+// Validation is the result of validating a build: the build's name, its
+// resolved inheritance chain, and all of the problems found with it.  The
+// chain is the builds whose settings were merged, in order, ending with the
+// build itself; it is empty if the chain couldn't be resolved.  Warnings are
+// the unknown component settings and undeclared user variables found, when
+// not in strict mode; they don't make the build invalid.
+type Validation struct {
+	Name     string
+	Chain    []string
+	Errs     []error
+	Warnings []string
+}
+
+// Valid returns whether or not the build is valid, i.e. no problems were
+// found with it.
+func (v Validation) Valid() bool {
+	return len(v.Errs) == 0
+}
+
+// ValidateBuilds checks the passed builds, or every build if no build names
+// are passed, for problems.  Each build goes through the same process as
+// BuildBuilds, except that the Packer template is never written out, so the
+// prior build's output is left alone.  All of the problems found with a build
+// are returned, not just the first one.  An error is only returned if the
+// builds couldn't be checked, e.g. the builds couldn't be loaded.
+func ValidateBuilds(buildNames ...string) ([]Validation, error) {
+	if !DistroDefaults.IsSet {
+		log.Debug("loading distro defaults")
+		err := DistroDefaults.Set()
+		if err != nil {
+			err = fmt.Errorf("validate builds failed: %s", err)
+			log.Error(err)
+			return nil, err
+		}
+	}
+	err := loadBuilds()
+	if err != nil {
+		err = fmt.Errorf("validate builds failed: %s", err)
+		log.Error(err)
+		return nil, err
+	}
+	if len(buildNames) == 0 {
+		buildNames = allBuildNames()
+	} else {
+		buildNames = expandBuildNames(buildNames)
+	}
+	log.Infof("validate builds: %v", buildNames)
+	vals := make([]Validation, len(buildNames))
+	for i, name := range buildNames {
+		vals[i] = validateBuild(name)
+	}
+	return vals, nil
+}
+
+// validateBuild checks the named build for problems.
+func validateBuild(name string) Validation {
+	v := Validation{Name: name}
+	rTpl, err := buildRawTemplate(name)
+	if err != nil {
+		log.Errorf("%s: %s", name, err)
+		v.Errs = []error{err}
+		return v
+	}
+	v.Chain = rTpl.chain
+	v.Errs = rTpl.validate()
+	v.Warnings = rTpl.warnings
+	for _, err := range v.Errs {
+		log.Errorf("%s: %s", name, err)
+	}
+	return v
+}
+
+// componentErrs returns the problems in a component's error, err: an error
+// for each of the problems found with its settings, see SettingErrs, or err.
+func componentErrs(err error) []error {
+	var errs []error
+	switch e := err.(type) {
+	case BuilderErr:
+		for _, err := range errList(e.Err) {
+			errs = append(errs, BuilderErr{id: e.id, Builder: e.Builder, Err: err})
+		}
+	case PostProcessorErr:
+		for _, err := range errList(e.Err) {
+			errs = append(errs, PostProcessorErr{id: e.id, PostProcessor: e.PostProcessor, Err: err})
+		}
+	case ProvisionerErr:
+		for _, err := range errList(e.Err) {
+			errs = append(errs, ProvisionerErr{id: e.id, Provisioner: e.Provisioner, Err: err})
+		}
+	default:
+		errs = []error{err}
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestRawTemplateValidate(t *testing.T) {
+	r := newRawTemplate()
+	r.Delim = ":"
+	r.Name = "validate-test"
+	r.BuilderIDs = []string{"virtualbox-iso"}
+	r.PostProcessorIDs = []string{"compress", "vagrant"}
+	r.PostProcessors = map[string]PostProcessorC{
+		"compress": {
+			TemplateSection{
+				Type: "compress",
+				Settings: []string{
+					"compression_level = six",
+					"output = out.tar.gz",
+					"keep_input_artifact = maybe",
+				},
+				Arrays: map[string]interface{}{
+					"only": []string{"vmware-iso"},
+				},
+			},
+		},
+	}
+	r.ProvisionerIDs = []string{"unknown"}
+	r.Provisioners = map[string]ProvisionerC{
+		"unknown": {
+			TemplateSection{
+				Type: "unknown",
+			},
+		},
+	}
+	_, ierr := strconv.Atoi("six")
+	_, perr := strconv.ParseBool("maybe")
+	// every problem with the compress post-processor's settings is found.
+	expected := []error{
+		BuilderErr{id: "virtualbox-iso", Err: ErrBuilderNotFound},
+		PostProcessorErr{id: "compress", PostProcessor: Compress, Err: SettingErr{"compression_level", "six", ierr}},
+		PostProcessorErr{id: "compress", PostProcessor: Compress, Err: SettingErr{"keep_input_artifact", "maybe", perr}},
+		PostProcessorErr{id: "compress", PostProcessor: Compress, Err: Error{slug: "only", err: errors.New("vmware-iso: builder not in builder_ids")}},
+		PostProcessorErr{id: "vagrant", Err: ErrPostProcessorNotFound},
+		InvalidComponentErr{cTyp: "provisioner", s: "unknown"},
+	}
+	errs := r.validate()
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i].Error() {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], err)
+		}
+	}
+}
+
+func TestValidationValid(t *testing.T) {
+	tests := []struct {
+		v        Validation
+		expected bool
+	}{
+		{Validation{Name: "test"}, true},
+		{Validation{Name: "test", Errs: []error{ErrBuilderNotFound}}, false},
+	}
+	for i, test := range tests {
+		if test.v.Valid() != test.expected {
+			t.Errorf("%d: expected %t, got %t", i, test.expected, test.v.Valid())
+		}
+	}
+}
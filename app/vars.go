@@ -0,0 +1,348 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvVarPrefix is the prefix of the Feedlot variables whose values come from
+// environment variables, e.g. :env.HTTP_PROXY.
+const EnvVarPrefix = "env."
+
+// ErrUndefinedVar occurs when a variable is referenced but not defined.
+var ErrUndefinedVar = errors.New("undefined variable")
+
+// UndefinedVarErr occurs when a setting references a variable that isn't
+// defined.
+type UndefinedVarErr struct {
+	Setting string
+	Name    string
+}
+
+func (e UndefinedVarErr) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Setting, e.Name, ErrUndefinedVar)
+}
+
+// VarErr is an error resolving a user-defined Feedlot variable.
+type VarErr struct {
+	name string
+	Err  error
+}
+
+func (e VarErr) Error() string {
+	return fmt.Sprintf("var %s: %s", e.name, e.Err)
+}
+
+// builtinVars are the names of the variables that Feedlot defines; the
+// vars can't redefine them.
+var builtinVars = []string{
+	"arch",
+	"build_name",
+	"date",
+	"distro",
+	"image",
+	"name",
+	"packer_output_dir",
+	"release",
+	"source_dir",
+	"template_output_dir",
+}
+
+// isVarNameChar returns whether c can be part of a variable's name.
+func isVarNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// isVarNameStart returns whether a variable's name can start with c.
+func isVarNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// varToken is a token of a string that may contain variable references:
+// either literal text or a reference to the variable, name.
+type varToken struct {
+	text string
+	name string
+	ref  bool
+}
+
+// tokenizeVars splits s into literal text and variable references.  A
+// reference is the delim followed by the variable's name, which starts with a
+// letter or an underscore and is the longest run of letters, digits, and
+// underscores that follows; the name of an environment variable reference
+// also has the EnvVarPrefix, e.g. env.HTTP_PROXY.  The delim right after a
+// name ends the reference, and is dropped, when a letter, digit, or
+// underscore, or another reference, follows it, e.g. :name:_suffix and
+// :distro::release.  Otherwise, a doubled delim is an escaped, literal,
+// delim.  A delim that isn't any of these is literal text.
+func tokenizeVars(s, delim string) []varToken {
+	var toks []varToken
+	var text []byte
+	for i := 0; i < len(s); {
+		if delim == "" || !strings.HasPrefix(s[i:], delim) {
+			text = append(text, s[i])
+			i++
+			continue
+		}
+		i += len(delim)
+		if strings.HasPrefix(s[i:], delim) {
+			text = append(text, delim...)
+			i += len(delim)
+			continue
+		}
+		start := i
+		if strings.HasPrefix(s[i:], EnvVarPrefix) {
+			i += len(EnvVarPrefix)
+		}
+		if i >= len(s) || !isVarNameStart(s[i]) {
+			text = append(text, delim...)
+			i = start
+			continue
+		}
+		for i < len(s) && isVarNameChar(s[i]) {
+			i++
+		}
+		if len(text) > 0 {
+			toks = append(toks, varToken{text: string(text)})
+			text = nil
+		}
+		toks = append(toks, varToken{name: s[start:i], ref: true})
+		if closesVarRef(s[i:], delim) {
+			i += len(delim)
+		}
+	}
+	if len(text) > 0 {
+		toks = append(toks, varToken{text: string(text)})
+	}
+	return toks
+}
+
+// closesVarRef returns whether s, the rest of the string after a variable's
+// name, starts with a closing delim: a delim followed by either a letter,
+// digit, or underscore or another variable reference.
+func closesVarRef(s, delim string) bool {
+	if !strings.HasPrefix(s, delim) {
+		return false
+	}
+	s = s[len(delim):]
+	if s != "" && isVarNameChar(s[0]) {
+		return true
+	}
+	if !strings.HasPrefix(s, delim) {
+		return false
+	}
+	s = strings.TrimPrefix(s[len(delim):], EnvVarPrefix)
+	return s != "" && isVarNameStart(s[0])
+}
+
+// expandVars returns s with each variable reference replaced by the value
+// that lookup returns for it; see tokenizeVars.  References that lookup
+// doesn't find are left as is and their names are returned, in the order
+// they are referenced.
+func expandVars(s, delim string, lookup func(name string) (string, bool)) (string, []string) {
+	if delim == "" || !strings.Contains(s, delim) {
+		return s, nil
+	}
+	var buf []byte
+	var undefined []string
+	for _, tok := range tokenizeVars(s, delim) {
+		if !tok.ref {
+			buf = append(buf, tok.text...)
+			continue
+		}
+		v, ok := lookup(tok.name)
+		if !ok {
+			undefined = append(undefined, tok.name)
+			v = delim + tok.name
+		}
+		buf = append(buf, v...)
+	}
+	return string(buf), undefined
+}
+
+// varRefs returns the names of the variables referenced in s, in the order
+// they are referenced.
+func varRefs(s, delim string) []string {
+	_, refs := expandVars(s, delim, func(string) (string, bool) { return "", false })
+	return refs
+}
+
+// lookupVar returns the value of the variable with the passed name.  The
+// values of environment variable references come from the environment.
+func (r *RawTemplate) lookupVar(name string) (string, bool) {
+	if strings.HasPrefix(name, EnvVarPrefix) {
+		return os.LookupEnv(strings.TrimPrefix(name, EnvVarPrefix))
+	}
+	v, ok := r.VarVals[r.Delim+name]
+	return v, ok
+}
+
+// replaceSettingVars returns the value, v, of the setting, k, with its
+// variables replaced.  Each reference to a variable that isn't defined is
+// left as is and recorded; see varErr.
+func (r *RawTemplate) replaceSettingVars(k, v string) string {
+	s, undefined := expandVars(v, r.Delim, r.lookupVar)
+	for _, name := range undefined {
+		r.varErrs = append(r.varErrs, UndefinedVarErr{Setting: k, Name: r.Delim + name})
+	}
+	return s
+}
+
+// replaceSettingValueVars returns the value, v, of the setting or array, k,
+// with the variables in all of the strings within it replaced; see
+// replaceSettingVars.
+func (r *RawTemplate) replaceSettingValueVars(k string, v interface{}) interface{} {
+	switch vv := v.(type) {
+	case string:
+		return r.replaceSettingVars(k, vv)
+	case []string:
+		s := make([]string, len(vv))
+		for i, x := range vv {
+			s[i] = r.replaceSettingVars(fmt.Sprintf("%s[%d]", k, i), x)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = r.replaceSettingValueVars(fmt.Sprintf("%s[%d]", k, i), x)
+		}
+		return s
+	case []map[string]interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = r.replaceSettingValueVars(fmt.Sprintf("%s[%d]", k, i), x)
+		}
+		return s
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for key, x := range vv {
+			m[key] = r.replaceSettingValueVars(k+"."+key, x)
+		}
+		return m
+	}
+	return v
+}
+
+// varErrors returns the undefined variable errors recorded by
+// replaceSettingVars, sorted by setting and then variable name, and clears
+// them.  The arrays are maps; sorting keeps the errors in the same order
+// from run to run.
+func (r *RawTemplate) varErrors() []error {
+	sort.Slice(r.varErrs, func(i, j int) bool {
+		if r.varErrs[i].Setting != r.varErrs[j].Setting {
+			return r.varErrs[i].Setting < r.varErrs[j].Setting
+		}
+		return r.varErrs[i].Name < r.varErrs[j].Name
+	})
+	var errs []error
+	for i, err := range r.varErrs {
+		// a setting's value may be replaced more than once.
+		if i > 0 && err == r.varErrs[i-1] {
+			continue
+		}
+		errs = append(errs, err)
+	}
+	r.varErrs = nil
+	return errs
+}
+
+// updateVars merges the passed Feedlot vars with the template's; a var's
+// value is replaced by the new one.
+func (r *RawTemplate) updateVars(vars []string) error {
+	for _, v := range vars {
+		k, vv := parseVar(v)
+		r.recordOrigin("vars", k, vv)
+	}
+	var err error
+	r.Vars, err = mergeSettingsSlices(r.Vars, vars)
+	if err != nil {
+		return Error{slug: "merge vars", err: err}
+	}
+	return nil
+}
+
+// resolveVars resolves the values of the template's name, its dirs, and its
+// vars and adds them to the VarVals.  The base variables must already be
+// set.  The values may reference each other, the base variables, and
+// environment variables; they are resolved in dependency order.  A cycle is
+// an error, as is a var whose value references a variable that isn't
+// defined.  For compatibility, references in the name and dirs to undefined
+// variables are left as is.
+func (r *RawTemplate) resolveVars() error {
+	raw := map[string]string{
+		"name":                r.Name,
+		"source_dir":          r.SourceDir,
+		"template_output_dir": r.TemplateOutputDir,
+		"packer_output_dir":   r.PackerOutputDir,
+	}
+	user := map[string]bool{}
+	for _, v := range r.Vars {
+		k, vv := parseVar(v)
+		if k == "" || !isVarNameStart(k[0]) || strings.IndexFunc(k, func(c rune) bool { return c > 0x7f || !isVarNameChar(byte(c)) }) >= 0 {
+			return VarErr{name: k, Err: errors.New("invalid name: must start with a letter or underscore and only contain letters, digits, and underscores")}
+		}
+		if contains(builtinVars, k) {
+			return VarErr{name: k, Err: errors.New("can't redefine a Feedlot variable")}
+		}
+		raw[k] = vv
+		user[k] = true
+	}
+	names := make([]string, 0, len(raw))
+	for k := range raw {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	const (
+		unvisited = iota
+		visiting
+		resolved
+	)
+	state := map[string]int{}
+	var path []string
+	var resolve func(name string) error
+	resolve = func(name string) error {
+		switch state[name] {
+		case resolved:
+			return nil
+		case visiting:
+			// the cycle starts at the first occurrence of name in the path.
+			for i, p := range path {
+				if p == name {
+					return VarErr{name: name, Err: fmt.Errorf("cycle: %s", strings.Join(append(path[i:], name), " -> "))}
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range varRefs(raw[name], r.Delim) {
+			if _, ok := raw[ref]; ok {
+				err := resolve(ref)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		v, undefined := expandVars(raw[name], r.Delim, r.lookupVar)
+		if len(undefined) > 0 && user[name] {
+			return VarErr{name: name, Err: fmt.Errorf("%s: %s", undefined[0], ErrUndefinedVar)}
+		}
+		r.VarVals[r.Delim+name] = v
+		path = path[:len(path)-1]
+		state[name] = resolved
+		return nil
+	}
+	for _, name := range names {
+		err := resolve(name)
+		if err != nil {
+			return err
+		}
+	}
+	r.Name = r.VarVals[r.Delim+"name"]
+	r.SourceDir = r.VarVals[r.Delim+"source_dir"]
+	r.TemplateOutputDir = r.VarVals[r.Delim+"template_output_dir"]
+	r.PackerOutputDir = r.VarVals[r.Delim+"packer_output_dir"]
+	return nil
+}
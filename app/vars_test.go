@@ -0,0 +1,171 @@
+package app
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	vals := map[string]string{
+		"distro":      "ubuntu",
+		"name":        "1604-64",
+		"name_suffix": "box",
+		"env.HOME":    "/home/vagrant",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := vals[name]
+		return v, ok
+	}
+	tests := []struct {
+		s         string
+		delim     string
+		expected  string
+		undefined []string
+	}{
+		{"http", ":", "http", nil},
+		{"src/:distro/commands", ":", "src/ubuntu/commands", nil},
+		{":name-:name_suffix", ":", "1604-64-box", nil},
+		{":name.:name_suffix", ":", "1604-64.box", nil},
+		{":env.HOME/src", ":", "/home/vagrant/src", nil},
+		{"::distro is :distro", ":", ":distro is ubuntu", nil},
+		{"{{.HTTPIP}}:{{.HTTPPort}}", ":", "{{.HTTPIP}}:{{.HTTPPort}}", nil},
+		{"http://:distro.com:", ":", "http://ubuntu.com:", nil},
+		{":release-:distro-:env.NOPE", ":", ":release-ubuntu-:env.NOPE", []string{"release", "env.NOPE"}},
+		{"$$distro-$$$$distro", "$$", "ubuntu-$$distro", nil},
+		{":name:_suffix", ":", "1604-64_suffix", nil},
+		{":distro:name", ":", "ubuntuname", nil},
+		{":distro::name", ":", "ubuntu1604-64", nil},
+		{":distro::env.HOME", ":", "ubuntu/home/vagrant", nil},
+		{":distro:", ":", "ubuntu:", nil},
+		{":distro:-", ":", "ubuntu:-", nil},
+		{"http://:distro:8080", ":", "http://ubuntu8080", nil},
+		{"http://:distro::8080", ":", "http://ubuntu:8080", nil},
+		{"localhost:8080", ":", "localhost:8080", nil},
+		{":_distro", ":", ":_distro", []string{"_distro"}},
+		{":env.", ":", ":env.", nil},
+	}
+	for i, test := range tests {
+		s, undefined := expandVars(test.s, test.delim, lookup)
+		if s != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, s)
+		}
+		if !reflect.DeepEqual(undefined, test.undefined) {
+			t.Errorf("%d: expected undefined %q, got %q", i, test.undefined, undefined)
+		}
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	os.Setenv("FEEDLOT_TEST_PROXY", "http://proxy:3128")
+	defer os.Unsetenv("FEEDLOT_TEST_PROXY")
+	tests := []struct {
+		vars      []string
+		sourceDir string
+		expected  map[string]string
+		err       string
+	}{
+		{
+			[]string{"box = :box_name.box", "box_name = :distro-:release", "proxy = :env.FEEDLOT_TEST_PROXY"},
+			"src/:box_name",
+			map[string]string{
+				":box":        "ubuntu-16.04.box",
+				":box_name":   "ubuntu-16.04",
+				":proxy":      "http://proxy:3128",
+				":source_dir": "src/ubuntu-16.04",
+			},
+			"",
+		},
+		{
+			[]string{"out = :source_dir/out"},
+			"src/:distro",
+			map[string]string{":out": "src/ubuntu/out", ":source_dir": "src/ubuntu"},
+			"",
+		},
+		{[]string{"price = ::5"}, "src", map[string]string{":price": ":5", ":source_dir": "src"}, ""},
+		{[]string{"a = :b", "b = :c", "c = :a"}, "src", nil, "var a: cycle: a -> b -> c -> a"},
+		{[]string{"a = :a"}, "src", nil, "var a: cycle: a -> a"},
+		{[]string{"dir = :source_dir"}, "src/:dir", nil, "var dir: cycle: dir -> source_dir -> dir"},
+		{[]string{"a = :b"}, "src", nil, "var a: b: undefined variable"},
+		{[]string{"a = :env.FEEDLOT_TEST_NOT_SET"}, "src", nil, "var a: env.FEEDLOT_TEST_NOT_SET: undefined variable"},
+		{[]string{"distro = centos"}, "src", nil, "var distro: can't redefine a Feedlot variable"},
+		{[]string{"my-var = x"}, "src", nil, "var my-var: invalid name: must start with a letter or underscore and only contain letters, digits, and underscores"},
+		{[]string{"2nd = x"}, "src", nil, "var 2nd: invalid name: must start with a letter or underscore and only contain letters, digits, and underscores"},
+	}
+	for i, test := range tests {
+		r := newRawTemplate()
+		r.Delim = ":"
+		r.Distro = "ubuntu"
+		r.Release = "16.04"
+		r.Name = ":distro"
+		r.SourceDir = test.sourceDir
+		r.Vars = test.vars
+		err := r.mergeVariables()
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		for k, v := range test.expected {
+			if r.VarVals[k] != v {
+				t.Errorf("%d: %s: expected %q, got %q", i, k, v, r.VarVals[k])
+			}
+		}
+		if r.SourceDir != test.expected[":source_dir"] {
+			t.Errorf("%d: expected source dir to be %q, got %q", i, test.expected[":source_dir"], r.SourceDir)
+		}
+		if r.Name != "ubuntu" {
+			t.Errorf("%d: expected name to be %q, got %q", i, "ubuntu", r.Name)
+		}
+	}
+}
+
+func TestUndefinedSettingVars(t *testing.T) {
+	r := newRawTemplate()
+	r.Delim = ":"
+	r.Distro = "ubuntu"
+	r.Release = "16.04"
+	r.Vars = []string{"box = :distro-:release"}
+	err := r.mergeVariables()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	tests := []struct {
+		settings []string
+		expected interface{}
+		err      string
+	}{
+		{[]string{"output = :box:_final.tar.gz"}, "ubuntu-16.04_final.tar.gz", ""},
+		{[]string{"output = :box-:nope.tar.gz"}, nil, "compress: out: output: :nope: undefined variable"},
+		{[]string{"compression_level = 6", "output = :nope:_:box"}, nil, "compress: out: output: :nope: undefined variable"},
+		// a literal delim in a value must be escaped.
+		{[]string{"output = ubuntu::latest"}, "ubuntu:latest", ""},
+		{[]string{"output = chown vagrant::vagrant :box"}, "chown vagrant:vagrant ubuntu-16.04", ""},
+		{[]string{"output = ubuntu:latest"}, nil, "compress: out: output: :latest: undefined variable"},
+		{[]string{"output = chown vagrant:vagrant"}, nil, "compress: out: output: :vagrant: undefined variable"},
+	}
+	for i, test := range tests {
+		r.PostProcessors = map[string]PostProcessorC{
+			"out": {TemplateSection{Type: "compress", Settings: test.settings}},
+		}
+		settings, err := r.createPostProcessor("out")
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%d: expected %q, got %q", i, test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%d: expected %q, got none", i, test.err)
+			continue
+		}
+		if settings["output"] != test.expected {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, settings["output"])
+		}
+	}
+}
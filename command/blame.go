@@ -0,0 +1,103 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+	json "github.com/mohae/unsafejson"
+)
+
+// BlameCommand is a Command implementation that shows where each of a build's
+// settings came from.
+type BlameCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the blame sub-command.
+func (c *BlameCommand) Help() string {
+	helpText := `
+Usage: feedlot blame [options] <buildName>
+
+Shows every setting of a build, its final value, and where that value came
+from: the layer, default, supported, or build, the file, and, for component
+settings, the section it was defined in. Any values that were replaced by
+the final value are listed below it. The values are shown as they were
+defined; Feedlot variables are not replaced.
+
+	$ feedlot blame 1404-amd64-server
+	$ feedlot blame -format=json 1404-amd64-server
+
+Options:
+-format=<text|json>	The output format; defaults to text. For the blame
+			sub-command, this is the output format, not the
+			conf format; use -f to set the conf format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the blame sub-command, handling all passed args and flags.
+func (c *BlameCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	// -format is the output format for blame, so pull it out before contour
+	// gets the args.
+	format, args := commandFlag("format", args)
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if len(filteredArgs) != 1 {
+		c.UI.Error("blame: exactly one build name is required")
+		return 1
+	}
+	blames, err := app.BlameBuild(filteredArgs[0])
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	switch strings.ToLower(format) {
+	case "", "text":
+		c.UI.Output(blameText(blames))
+	case "json":
+		b, err := json.MarshalIndent(blames, "", "\t")
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.UI.Output(string(b))
+	default:
+		c.UI.Error(fmt.Sprintf("blame: %s: unsupported output format", format))
+		return 1
+	}
+	return 0
+}
+
+// Synopsis provides a precis of the blame sub-command.
+func (c *BlameCommand) Synopsis() string {
+	return "Show where each of a build's settings came from."
+}
+
+// blameText returns the blames as text: one setting per line followed by the
+// values it replaced, most recent first, indented.
+func blameText(blames []app.Blame) string {
+	var buf bytes.Buffer
+	for _, b := range blames {
+		fmt.Fprintf(&buf, "%s = %s (%s)\n", b.Key, b.Value, b.Origin)
+		for i := len(b.Replaced) - 1; i >= 0; i-- {
+			fmt.Fprintf(&buf, "    replaced %s (%s)\n", b.Replaced[i].Value, b.Replaced[i])
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
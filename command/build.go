@@ -39,6 +39,17 @@ For builds using the -distro flag, the -arch, -image, and -release flags are
 optional. If any of them are missing, the distribution's default value for that
 flag will be used.
 
+Builds can also be selected by their attributes, using the -select flag, or
+all builds can be built, using the -all flag:
+
+	$ feedlot build -select='distro=ubuntu,tag=ci,!tag=experimental'
+	$ feedlot build -all
+
+A selector is a comma separated list of key=pattern terms; a build is selected
+if it matches every term. The keys are name, distro, release, arch, image, and
+tag; the patterns are glob patterns. A term that starts with a ! selects the
+builds that don't match it.
+
 Options:
 -distro=<distroName>	Create a Packer template from the distro's default
 			settings. The -arch, -image, and -release flags can be
@@ -52,9 +63,18 @@ Options:
 
 -release=<releaseNum>	Override the distro's default release with this flag.
 			The actual values are determined by the distro.
+-select=<selector>      Build the builds that the selector selects.
+-all=bool               true/false: build every build.
+-dry-run=bool           true/false: generate the Packer templates in memory and
+                        show how they, and their resources, differ from what is
+                        in the template output directory. Nothing is written,
+                        copied, archived, or deleted.
 -envs=<list of envs>    Include builds from the specified feedlot environments.
 -eg=bool                true/false: create builds from examples; generates
                         example Packer templates.
+-strict=bool            true/false: settings that a component doesn't support
+                        and undeclared user variables are errors instead
+                        of warnings.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -69,6 +89,10 @@ func (c *BuildCommand) Run(args []string) int {
 	var err error
 	var filteredArgs []string
 	var message string
+	// -select and -all choose builds, so pull them out before contour gets
+	// the args.
+	selector, args := commandFlag("select", args)
+	all, args := commandBoolFlag("all", args)
 	filteredArgs, err = contour.FilterArgs(args)
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -88,6 +112,29 @@ func (c *BuildCommand) Run(args []string) int {
 		}
 	}
 
+	// Add the selected builds, if any, to the passed builds.
+	if selector != "" || all {
+		selected, err := app.SelectBuilds(selector)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		if len(selected) == 0 {
+			c.UI.Error(fmt.Sprintf("no builds match the selector: %q", selector))
+			return 1
+		}
+		// a build that was also passed by name is only built once.
+		passed := make(map[string]bool, len(filteredArgs))
+		for _, name := range filteredArgs {
+			passed[name] = true
+		}
+		for _, name := range selected {
+			if !passed[name] {
+				filteredArgs = append(filteredArgs, name)
+			}
+		}
+	}
+
 	// If there were any builds passed, build them.
 	if len(filteredArgs) > 0 {
 		tmp, err := app.BuildBuilds(filteredArgs...)
@@ -0,0 +1,169 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+)
+
+// ConvertCommand is a Command implementation that converts Feedlot conf files
+// from one format to another.
+type ConvertCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the convert sub-command.
+func (c *ConvertCommand) Help() string {
+	helpText := `
+Usage: feedlot convert -to=<format> [options] <confFile...>
+       feedlot convert -check <confFile|confDir> <confFile|confDir>
+
+Converts Feedlot conf files, e.g. default, supported, build, build_list, or
+the application's conf file, from one format to another. The format of each
+file is determined by its extension. Every setting in the file is carried
+over; comments are carried over if the target format supports them, which
+JSON does not. Any comments that couldn't be carried over are reported.
+
+If a single file is converted and -out isn't set, the result is written to
+stdout. Otherwise, each converted file is written to the -out directory,
+using the file's name with the target format's extension.
+
+With -check, the two files, or the files in the two directories, are compared
+instead: the settings and their values are compared regardless of the files'
+formats, comments and formatting are not. Files in directories are matched by
+their name without the extension. If any of the files differ, the exit status
+will be non-zero.
+
+	$ feedlot convert -to=toml conf/default.cjsn
+	$ feedlot convert -to=yaml -out=conf/yaml conf/json/build.cjsn conf/json/build_list.cjsn
+	$ feedlot convert -check example/conf/json example/conf/toml
+
+Options:
+-to=<format>		The format to convert to: json, cjsn, toml, or yaml.
+
+-out=<dir>		The directory to write the converted files to.
+
+-force			Replace existing files in the -out directory.
+
+-check			Compare the files instead of converting them.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the convert sub-command, handling all passed args and flags.
+func (c *ConvertCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	// the convert flags aren't conf settings, so pull them out before contour
+	// gets the args.
+	to, args := commandFlag("to", args)
+	out, args := commandFlag("out", args)
+	force, args := commandBoolFlag("force", args)
+	check, args := commandBoolFlag("check", args)
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if check {
+		return c.check(filteredArgs)
+	}
+	if to == "" {
+		c.UI.Error("convert: the format to convert to, -to, is required")
+		return 1
+	}
+	if len(filteredArgs) == 0 {
+		c.UI.Error("convert: at least one conf file is required")
+		return 1
+	}
+	to = strings.ToLower(to)
+	if out == "" {
+		if len(filteredArgs) > 1 {
+			c.UI.Error("convert: -out is required when converting more than one file")
+			return 1
+		}
+		conv, err := app.ConvertConf(filteredArgs[0], to)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.UI.Output(strings.TrimSuffix(string(conv.Content), "\n"))
+		c.dropped(conv)
+		return 0
+	}
+	convs, err := app.ConvertConfFiles(filteredArgs, to, out, force)
+	for _, conv := range convs {
+		c.UI.Output(fmt.Sprintf("%s: converted to %s", conv.Src, conv.Dst))
+		c.dropped(conv)
+	}
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// Synopsis provides a precis of the convert sub-command.
+func (c *ConvertCommand) Synopsis() string {
+	return "Convert Feedlot conf files from one format to another."
+}
+
+// dropped reports the comments that couldn't be carried over.
+func (c *ConvertCommand) dropped(conv app.Conversion) {
+	if len(conv.Dropped) == 0 {
+		return
+	}
+	c.UI.Error(fmt.Sprintf("%s: %d comment lines dropped:", conv.Src, len(conv.Dropped)))
+	for _, l := range conv.Dropped {
+		c.UI.Error(fmt.Sprintf("\t%s", l))
+	}
+}
+
+// check compares the two conf files, or dirs, and reports the differences.
+func (c *ConvertCommand) check(args []string) int {
+	if len(args) != 2 {
+		c.UI.Error("convert: -check requires two conf files or directories")
+		return 1
+	}
+	comps, err := app.CompareConf(args[0], args[1])
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	var diff int
+	for _, comp := range comps {
+		if comp.Equal() {
+			c.UI.Output(fmt.Sprintf("%s: same", comp.Name))
+			continue
+		}
+		diff++
+		switch {
+		case comp.Err != nil:
+			c.UI.Error(fmt.Sprintf("%s: %s", comp.Name, comp.Err))
+		case comp.A == "":
+			c.UI.Error(fmt.Sprintf("%s: only in %s", comp.Name, args[1]))
+		case comp.B == "":
+			c.UI.Error(fmt.Sprintf("%s: only in %s", comp.Name, args[0]))
+		default:
+			c.UI.Error(fmt.Sprintf("%s: %d differences found", comp.Name, len(comp.Changes)))
+			for _, ch := range comp.Changes {
+				c.UI.Error(fmt.Sprintf("\t%s", ch))
+			}
+		}
+	}
+	if diff > 0 {
+		c.UI.Error(fmt.Sprintf("%d of %d conf files differ", diff, len(comps)))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	json "github.com/mohae/unsafejson"
+)
+
+// encode returns v encoded in the requested format: either json or toml.  If
+// the format is empty, json is used.
+func encode(v interface{}, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		b, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case "toml":
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(v)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return "", fmt.Errorf("%s: unsupported output format", format)
+}
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// commandFlag removes the named flag, and its value, from args and returns
+// the value along with the remaining args.  This is for sub-command flags that
+// aren't registered with contour, e.g. the -format flag for list output would
+// otherwise be used as the conf format.  The flag can be in either the
+// -name=value or -name value form and can have either 1 or 2 leading dashes.
+// If the flag is not found, an empty string is returned.
+func commandFlag(name string, args []string) (string, []string) {
+	var v string
+	filtered := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if arg == args[i] {
+			filtered = append(filtered, args[i])
+			continue
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			v = strings.TrimPrefix(arg, name+"=")
+			continue
+		}
+		if arg == name && i+1 < len(args) {
+			v = args[i+1]
+			i++
+			continue
+		}
+		filtered = append(filtered, args[i])
+	}
+	return v, filtered
+}
+
+// commandBoolFlag removes the named bool flag from args and returns its value
+// along with the remaining args.  The flag can be in either the -name or the
+// -name=bool form; the value is never taken from the next arg.  If the flag
+// is not found, or its value can't be parsed, false is returned.
+func commandBoolFlag(name string, args []string) (bool, []string) {
+	var v bool
+	filtered := make([]string, 0, len(args))
+	for _, a := range args {
+		arg := strings.TrimLeft(a, "-")
+		if arg == a {
+			filtered = append(filtered, a)
+			continue
+		}
+		if arg == name {
+			v = true
+			continue
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			v, _ = strconv.ParseBool(strings.TrimPrefix(arg, name+"="))
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return v, filtered
+}
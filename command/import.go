@@ -0,0 +1,109 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+)
+
+// ImportCommand is a Command implementation that creates a Feedlot build from
+// a Packer template.
+type ImportCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the import sub-command.
+func (c *ImportCommand) Help() string {
+	helpText := `
+Usage: feedlot import [options] <packerTemplate> [buildName]
+
+Creates a Feedlot build from an existing Packer JSON template. The build is
+written to a new file, named after the build, in the conf_dir using the conf
+format. If a build name isn't passed, the template's filename, without its
+extension, is used.
+
+Each builder, post-processor, and provisioner becomes a section in the build:
+simple values are added to the section's settings, as key = value, and
+everything else is added to the section's arrays. Settings that match the
+distro's defaults are left out. Scripts, http directories, and other
+resources that the template references are copied to
+source_dir/distro/build_name, where Feedlot will find them.
+
+	$ feedlot import -distro=ubuntu ubuntu-server.json
+	$ feedlot import -distro=centos -release=7 centos.json 7-x86_64-minimal
+
+Options:
+-distro=<distroName>	The distro that the build targets; this is required.
+
+-arch=<architecture>	The build's architecture; if not set, the distro's
+			default is used.
+
+-image=<imageType>	The build's image; if not set, the distro's default is
+			used.
+
+-release=<releaseNum>	The build's release; if not set, the distro's default
+			is used.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the import sub-command, handling all passed args and flags.
+func (c *ImportCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if len(filteredArgs) < 1 || len(filteredArgs) > 2 {
+		c.UI.Error("import: a Packer template and, optionally, a build name are required")
+		return 1
+	}
+	var name string
+	if len(filteredArgs) == 2 {
+		name = filteredArgs[1]
+	}
+	res, err := app.ImportTemplate(filteredArgs[0], name)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(importText(res))
+	return 0
+}
+
+// Synopsis provides a precis of the import sub-command.
+func (c *ImportCommand) Synopsis() string {
+	return "Create a Feedlot build from a Packer template."
+}
+
+// importText returns the import result as text.
+func importText(res app.ImportResult) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: imported to %s\n", res.BuildName, res.File)
+	if len(res.Resources) > 0 {
+		buf.WriteString("copied:\n")
+		for _, r := range res.Resources {
+			fmt.Fprintf(&buf, "    %s\n", r)
+		}
+	}
+	if len(res.Warnings) > 0 {
+		buf.WriteString("warnings:\n")
+		for _, w := range res.Warnings {
+			fmt.Fprintf(&buf, "    %s\n", w)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
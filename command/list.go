@@ -0,0 +1,107 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+	json "github.com/mohae/unsafejson"
+)
+
+// ListCommand is a Command implementation that lists the builds, build
+// lists, and supported distros that Feedlot knows about.
+type ListCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the list sub-command.
+func (c *ListCommand) Help() string {
+	helpText := `
+Usage: feedlot list [options]
+
+Lists every build, along with the file it was defined in, its distro, release,
+arch, and builder_ids; every build list and its builds; and the arch, image,
+and release matrix of every supported distro.
+
+	$ feedlot list
+	$ feedlot list -format=json
+
+Options:
+-format=<text|json>	The output format; defaults to text. For the list
+			sub-command, this is the output format, not the
+			conf format; use -f to set the conf format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the list sub-command, handling all passed args and flags.
+func (c *ListCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	// -format is the output format for list, so pull it out before contour
+	// gets the args.
+	format, args := commandFlag("format", args)
+	_, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	inv, err := app.LoadInventory()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	switch strings.ToLower(format) {
+	case "", "text":
+		c.UI.Output(listText(inv))
+	case "json":
+		b, err := json.MarshalIndent(inv, "", "\t")
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.UI.Output(string(b))
+	default:
+		c.UI.Error(fmt.Sprintf("list: %s: unsupported output format", format))
+		return 1
+	}
+	return 0
+}
+
+// Synopsis provides a precis of the list sub-command.
+func (c *ListCommand) Synopsis() string {
+	return "List the builds, build lists, and supported distros."
+}
+
+// listText returns the inventory as aligned columns of text.
+func listText(inv app.Inventory) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "BUILD\tFILE\tDISTRO\tRELEASE\tARCH\tBUILDER_IDS")
+	for _, b := range inv.Builds {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", b.Name, b.File, b.Distro, b.Release, b.Arch, strings.Join(b.BuilderIDs, ", "))
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "BUILD LIST\tBUILDS")
+	for _, l := range inv.BuildLists {
+		fmt.Fprintf(w, "%s\t%s\n", l.Name, strings.Join(l.Builds, ", "))
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "DISTRO\tARCH\tIMAGE\tRELEASE\tDEFAULT IMAGE")
+	for _, d := range inv.Distros {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Name, strings.Join(d.Arch, ", "), strings.Join(d.Image, ", "), strings.Join(d.Release, ", "), strings.Join(d.DefaultImage, ", "))
+	}
+	w.Flush()
+	return strings.TrimSpace(buf.String())
+}
@@ -33,6 +33,11 @@ func (c *RunCommand) Help() string {
 	Options:
 	-eg=bool           true/false: create builds from examples; generates
                        example Packer templates.
+	-dry-run=bool      true/false: show what would change in each build's
+                       template output directory without changing anything.
+	-strict=bool       true/false: settings that a component doesn't support
+                       and undeclared user variables are errors instead
+                       of warnings.
 `
 
 	return strings.TrimSpace(helpText)
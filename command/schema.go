@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+)
+
+// SchemaCommand is a Command implementation that outputs the schemas of the
+// Packer components.
+type SchemaCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the schema sub-command.
+func (c *SchemaCommand) Help() string {
+	helpText := `
+Usage: feedlot schema [options] [componentType...]
+
+Outputs the schemas of the builders, post-processors, and provisioners; every
+component that Feedlot supports is created from its schema. A schema lists
+each setting's name, kind, whether it is required, its default, whether it
+can be a command file, whether it is a resource, its deprecated aliases, its
+valid options, and the settings that it overrides or conflicts with. If
+component types are passed, only their schemas are output.
+
+	$ feedlot schema
+	$ feedlot schema -format=toml vagrant docker
+
+Options:
+-format=<json|toml>	The output format; defaults to json. For the schema
+			sub-command, this is the output format, not the
+			conf format; use -f to set the conf format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the schema sub-command, handling all passed args and flags.
+func (c *SchemaCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	// -format is the output format for schema, so pull it out before
+	// contour gets the args.
+	format, args := commandFlag("format", args)
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	schemas := app.Schemas()
+	if len(filteredArgs) > 0 {
+		var selected []app.ComponentSchema
+		for _, typ := range filteredArgs {
+			var found bool
+			for _, s := range schemas {
+				if s.Type == strings.ToLower(typ) {
+					selected = append(selected, s)
+					found = true
+				}
+			}
+			if !found {
+				c.UI.Error(fmt.Sprintf("schema: %s: no component of this type has a schema", typ))
+				return 1
+			}
+		}
+		schemas = selected
+	}
+	// toml can't encode a top-level array.
+	s, err := encode(struct {
+		Schemas []app.ComponentSchema `toml:"schemas" json:"schemas"`
+	}{schemas}, format)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(s)
+	return 0
+}
+
+// Synopsis provides a precis of the schema sub-command.
+func (c *SchemaCommand) Synopsis() string {
+	return "Output the setting schemas of the Packer components."
+}
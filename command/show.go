@@ -0,0 +1,80 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+)
+
+// ShowCommand is a Command implementation that shows the fully merged
+// configuration of a build.
+type ShowCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the show sub-command.
+func (c *ShowCommand) Help() string {
+	helpText := `
+Usage: feedlot show [options] <buildName>
+
+Shows the final configuration of a build: the defaults, the supported distro's
+settings, and the build's settings after they have been merged and all of the
+Feedlot variables have been replaced. This includes the resolved variable
+values, the settings and arrays of every builder, post-processor, and
+provisioner, and the resolved source of every resource. The common builder's
+settings are shown merged into each builder's settings. Nothing is written.
+
+	$ feedlot show 1404-amd64-server
+	$ feedlot show -format=toml 1404-amd64-server
+
+Options:
+-format=<json|toml>	The output format; defaults to json. For the show
+			sub-command, this is the output format, not the
+			conf format; use -f to set the conf format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the show sub-command, handling all passed args and flags.
+func (c *ShowCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	// -format is the output format for show, so pull it out before contour
+	// gets the args.
+	format, args := commandFlag("format", args)
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if len(filteredArgs) != 1 {
+		c.UI.Error("show: exactly one build name is required")
+		return 1
+	}
+	m, err := app.ShowBuild(filteredArgs[0])
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	s, err := encode(m, format)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(s)
+	return 0
+}
+
+// Synopsis provides a precis of the show sub-command.
+func (c *ShowCommand) Synopsis() string {
+	return "Show the fully merged configuration of a build."
+}
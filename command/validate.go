@@ -0,0 +1,103 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mohae/cli"
+	"github.com/mohae/contour"
+	"github.com/mohae/feedlot/app"
+	"github.com/mohae/feedlot/log"
+)
+
+// ValidateCommand is a Command implementation that checks build templates for
+// problems without generating any Packer templates.
+type ValidateCommand struct {
+	UI cli.Ui
+}
+
+// Help prints the help text for the validate sub-command.
+func (c *ValidateCommand) Help() string {
+	helpText := `
+Usage: feedlot validate [options] [buildName...]
+
+Checks each passed build for problems, e.g. missing required settings, unknown
+builder, post-processor, or provisioner IDs, source files that can't be found,
+and invalid int or bool values. If no build names are passed, every build is
+checked.
+
+The builds go through the same process as the build sub-command, but nothing
+is written: neither the Packer templates nor their resources are created and
+any prior build output is left as is. All of the problems found with a build
+are reported, including every problem with each of its components' settings.
+If any build is invalid, the exit status will be non-zero.
+
+Settings that a component doesn't support are reported as warnings, with the
+supported settings whose names are close to them, as are user variables that
+are referenced but not declared; in strict mode, they are problems.
+
+For builds that extend other builds, the resolved inheritance chain, the
+builds whose settings are merged in the order they are merged, is also shown.
+
+	$ feedlot validate
+	$ feedlot validate 1204-amd64-server 1404-amd64-desktop
+
+Options:
+-eg=bool                true/false: validate the example builds.
+-strict=bool            true/false: settings that a component doesn't support
+                        and undeclared user variables are problems instead
+                        of warnings.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the validate sub-command, handling all passed args and flags.
+func (c *ValidateCommand) Run(args []string) int {
+	contour.SetUsage(func() {
+		c.UI.Output(c.Help())
+	})
+	filteredArgs, err := contour.FilterArgs(args)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	err = log.Set()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	vals, err := app.ValidateBuilds(filteredArgs...)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	var invalid int
+	for _, v := range vals {
+		if len(v.Chain) > 1 {
+			c.UI.Output(fmt.Sprintf("%s: chain: %s", v.Name, strings.Join(v.Chain, " -> ")))
+		}
+		for _, w := range v.Warnings {
+			c.UI.Warn(fmt.Sprintf("%s: warning: %s", v.Name, w))
+		}
+		if v.Valid() {
+			c.UI.Output(fmt.Sprintf("%s: valid", v.Name))
+			continue
+		}
+		invalid++
+		c.UI.Error(fmt.Sprintf("%s: invalid: %d problems found", v.Name, len(v.Errs)))
+		for _, err := range v.Errs {
+			c.UI.Error(fmt.Sprintf("\t%s", err))
+		}
+	}
+	if invalid > 0 {
+		c.UI.Error(fmt.Sprintf("%d of %d builds are invalid", invalid, len(vals)))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("%d builds are valid", len(vals)))
+	return 0
+}
+
+// Synopsis provides a precis of the validate sub-command.
+func (c *ValidateCommand) Synopsis() string {
+	return "Check build templates for problems without creating Packer templates."
+}
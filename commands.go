@@ -15,15 +15,50 @@ var Commands map[string]cli.CommandFactory
 func init() {
 	ui := &cli.BasicUi{Writer: os.Stdout}
 	Commands = map[string]cli.CommandFactory{
+		"blame": func() (cli.Command, error) {
+			return &command.BlameCommand{
+				UI: ui,
+			}, nil
+		},
 		"build": func() (cli.Command, error) {
 			return &command.BuildCommand{
 				UI: ui,
 			}, nil
 		},
+		"convert": func() (cli.Command, error) {
+			return &command.ConvertCommand{
+				UI: ui,
+			}, nil
+		},
+		"import": func() (cli.Command, error) {
+			return &command.ImportCommand{
+				UI: ui,
+			}, nil
+		},
+		"list": func() (cli.Command, error) {
+			return &command.ListCommand{
+				UI: ui,
+			}, nil
+		},
 		"run": func() (cli.Command, error) {
 			return &command.RunCommand{
 				UI: ui,
 			}, nil
 		},
+		"schema": func() (cli.Command, error) {
+			return &command.SchemaCommand{
+				UI: ui,
+			}, nil
+		},
+		"show": func() (cli.Command, error) {
+			return &command.ShowCommand{
+				UI: ui,
+			}, nil
+		},
+		"validate": func() (cli.Command, error) {
+			return &command.ValidateCommand{
+				UI: ui,
+			}, nil
+		},
 	}
 }
@@ -27,6 +27,19 @@ const (
 	ArchivePriorBuild = "archive_prior_build"
 	// Dir is the directory that contains the Feedlot build information.
 	Dir = "conf_dir"
+	// DryRun is a bool that let's Feedlot know that the Packer templates
+	// should be generated in memory and compared with the ones that are
+	// already in the template output directories.  Nothing is written,
+	// copied, archived, or deleted.
+	DryRun = "dry-run"
+	// Envs is a comma separated list of the environments, envs, whose builds
+	// should be loaded in addition to the builds in the Dir.  An env is a
+	// subdirectory of the Dir; its builds are namespaced by the env's name.
+	Envs = "envs"
+	// EnvSeparator is the string that separates an env's name from the names
+	// of the builds within it, e.g. the build server in the env prod is
+	// addressed as prod-server.  The default separator is '-'.
+	EnvSeparator = "env_separator_char"
 	// Example is a bool that let's Feedlot know that the current run is an
 	// example run.  Feedlot will look for the configurations and source in
 	// the configured ExampleDir.
@@ -36,10 +49,11 @@ const (
 	// provide an easy way to generated example Packer templates.
 	ExampleDir = "example_dir"
 	// Format is the format used for the Feedlot configuration files: either
-	// TOML or JSON.  TOML expects all configuration files to have either the
-	// '.toml' or '.tml' extension.  JSON expects all configuration files to have
-	// one of the following extensions: '.json', '.jsn', '.cjsn', or '.cjson'.
-	// JSON is the default format.
+	// TOML, JSON, or YAML.  TOML expects all configuration files to have either
+	// the '.toml' or '.tml' extension.  JSON expects all configuration files to
+	// have one of the following extensions: '.json', '.jsn', '.cjsn', or
+	// '.cjson'.  YAML expects all configuration files to have either the
+	// '.yaml' or '.yml' extension.  JSON is the default format.
 	Format = "format"
 	// ParamDelimStart is the delimiter used to indicate the start of a Feedlot
 	// parameter (variable).  The default start delimiter is ':'.  This is used
@@ -55,6 +69,16 @@ const (
 	// In addition to the ones defined in the docs, none is also a valid value. None means
 	// don't use any flags. By default, log.LstdFlags is used.
 	LogFlags = "log_flags"
+	// Strict is a bool that makes the settings that a component doesn't
+	// support, and the user variables that are referenced but not declared,
+	// errors.  By default, they are logged as warnings; unsupported settings
+	// are left out of the Packer template.
+	Strict = "strict"
+	// TemplateFormat is the format of the Packer templates that are written:
+	// either JSON, '<name>.json', or HCL2, '<name>.pkr.hcl'.  A build's
+	// template_format setting takes precedence over this.  JSON is the
+	// default format.
+	TemplateFormat = "template_format"
 )
 
 var (
@@ -79,6 +103,7 @@ const (
 	UnsupportedConfFormat ConfFormat = iota
 	JSON
 	TOML
+	YAML
 )
 
 // ConfFormat: the configuration file's format.
@@ -88,6 +113,7 @@ var confFormats = [...]string{
 	"unsupported configuration format",
 	"JSON",
 	"TOML",
+	"YAML",
 }
 
 func (c ConfFormat) String() string { return confFormats[c] }
@@ -103,11 +129,33 @@ func ParseConfFormat(s string) ConfFormat {
 		return JSON
 	case "TOML", "TML":
 		return TOML
+	case "YAML", "YML":
+		return YAML
 	default:
 		return UnsupportedConfFormat
 	}
 }
 
+// FileFormat returns the ConfFormat for the file's extension. If the
+// extension isn't one of the supported formats' extensions,
+// UnsupportedConfFormat is returned.
+func FileFormat(name string) ConfFormat {
+	return ParseConfFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// exts returns the file extensions for the format.
+func (c ConfFormat) exts() []string {
+	switch c {
+	case JSON:
+		return []string{"json", "jsn", "cjson", "cjsn", "JSON", "JSN", "CJSON", "CJSN"}
+	case TOML:
+		return []string{"toml", "tml", "TOML", "TML"}
+	case YAML:
+		return []string{"yaml", "yml", "YAML", "YML"}
+	}
+	return nil
+}
+
 type app struct {
 	ConfDir         string `toml:"conf_dir",json:"conf_dir"`
 	Example         bool
@@ -132,17 +180,21 @@ func init() {
 	// missing main application cfg isn't considered an error state.
 	contour.SetErrOnMissingCfg(false)
 	contour.RegisterCfgFile(File, Filename)
-	// shortcuts used: a, d, e, f, i, g, l, n, o, p, r, s, t, v, 	x
+	// shortcuts used: a, d, e, f, i, g, k, l, n, o, p, r, s, t, v, 	x
 	contour.RegisterBoolFlag(ArchivePriorBuild, "v", false, "false", "archive prior build before writing new packer template files")
 	contour.RegisterStringFlag(Dir, "c", "conf/", "conf/", "location of the directory with the feedlot build configuration files")
+	contour.RegisterBoolFlag(DryRun, "n", false, "false", "show what would change without writing any packer template files")
 	contour.RegisterBoolFlag(Example, "x", false, "false", "whether or not to generate from examples")
 	contour.RegisterStringFlag(ExampleDir, "y", "examples/", "examples/", "location of the directory with the example feedlot build configuration files")
-	contour.RegisterStringFlag(Format, "f", JSON.String(), JSON.String(), "the format of the feedlot conf files: toml or json")
+	contour.RegisterStringFlag(Format, "f", JSON.String(), JSON.String(), "the format of the feedlot conf files: toml, json, or yaml")
 	contour.RegisterStringFlag(LogFile, "g", "stderr", "stderr", "log filename")
 	contour.RegisterStringFlag(LogLevel, "l", "error", "error", "log level")
 	contour.RegisterStringFlag(LogFlags, "g", "", "", "'none' for no prefixes; comma separated list of log flags; default: log.LstdFlags")
 	contour.RegisterStringFlag(ParamDelimStart, "p", ":", ":", "the start delimiter for template variabes")
-	contour.RegisterStringFlag("envs", "e", "", "", "additional environments from within which config additional config information should be loaded")
+	contour.RegisterBoolFlag(Strict, "k", false, "false", "treat settings that a component doesn't support and undeclared user variables as errors instead of warnings")
+	contour.RegisterStringFlag(TemplateFormat, "t", "json", "json", "the format of the packer templates: json or hcl2")
+	contour.RegisterStringFlag(Envs, "e", "", "", "additional environments from within which config additional config information should be loaded")
+	contour.RegisterString(EnvSeparator, "-")
 	contour.RegisterStringFlag("distro", "d", "", "", "specifies the distro for which a Packer template using defaults should be created")
 	contour.RegisterStringFlag("arch", "a", "", "", "os arch override for default builds")
 	contour.RegisterStringFlag("image", "i", "", "", "os image override for default builds")
@@ -163,6 +215,7 @@ func init() {
 // Currently supported conf file formats:
 //    TOML
 //    JSON || CJSN
+//    YAML
 func SetAppConfFile() error {
 	// find the actual conf filename, it may have a different extension as
 	// formats can have more than one accepted extension, this is mainly to
@@ -184,32 +237,41 @@ func SetAppConfFile() error {
 // ConfFilename takea a conf file name and checks to see if it exists. If it
 // doesn't exist, it checks to see if the file can be found under an alternate
 // extension by checking what config format Feedlot is set to use and iterating
-// through the list of supported exts for that format.  If a file exists under
-// a particular file + ext combination, that is returned.  If no match is
-// found, the error on the original filename is returned so that the message
-// information is consistent with what is expected.
+// through the list of supported exts for that format.  If it still isn't
+// found, the exts of the other supported formats are checked, in order, so
+// that a conf dir can have files in different formats.  If a file exists
+// under a particular file + ext combination, that is returned along with the
+// format that matches its extension.  If no match is found, the error on the
+// original filename is returned so that the message information is
+// consistent with what is expected.
 func ConfFilename(fname string) (string, ConfFormat, error) {
 	cf := ParseConfFormat(contour.GetString(Format))
 	_, err := os.Stat(fname)
 	if err == nil {
+		if f := FileFormat(fname); f != UnsupportedConfFormat {
+			return fname, f, nil
+		}
 		return fname, cf, nil
 	}
-	// if the file isn't found, look for it according to format extensions
-	var exts []string
-	switch cf {
-	case JSON:
-		exts = []string{"json", "jsn", "cjson", "cjsn", "JSON", "JSN", "CJSON", "CJSN"}
-	case TOML:
-		exts = []string{"toml", "tml", "TOML", "TML"}
-	default:
+	// if the file isn't found, look for it according to format extensions;
+	// the configured format's are checked first.
+	if cf == UnsupportedConfFormat {
 		return "", UnsupportedConfFormat, fmt.Errorf("%s: unsupported conf format", contour.GetString(Format))
 	}
+	formats := []ConfFormat{cf}
+	for _, f := range []ConfFormat{JSON, TOML, YAML} {
+		if f != cf {
+			formats = append(formats, f)
+		}
+	}
 	name := strings.TrimSuffix(fname, filepath.Ext(fname))
-	for _, ext := range exts {
-		n := fmt.Sprintf("%s.%s", name, ext)
-		_, err := os.Stat(n)
-		if err == nil {
-			return n, cf, nil
+	for _, f := range formats {
+		for _, ext := range f.exts() {
+			n := fmt.Sprintf("%s.%s", name, ext)
+			_, err := os.Stat(n)
+			if err == nil {
+				return n, f, nil
+			}
 		}
 	}
 	// nothing found
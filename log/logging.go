@@ -184,3 +184,21 @@ func Debugf(format string, v ...interface{}) {
 	}
 	log.Printf(fmt.Sprintf("debug: %s", format), v...)
 }
+
+// Warn writes a warning entry to the log. If the Level == LogNone, nothing
+// will be written.
+func Warn(v interface{}) {
+	if level == LogNone {
+		return
+	}
+	log.Printf("warning: %v", v)
+}
+
+// Warnf writes a warning entry to the log using the provided format and
+// data. If the Level == LogNone, nothing will be written.
+func Warnf(format string, v ...interface{}) {
+	if level == LogNone {
+		return
+	}
+	log.Printf(fmt.Sprintf("warning: %s", format), v...)
+}